@@ -13,6 +13,7 @@ type Config struct {
 	Postgres config.PostgresConfig `env:", prefix=POSTGRES_"`
 	Welcomer config.WelcomerConfig `env:", prefix=WELCOMER_"`
 	Player   config.PlayerConfig   `env:", prefix=PLAYER_"`
+	Remote   config.RemoteConfig   `env:", prefix=REMOTE_"`
 }
 
 var configInstance = utils.NewLazyConfig[Config]()
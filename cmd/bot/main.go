@@ -20,6 +20,7 @@ import (
 	"github.com/zanz1n/duvua/internal/lang"
 	"github.com/zanz1n/duvua/internal/manager"
 	"github.com/zanz1n/duvua/internal/music"
+	"github.com/zanz1n/duvua/internal/remote"
 	"github.com/zanz1n/duvua/internal/ticket"
 	"github.com/zanz1n/duvua/internal/utils"
 	"github.com/zanz1n/duvua/internal/utils/logger"
@@ -162,12 +163,20 @@ func main() {
 	ticketConfigRepository := ticket.NewPgTicketConfigRepository(db)
 
 	musicRepository := music.NewPgMusicConfigRepository(db)
+	playlistRepository := music.NewPgPlaylistRepository(db)
+	favoriteRepository := music.NewPgFavoriteRepository(db)
+	playLogRepository := music.NewPgPlayLogRepository(db)
+	remoteTokenRepository := music.NewPgRemoteTokenRepository(db)
 
 	musicClient := player.NewPlayerClient(playerGrpc)
 
 	animeApi := anime.NewAnimeApi(nil)
 	translator := lang.NewGoogleTranslatorApi(nil)
 
+	// The tokens are revoked through the server, so it closes their
+	// websockets
+	remoteServer := remote.NewServer(s, musicRepository, remoteTokenRepository, musicClient)
+
 	m := manager.NewManager()
 
 	commands.Wire(m,
@@ -178,7 +187,12 @@ func main() {
 		ticketRepository,
 		ticketConfigRepository,
 		musicRepository,
+		playlistRepository,
+		favoriteRepository,
+		playLogRepository,
+		remoteServer.TokenRepository(),
 		musicClient,
+		cfg.Remote.URL,
 	)
 
 	m.AutoHandle(s)
@@ -202,6 +216,9 @@ func main() {
 		}
 	}()
 
+	remoteCancel := startRemoteServer(remoteServer)
+	defer remoteCancel()
+
 	utils.SetStatus(s, utils.StatusTypeStarting)
 
 	sig := <-endCh
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/zanz1n/duvua/internal/remote"
+)
+
+func startRemoteServer(server *remote.Server) func() {
+	cfg := GetConfig()
+	if !cfg.Remote.Enabled {
+		return func() {}
+	}
+
+	listenAddr := fmt.Sprintf("0.0.0.0:%d", cfg.Remote.ListenPort)
+	httpServer := &http.Server{
+		Addr:              listenAddr,
+		Handler:           server.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		slog.Info("Remote: Listening for http connections", "addr", listenAddr)
+		if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+			slog.Error("Remote: Failed to serve http", "error", err)
+		}
+	}()
+
+	return func() {
+		start := time.Now()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := httpServer.Shutdown(ctx); err != nil {
+			slog.Error(
+				"Failed to close remote http server",
+				"took", time.Since(start).Round(time.Millisecond),
+				"error", err,
+			)
+		} else {
+			slog.Info(
+				"Closed remote http server",
+				"took", time.Since(start).Round(time.Millisecond),
+			)
+		}
+	}
+}
@@ -12,6 +12,8 @@ type Config struct {
 	Discord  config.DiscordConfig `env:", prefix=DISCORD_"`
 	Player   config.PlayerConfig  `env:", prefix=PLAYER_"`
 	Spotify  config.SpotifyConfig `env:", prefix=SPOTIFY_"`
+
+	SoundCloud config.SoundCloudConfig `env:", prefix=SOUNDCLOUD_"`
 }
 
 var configInstance = utils.NewLazyConfig[Config]()
@@ -23,3 +25,15 @@ func GetConfig() *Config {
 func InitConfig() error {
 	return configInstance.Init()
 }
+
+// Only loaded if the play log is enabled, so postgres is not required
+// otherwise
+type PostgresConfig struct {
+	Postgres config.PostgresConfig `env:", prefix=POSTGRES_"`
+}
+
+var postgresConfigInstance = utils.NewLazyConfig[PostgresConfig]()
+
+func GetPostgresConfig() *config.PostgresConfig {
+	return &postgresConfigInstance.Get().Postgres
+}
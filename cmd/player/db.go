@@ -0,0 +1,42 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// The player does not run the migrations, it only uses the tables created by
+// the bot
+func connectToPostgres() *sql.DB {
+	cfg := GetPostgresConfig()
+
+	dbStart := time.Now()
+
+	pgxConfig, err := pgx.ParseConfig(cfg.IntoUri())
+	if err != nil {
+		log.Fatalln("Failed to parse postgres config:", err)
+	}
+
+	cfgId := stdlib.RegisterConnConfig(pgxConfig)
+
+	db, err := sql.Open("pgx/v5", cfgId)
+	if err != nil {
+		log.Fatalln("Failed to connect to postgres:", err)
+	}
+	db.SetMaxIdleConns(cfg.MaxConns)
+
+	if cfg.MinConns > 0 {
+		if err = db.Ping(); err != nil {
+			log.Fatalln("Failed to ping postgres database:", err)
+		}
+	}
+
+	slog.Info("Connected to database", "took", time.Since(dbStart))
+
+	return db
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"log/slog"
 	"net"
+	"net/url"
 	"os"
 	"os/signal"
 	"runtime"
@@ -14,8 +15,10 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/zanz1n/duvua/config"
+	"github.com/zanz1n/duvua/internal/music"
 	"github.com/zanz1n/duvua/internal/player"
 	"github.com/zanz1n/duvua/internal/player/encoder"
+	"github.com/zanz1n/duvua/internal/player/opuscache"
 	"github.com/zanz1n/duvua/internal/player/platform"
 	"github.com/zanz1n/duvua/internal/utils/grpcutils"
 	"github.com/zanz1n/duvua/internal/utils/logger"
@@ -102,18 +105,86 @@ func main() {
 
 	s.LogLevel = logger.SlogLevelToDiscordgo(cfg.LogLevel + 4)
 
-	ytFetcher := platform.NewYoutube(nil, 1)
-	spotifyFetcher, err := platform.NewSpotify(
-		cfg.Spotify.ClientId,
-		cfg.Spotify.ClientSecret,
-		ytFetcher,
-	)
-	if err != nil {
-		log.Fatalln(err)
+	var cache *opuscache.Cache
+	if cfg.Player.CacheDir != "" {
+		cache, err = opuscache.New(cfg.Player.CacheDir, cfg.Player.CacheMaxSize)
+		if err != nil {
+			log.Fatalln("Failed to open opus cache:", err)
+		}
+	}
+
+	fetcher := platform.NewFetcher(cache)
+
+	ytProxies := make([]*url.URL, len(cfg.Player.YoutubeProxies))
+	for i, proxy := range cfg.Player.YoutubeProxies {
+		if ytProxies[i], err = url.Parse(proxy); err != nil {
+			log.Fatalln("Failed to parse youtube proxy url:", err)
+		}
+	}
+
+	ytFetcher := platform.NewYoutube(nil, 1, ytProxies...)
+	if cfg.Player.PlatformEnabled("youtube") {
+		fetcher.Register(ytFetcher)
+	}
+
+	if cfg.Player.PlatformEnabled("spotify") {
+		if cfg.Spotify.ClientId == "" || cfg.Spotify.ClientSecret == "" {
+			log.Fatalln("Spotify credentials are required if the platform is enabled")
+		}
+
+		spotifyFetcher, err := platform.NewSpotify(
+			cfg.Spotify.ClientId,
+			cfg.Spotify.ClientSecret,
+			cfg.Spotify.Market,
+			ytFetcher,
+		)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		fetcher.Register(spotifyFetcher)
+	}
+
+	if cfg.Player.PlatformEnabled("soundcloud") {
+		fetcher.Register(platform.NewSoundCloud(platform.SoundCloudConfig{
+			ClientId: cfg.SoundCloud.ClientId,
+		}))
+	}
+
+	if cfg.Player.PlatformEnabled("http") {
+		fetcher.Register(platform.NewHttp(nil, cfg.Player.FFprobeExec))
+	}
+
+	if err = fetcher.SetSearchPlatform(cfg.Player.SearchPlatform); err != nil {
+		log.Fatalln("Failed to set search platform:", err)
+	}
+
+	if cfg.Player.SearchCacheSize > 0 {
+		fetcher.EnableSearchCache(
+			cfg.Player.SearchCacheSize,
+			cfg.Player.SearchCacheTTL,
+		)
+	}
+
+	var playLogRepository music.PlayLogRepository
+	if cfg.Player.PlayLog {
+		db := connectToPostgres()
+		defer func() {
+			start := time.Now()
+			if e := db.Close(); e != nil {
+				slog.Error(
+					"Failed to close postgres client",
+					"took", time.Since(start),
+					"error", e,
+				)
+			} else {
+				slog.Info("Closed postgres client", "took", time.Since(start))
+			}
+		}()
+
+		playLogRepository = music.NewPgPlayLogRepository(db)
 	}
 
-	fetcher := platform.NewFetcher(ytFetcher, spotifyFetcher)
-	manager := player.NewPlayerManager(s, fetcher)
+	manager := player.NewPlayerManager(s, fetcher, playLogRepository)
 
 	defer manager.Close()
 
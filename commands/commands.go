@@ -26,7 +26,12 @@ func Wire(
 	ticketRepository ticket.TicketRepository,
 	ticketConfigRepository ticket.TicketConfigRepository,
 	musicRepository music.MusicConfigRepository,
+	playlistRepository music.PlaylistRepository,
+	favoriteRepository music.FavoriteRepository,
+	playLogRepository music.PlayLogRepository,
+	remoteTokenRepository music.RemoteTokenRepository,
 	musicClient player.PlayerClient,
+	remoteUrl string,
 ) {
 	m.Add(configcmds.NewWelcomeCommand(welcomeRepo, welcomeEvt))
 
@@ -46,10 +51,17 @@ func Wire(
 
 	m.Add(musiccmds.NewMusicAdminCommand(musicRepository))
 	m.Add(musiccmds.NewPlayCommand(musicRepository, musicClient))
+	m.Add(musiccmds.NewSearchCommand(musicRepository, musicClient))
 	m.Add(musiccmds.NewSkipCommand(musicRepository, musicClient))
 	m.Add(musiccmds.NewStopCommand(musicRepository, musicClient))
 	m.Add(musiccmds.NewQueueCommand(musicRepository, musicClient))
 	m.Add(musiccmds.NewLoopCommand(musicRepository, musicClient))
 	m.Add(musiccmds.NewPauseCommand(musicRepository, musicClient))
 	m.Add(musiccmds.NewUnpauseCommand(musicRepository, musicClient))
+	m.Add(musiccmds.NewChapterCommand(musicRepository, musicClient))
+	m.Add(musiccmds.NewSleepCommand(musicRepository, musicClient))
+	m.Add(musiccmds.NewPlaylistCommand(musicRepository, playlistRepository, musicClient))
+	m.Add(musiccmds.NewFavoritesCommand(musicRepository, favoriteRepository, musicClient))
+	m.Add(musiccmds.NewMusicStatsCommand(playLogRepository))
+	m.Add(musiccmds.NewRemoteCommand(musicRepository, remoteTokenRepository, remoteUrl))
 }
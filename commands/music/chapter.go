@@ -0,0 +1,217 @@
+package musiccmds
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zanz1n/duvua/internal/errors"
+	"github.com/zanz1n/duvua/internal/manager"
+	"github.com/zanz1n/duvua/internal/music"
+	"github.com/zanz1n/duvua/internal/utils"
+	"github.com/zanz1n/duvua/pkg/pb/player"
+)
+
+// Select menus are limited to 25 options
+const maxChapterOptions = 25
+
+var chapterCommandData = discordgo.ApplicationCommand{
+	Name:        "chapter",
+	Type:        discordgo.ChatApplicationCommand,
+	Description: "Pula para um capítulo da música atual",
+	DescriptionLocalizations: &map[discordgo.Locale]string{
+		discordgo.EnglishUS: "Jumps to a chapter of the current music",
+	},
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "number",
+			Description: "O número do capítulo, se não informado uma lista será exibida",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "The number of the chapter, if not provided a list is shown",
+			},
+			Required: false,
+		},
+	},
+}
+
+func NewChapterCommand(r music.MusicConfigRepository, client player.PlayerClient) *manager.Command {
+	return &manager.Command{
+		Accepts: manager.CommandAccept{
+			Slash:  true,
+			Button: true,
+		},
+		Data:     &chapterCommandData,
+		Category: manager.CommandCategoryMusic,
+		Handler:  &ChapterCommand{r: r, c: client},
+	}
+}
+
+type ChapterCommand struct {
+	r music.MusicConfigRepository
+	c player.PlayerClient
+}
+
+func (c *ChapterCommand) Handle(s *discordgo.Session, i *manager.InteractionCreate) error {
+	if i.Member == nil || i.GuildID == "" {
+		return errors.New("esse comando só pode ser utilizado dentro de um servidor")
+	}
+
+	cfg, err := musicConfig(i, c.r)
+	if err != nil {
+		return err
+	}
+
+	if err = checkPermission(i.Member, cfg, music.MusicActionSkip); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	res, err := c.c.GetCurrent(ctx, &player.GuildIdRequest{
+		GuildId: cuint64(i.GuildID),
+	})
+	if err != nil {
+		return err
+	}
+	track := res.Track
+
+	if len(track.Data.Chapters) == 0 {
+		return errors.New("a música atual não tem capítulos")
+	}
+
+	if i.Type == discordgo.InteractionMessageComponent {
+		data := i.MessageComponentData()
+		ids := strings.Split(data.CustomID, "/")
+		if len(ids) != 2 || len(data.Values) != 1 {
+			return errors.New("interação inválida")
+		}
+
+		// The chapters are of a track that is not playing anymore
+		if ids[1] != track.Id {
+			return errors.New("a música que está tocando mudou")
+		}
+
+		index, err := strconv.Atoi(data.Values[0])
+		if err != nil {
+			return errors.New("interação inválida")
+		}
+
+		return c.seek(ctx, s, i, track, index)
+	}
+
+	number, err := i.GetIntegerOption("number", false)
+	if err != nil {
+		return err
+	} else if number != 0 {
+		return c.seek(ctx, s, i, track, int(number)-1)
+	}
+
+	return c.handleList(s, i, track)
+}
+
+func (c *ChapterCommand) seek(
+	ctx context.Context,
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+	track *player.Track,
+	index int,
+) error {
+	if index >= len(track.Data.Chapters) || 0 > index {
+		return errors.Newf(
+			"o capítulo informado não existe, a música tem %d capítulos",
+			len(track.Data.Chapters),
+		)
+	}
+	chapter := track.Data.Chapters[index]
+
+	_, err := c.c.Seek(ctx, &player.SeekRequest{
+		GuildId:  cuint64(i.GuildID),
+		Position: chapter.Start,
+	})
+	if err != nil {
+		return err
+	}
+
+	return i.Replyf(s,
+		"Pulando para o capítulo **%s [%s]** de **[%s](<%s>)**",
+		chapter.Title,
+		utils.FmtDuration(chapter.Start.AsDuration()),
+		track.Data.Name,
+		track.Data.Url,
+	)
+}
+
+func (c *ChapterCommand) handleList(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+	track *player.Track,
+) error {
+	chapters := track.Data.Chapters
+	current := currentChapter(track)
+
+	lines := make([]string, len(chapters))
+	options := make([]discordgo.SelectMenuOption, 0, min(len(chapters), maxChapterOptions))
+
+	for idx, chapter := range chapters {
+		start := utils.FmtDuration(chapter.Start.AsDuration())
+
+		line := fmt.Sprintf("**%d.** [%s] %s", idx+1, start, chapter.Title)
+		if idx == current {
+			line = "▶️ " + line
+		}
+		lines[idx] = line
+
+		if len(options) < maxChapterOptions {
+			options = append(options, discordgo.SelectMenuOption{
+				Label:       truncate(fmt.Sprintf("%d. %s", idx+1, chapter.Title), 100),
+				Description: start,
+				Value:       strconv.Itoa(idx),
+				Default:     idx == current,
+			})
+		}
+	}
+
+	return i.Reply(s, &manager.InteractionResponse{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       "Capítulos de " + truncate(track.Data.Name, 200),
+			URL:         track.Data.Url,
+			Description: truncate(strings.Join(lines, "\n"), 4096),
+			Footer:      utils.EmbedRequestedByFooter(i.Interaction),
+		}},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.SelectMenu{
+						CustomID:    "chapter/" + track.Id,
+						Placeholder: "Selecione o capítulo!",
+						MenuType:    discordgo.StringSelectMenu,
+						MaxValues:   1,
+						Options:     options,
+					},
+				},
+			},
+		},
+	})
+}
+
+// currentChapter returns the index of the chapter that is playing.
+func currentChapter(track *player.Track) int {
+	if track.State == nil {
+		return -1
+	}
+	progress := track.State.Progress.AsDuration()
+
+	current := -1
+	for idx, chapter := range track.Data.Chapters {
+		if chapter.Start.AsDuration() > progress {
+			break
+		}
+		current = idx
+	}
+	return current
+}
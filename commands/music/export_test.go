@@ -0,0 +1,18 @@
+package musiccmds
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/zanz1n/duvua/pkg/pb/player"
+)
+
+func NewTestQueueCommand(client player.PlayerClient) *QueueCommand {
+	return &QueueCommand{c: client}
+}
+
+func (c *QueueCommand) HandleList(
+	guildId string,
+	page int,
+	userId string,
+) ([]*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	return c.handleList(guildId, page, userId)
+}
@@ -0,0 +1,325 @@
+package musiccmds
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zanz1n/duvua/internal/errors"
+	"github.com/zanz1n/duvua/internal/manager"
+	"github.com/zanz1n/duvua/internal/music"
+	"github.com/zanz1n/duvua/internal/utils"
+	"github.com/zanz1n/duvua/pkg/pb/player"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// The number of favorites shown by /favorites list
+const favoritesListLimit = 20
+
+// Matches the url of the track in the on-track-start messages
+var nowPlayingUrlRegex = regexp.MustCompile(`Tocando agora \*\*\[.*\]\((\S+)\)\*\*`)
+
+var favoritesCommandData = discordgo.ApplicationCommand{
+	Name:        "favorites",
+	Type:        discordgo.ChatApplicationCommand,
+	Description: "Comandos relacionados às suas músicas favoritas",
+	DescriptionLocalizations: &map[discordgo.Locale]string{
+		discordgo.EnglishUS: "Commands related to your favorite musics",
+	},
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "list",
+			Description: "Exibe as suas músicas favoritas",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Shows your favorite musics",
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "play",
+			Description: "Adiciona as suas músicas favoritas à fila",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Adds your favorite musics to the queue",
+			},
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "number",
+					Description: "O número da música, se não informado todas são adicionadas",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "The number of the music, if not provided all are added",
+					},
+					Required: false,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "remove",
+			Description: "Remove uma música dos seus favoritos",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Removes a music from your favorites",
+			},
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "number",
+					Description: "O número da música que deseja remover",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "The number of the music you want to remove",
+					},
+					Required: true,
+				},
+			},
+		},
+	},
+}
+
+func NewFavoritesCommand(
+	r music.MusicConfigRepository,
+	fr music.FavoriteRepository,
+	client player.PlayerClient,
+) *manager.Command {
+	return &manager.Command{
+		Accepts: manager.CommandAccept{
+			Slash:  true,
+			Button: true,
+		},
+		Data:     &favoritesCommandData,
+		Category: manager.CommandCategoryMusic,
+		Handler:  &FavoritesCommand{r: r, fr: fr, c: client},
+	}
+}
+
+type FavoritesCommand struct {
+	r  music.MusicConfigRepository
+	fr music.FavoriteRepository
+	c  player.PlayerClient
+}
+
+func (c *FavoritesCommand) Handle(s *discordgo.Session, i *manager.InteractionCreate) error {
+	if i.Member == nil || i.GuildID == "" {
+		return errors.New("esse comando só pode ser utilizado dentro de um servidor")
+	}
+
+	if i.Type == discordgo.InteractionMessageComponent {
+		return c.handleLike(s, i)
+	}
+
+	subCommand, err := i.GetSubCommand()
+	if err != nil {
+		return err
+	}
+
+	switch subCommand.Name {
+	case "list":
+		return c.handleList(s, i)
+
+	case "play":
+		number, err := i.GetIntegerOption("number", false)
+		if err != nil {
+			return err
+		}
+		return c.handlePlay(s, i, int(number))
+
+	case "remove":
+		number, err := i.GetIntegerOption("number", true)
+		if err != nil {
+			return err
+		}
+		return c.handleRemove(s, i, int(number))
+
+	default:
+		return errors.New("opção `sub-command` inválida")
+	}
+}
+
+func (c *FavoritesCommand) handleLike(s *discordgo.Session, i *manager.InteractionCreate) error {
+	ids := strings.Split(i.MessageComponentData().CustomID, "/")
+	if len(ids) != 2 {
+		return errors.New("interação inválida")
+	}
+
+	data, err := c.likedTrack(i, ids[1])
+	if err != nil {
+		return err
+	}
+
+	added, err := c.fr.Add(i.Member.User.ID, favoriteFromData(data))
+	if err != nil {
+		return err
+	} else if !added {
+		return errors.Newf(
+			"a música **[%s](<%s>)** já está nos seus favoritos",
+			data.Name,
+			data.Url,
+		)
+	}
+
+	return i.ReplyEphemeralf(s,
+		"❤️ Música **[%s](<%s>)** adicionada aos seus favoritos",
+		data.Name,
+		data.Url,
+	)
+}
+
+// likedTrack returns the data of the track of an on-track-start message.
+func (c *FavoritesCommand) likedTrack(
+	i *manager.InteractionCreate,
+	trackId string,
+) (*player.TrackData, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := c.c.GetCurrent(ctx, &player.GuildIdRequest{
+		GuildId: cuint64(i.GuildID),
+	})
+	if err == nil && res.Track.Id == trackId {
+		return res.Track.Data, nil
+	}
+
+	// The track is not playing anymore, so it is fetched again with the url
+	// of the message
+	if i.Message == nil || len(i.Message.Embeds) == 0 {
+		return nil, errors.New("a música não está mais tocando")
+	}
+	m := nowPlayingUrlRegex.FindStringSubmatch(i.Message.Embeds[0].Description)
+	if m == nil {
+		return nil, errors.New("a música não está mais tocando")
+	}
+
+	fetched, err := c.c.Fetch(ctx, &player.FetchRequest{Query: m[1]})
+	if err != nil {
+		return nil, err
+	} else if len(fetched.Data) == 0 {
+		return nil, errors.New("a música não foi encontrada")
+	}
+
+	return fetched.Data[0], nil
+}
+
+func (c *FavoritesCommand) handleList(s *discordgo.Session, i *manager.InteractionCreate) error {
+	favorites, err := c.fr.GetByUser(i.Member.User.ID)
+	if err != nil {
+		return err
+	} else if len(favorites) == 0 {
+		return errors.New(
+			"você não tem músicas favoritas, use o botão ❤️ das músicas que estão tocando",
+		)
+	}
+
+	fields := make([]*discordgo.MessageEmbedField, 0, min(len(favorites), favoritesListLimit))
+	for idx, favorite := range favorites[:min(len(favorites), favoritesListLimit)] {
+		duration := utils.FmtDuration(favorite.Duration)
+		if favorite.Live {
+			duration = "🔴 AO VIVO"
+		}
+
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("[%d°] Duração: [%s]", idx+1, duration),
+			Value: fmt.Sprintf("**[%s](%s)**", favorite.Name, favorite.Url),
+		})
+	}
+
+	desc := fmt.Sprintf("%d músicas", len(favorites))
+	if len(favorites) > favoritesListLimit {
+		desc += fmt.Sprintf(", exibindo as %d mais recentes", favoritesListLimit)
+	}
+
+	return i.Reply(s, &manager.InteractionResponse{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       "Favoritas de " + i.Member.User.Username,
+			Description: desc,
+			Fields:      fields,
+			Footer:      utils.EmbedRequestedByFooter(i.Interaction),
+		}},
+	})
+}
+
+func (c *FavoritesCommand) handlePlay(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+	number int,
+) error {
+	cfg, vs, err := checkPlay(s, i, c.r)
+	if err != nil {
+		return err
+	}
+
+	favorites, err := c.fr.GetByUser(i.Member.User.ID)
+	if err != nil {
+		return err
+	} else if len(favorites) == 0 {
+		return errors.New("você não tem músicas favoritas")
+	}
+
+	if number != 0 {
+		if number > len(favorites) || 0 > number {
+			return errors.Newf("você tem apenas %d músicas favoritas", len(favorites))
+		}
+		favorites = favorites[number-1 : number]
+	}
+
+	data := make([]*player.TrackData, len(favorites))
+	for idx, favorite := range favorites {
+		data[idx] = favoriteIntoData(favorite)
+	}
+
+	return addTracks(s, i, c.c, cfg, vs, data, 0)
+}
+
+func (c *FavoritesCommand) handleRemove(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+	number int,
+) error {
+	favorites, err := c.fr.GetByUser(i.Member.User.ID)
+	if err != nil {
+		return err
+	}
+
+	if number > len(favorites) || 1 > number {
+		return errors.Newf("você tem apenas %d músicas favoritas", len(favorites))
+	}
+
+	favorite, err := c.fr.Remove(i.Member.User.ID, favorites[number-1].Id)
+	if err != nil {
+		return err
+	} else if favorite == nil {
+		return errors.New("a música já foi removida dos seus favoritos")
+	}
+
+	return i.Replyf(s,
+		"Música **[%s](<%s>)** removida dos seus favoritos",
+		favorite.Name,
+		favorite.Url,
+	)
+}
+
+func favoriteFromData(data *player.TrackData) music.Favorite {
+	return music.Favorite{
+		Name:      data.Name,
+		Url:       data.Url,
+		PlayQuery: data.PlayQuery,
+		Thumbnail: data.Thumbnail,
+		Duration:  data.Duration.AsDuration(),
+		Live:      data.Live,
+		Author:    data.Author,
+	}
+}
+
+func favoriteIntoData(favorite music.Favorite) *player.TrackData {
+	return &player.TrackData{
+		Name:      favorite.Name,
+		Url:       favorite.Url,
+		PlayQuery: favorite.PlayQuery,
+		Thumbnail: favorite.Thumbnail,
+		Duration:  durationpb.New(favorite.Duration),
+		Live:      favorite.Live,
+		Author:    favorite.Author,
+	}
+}
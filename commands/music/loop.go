@@ -69,12 +69,12 @@ func (c *LoopCommand) Handle(s *discordgo.Session, i *manager.InteractionCreate)
 		return errors.New("interação inválida")
 	}
 
-	cfg, err := c.r.GetOrDefault(i.GuildID)
+	cfg, err := musicConfig(i, c.r)
 	if err != nil {
 		return err
 	}
 
-	if err = canControl(i.Member, cfg); err != nil {
+	if err = checkPermission(i.Member, cfg, music.MusicActionLoop); err != nil {
 		return err
 	}
 
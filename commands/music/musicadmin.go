@@ -1,6 +1,10 @@
 package musiccmds
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/bwmarrin/discordgo"
 	"github.com/zanz1n/duvua/internal/errors"
 	"github.com/zanz1n/duvua/internal/manager"
@@ -116,21 +120,280 @@ var musicadminCommandData = discordgo.ApplicationCommand{
 		},
 		{
 			Type:        discordgo.ApplicationCommandOptionSubCommand,
-			Name:        "set-dj",
-			Description: "Define um cargo para os DJs",
+			Name:        "add-dj",
+			Description: "Adiciona um cargo de DJ",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Adds a DJ role",
+			},
+			Options: []*discordgo.ApplicationCommandOption{{
+				Type:        discordgo.ApplicationCommandOptionRole,
+				Name:        "role",
+				Description: "O cargo de DJ",
+				DescriptionLocalizations: map[discordgo.Locale]string{
+					discordgo.EnglishUS: "The DJ role",
+				},
+				Required: true,
+			}},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "remove-dj",
+			Description: "Remove um cargo de DJ",
 			DescriptionLocalizations: map[discordgo.Locale]string{
-				discordgo.EnglishUS: "Defines a role for the DJ's",
+				discordgo.EnglishUS: "Removes a DJ role",
 			},
 			Options: []*discordgo.ApplicationCommandOption{{
 				Type:        discordgo.ApplicationCommandOptionRole,
 				Name:        "role",
-				Description: "O cargo dos DJ's",
+				Description: "O cargo de DJ",
 				DescriptionLocalizations: map[discordgo.Locale]string{
-					discordgo.EnglishUS: "The DJ's role",
+					discordgo.EnglishUS: "The DJ role",
 				},
 				Required: true,
 			}},
 		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "permissions",
+			Description: "Define quem pode fazer uma ação, se nenhuma for informada as permissões são exibidas",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Defines who can do an action, if none is provided the permissions are shown",
+			},
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "action",
+					Description: "A ação",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "The action",
+					},
+					Required: false,
+					Choices:  musicActionChoices(),
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "mode",
+					Description: "Quem poderá fazer a ação",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "Who will be able to do the action",
+					},
+					Required: false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{
+							Name: music.MusicPermissionAll.StringPtBr(),
+							NameLocalizations: map[discordgo.Locale]string{
+								discordgo.EnglishUS: music.MusicPermissionAll.StringEnUs(),
+							},
+							Value: music.MusicPermissionAll,
+						},
+						{
+							Name: music.MusicPermissionDJ.StringPtBr(),
+							NameLocalizations: map[discordgo.Locale]string{
+								discordgo.EnglishUS: music.MusicPermissionDJ.StringEnUs(),
+							},
+							Value: music.MusicPermissionDJ,
+						},
+						{
+							Name: music.MusicPermissionAdm.StringPtBr(),
+							NameLocalizations: map[discordgo.Locale]string{
+								discordgo.EnglishUS: music.MusicPermissionAdm.StringEnUs(),
+							},
+							Value: music.MusicPermissionAdm,
+						},
+						{
+							Name: "Padrão (allow-play ou allow-control)",
+							NameLocalizations: map[discordgo.Locale]string{
+								discordgo.EnglishUS: "Default (allow-play or allow-control)",
+							},
+							Value: "default",
+						},
+					},
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+			Name:        "channels",
+			Description: "Define os canais onde as músicas podem ser usadas",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Defines the channels where the musics can be used",
+			},
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Permite as músicas em um canal de texto ou de voz",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "Allows the musics in a text or voice channel",
+					},
+					Options: []*discordgo.ApplicationCommandOption{{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "O canal",
+						DescriptionLocalizations: map[discordgo.Locale]string{
+							discordgo.EnglishUS: "The channel",
+						},
+						ChannelTypes: musicChannelTypes,
+						Required:     true,
+					}},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Remove um canal permitido",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "Removes an allowed channel",
+					},
+					Options: []*discordgo.ApplicationCommandOption{{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        "channel",
+						Description: "O canal",
+						DescriptionLocalizations: map[discordgo.Locale]string{
+							discordgo.EnglishUS: "The channel",
+						},
+						ChannelTypes: musicChannelTypes,
+						Required:     true,
+					}},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Exibe os canais permitidos",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "Shows the allowed channels",
+					},
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+			Name:        "blocklist",
+			Description: "Bloqueia músicas no servidor",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Blocks musics on the server",
+			},
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Bloqueia músicas por url, canal ou palavra-chave",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "Blocks musics by url, channel or keyword",
+					},
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "kind",
+							Description: "O que será bloqueado",
+							DescriptionLocalizations: map[discordgo.Locale]string{
+								discordgo.EnglishUS: "What will be blocked",
+							},
+							Required: true,
+							Choices:  blocklistKindChoices(),
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "pattern",
+							Description: "A url, o id, o nome do canal ou a palavra-chave",
+							DescriptionLocalizations: map[discordgo.Locale]string{
+								discordgo.EnglishUS: "The url, the id, the channel name or the keyword",
+							},
+							MaxLength: music.MaxBlocklistPatternLength,
+							Required:  true,
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Remove um item da lista de bloqueio",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "Removes an item from the blocklist",
+					},
+					Options: []*discordgo.ApplicationCommandOption{{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "number",
+						Description: "O número do item",
+						DescriptionLocalizations: map[discordgo.Locale]string{
+							discordgo.EnglishUS: "The number of the item",
+						},
+						Required: true,
+					}},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Exibe a lista de bloqueio",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "Shows the blocklist",
+					},
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "player",
+			Description: "Define as configurações do player, se nenhuma for informada as atuais são exibidas",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Defines the player settings, if none is provided the current are shown",
+			},
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "volume",
+					Description: "O volume padrão em porcentagem (1 a 200)",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "The default volume in percent (1 to 200)",
+					},
+					Required: false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "idle-timeout",
+					Description: "Quantos segundos o player espera por músicas quando a fila termina",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "How many seconds the player waits for musics when the queue ends",
+					},
+					Required: false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "pause-timeout",
+					Description: "Quantos segundos o player pode ficar pausado antes de sair",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "How many seconds the player can stay paused before leaving",
+					},
+					Required: false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "announce",
+					Description: "Se as músicas são anunciadas quando começam a tocar",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "Whether the musics are announced when they start playing",
+					},
+					Required: false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "always-on",
+					Description: "Modo 24/7, o player nunca sai do canal de voz sozinho",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "24/7 mode, the player never leaves the voice channel by itself",
+					},
+					Required: false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "max-queue",
+					Description: "O máximo de músicas na fila, 0 para ilimitado",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "The max number of musics in the queue, 0 for unlimited",
+					},
+					Required: false,
+				},
+			},
+		},
 	},
 }
 
@@ -158,11 +421,23 @@ func (c *MusicAdminCommand) Handle(s *discordgo.Session, i *manager.InteractionC
 		return errors.New("você não tem permissão para usar esse comando")
 	}
 
+	subCommandGroup := i.GetSubCommandGroup()
 	subCommand, err := i.GetSubCommand()
 	if err != nil {
 		return err
 	}
 
+	if subCommandGroup != nil {
+		switch subCommandGroup.Name {
+		case "channels":
+			return c.handleChannels(s, i, subCommand.Name)
+		case "blocklist":
+			return c.handleBlocklist(s, i, subCommand.Name)
+		default:
+			return errors.New("opção `sub-command-group` inválida")
+		}
+	}
+
 	switch subCommand.Name {
 	case "enable":
 		return c.handleEnable(s, i, true)
@@ -196,7 +471,7 @@ func (c *MusicAdminCommand) Handle(s *discordgo.Session, i *manager.InteractionC
 
 		return c.handleAllowControl(s, i, mode)
 
-	case "set-dj":
+	case "add-dj", "remove-dj":
 		opt, err := i.GetTypedOption("role", true, discordgo.ApplicationCommandOptionRole)
 		if err != nil {
 			return err
@@ -207,7 +482,13 @@ func (c *MusicAdminCommand) Handle(s *discordgo.Session, i *manager.InteractionC
 			return errors.New("opção `role` precisa ser um cargo válido")
 		}
 
-		return c.handleSetDJ(s, i, role)
+		return c.handleDJ(s, i, role, subCommand.Name == "add-dj")
+
+	case "permissions":
+		return c.handlePermissions(s, i)
+
+	case "player":
+		return c.handlePlayer(s, i)
 
 	default:
 		return errors.New("opção `sub-command` inválida")
@@ -339,43 +620,500 @@ func (c *MusicAdminCommand) handleAllowControl(
 	}
 }
 
-func (c *MusicAdminCommand) handleSetDJ(
+func (c *MusicAdminCommand) handleDJ(
 	s *discordgo.Session,
 	i *manager.InteractionCreate,
 	role *discordgo.Role,
+	add bool,
 ) error {
-	cfg, err := c.r.GetByGuildId(i.GuildID)
+	var (
+		changed bool
+		err     error
+	)
+	if add {
+		changed, err = c.r.AddDjRole(i.GuildID, role.ID)
+	} else {
+		changed, err = c.r.RemoveDjRole(i.GuildID, role.ID)
+	}
 	if err != nil {
 		return err
 	}
 
-	changed := false
-	if cfg != nil && cfg.DjRole != role.ID {
-		if err = c.r.UpdateDjRole(i.GuildID, role.ID); err != nil {
+	switch {
+	case changed && add:
+		return i.Replyf(s, "Configuração atualizada: `%s` agora é um cargo de DJ", role.Name)
+	case changed:
+		return i.Replyf(s, "Configuração atualizada: `%s` não é mais um cargo de DJ", role.Name)
+	case add:
+		return i.Replyf(s, "Configuração não mudou: `%s` já era um cargo de DJ", role.Name)
+	default:
+		return i.Replyf(s, "Configuração não mudou: `%s` não era um cargo de DJ", role.Name)
+	}
+}
+
+func (c *MusicAdminCommand) handlePermissions(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+) error {
+	actionOpt, err := i.GetStringOption("action", false)
+	if err != nil {
+		return err
+	}
+	modeOpt, err := i.GetStringOption("mode", false)
+	if err != nil {
+		return err
+	}
+
+	msg := ""
+	if actionOpt != "" || modeOpt != "" {
+		if actionOpt == "" || modeOpt == "" {
+			return errors.New("as opções `action` e `mode` precisam ser informadas juntas")
+		}
+
+		action, err := music.ParseMusicAction(actionOpt)
+		if err != nil {
+			return errors.New("opção `action` inválida: " + err.Error())
+		}
+
+		mode := music.MusicPermission("")
+		if modeOpt != "default" {
+			if mode, err = music.ParseMusicPermission(modeOpt); err != nil {
+				return errors.New("opção `mode` inválida: " + err.Error())
+			}
+		}
+
+		if err = c.r.UpdatePermission(i.GuildID, action, mode); err != nil {
 			return err
 		}
-		changed = true
-	} else if cfg == nil && role.ID != "" {
-		_, err = c.r.Create(music.MusicConfigCreateData{
-			GuildId: i.GuildID,
-			Enabled: music.DefaultConfigEnabled,
-			DjRole:  role.ID,
+		msg = "Configuração atualizada"
+	}
+
+	cfg, err := c.r.GetOrDefault(i.GuildID)
+	if err != nil {
+		return err
+	}
+
+	fields := make([]*discordgo.MessageEmbedField, len(music.MusicActions))
+	for idx, action := range music.MusicActions {
+		value := cfg.Permission(action).StringPtBr()
+		if _, ok := cfg.Permissions[action]; !ok {
+			value += " (padrão)"
+		}
+
+		fields[idx] = &discordgo.MessageEmbedField{
+			Name:   action.StringPtBr(),
+			Value:  value,
+			Inline: true,
+		}
+	}
+
+	djRoles := "Nenhum"
+	if len(cfg.DjRoles) > 0 {
+		mentions := make([]string, len(cfg.DjRoles))
+		for idx, roleId := range cfg.DjRoles {
+			mentions[idx] = "<@&" + roleId + ">"
+		}
+		djRoles = strings.Join(mentions, ", ")
+	}
+
+	return i.Reply(s, &manager.InteractionResponse{
+		Content: msg,
+		Embeds: []*discordgo.MessageEmbed{{
+			Title: "Permissões de música",
+			Description: "Os membros sempre podem pular ou remover as músicas " +
+				"que adicionaram\n\n**Cargos de DJ:** " + djRoles,
+			Fields: fields,
+			Footer: utils.EmbedRequestedByFooter(i.Interaction),
+		}},
+	})
+}
+
+func (c *MusicAdminCommand) handleChannels(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+	subCommand string,
+) error {
+	if subCommand == "list" {
+		cfg, err := c.r.GetOrDefault(i.GuildID)
+		if err != nil {
+			return err
+		}
+
+		textChannels, voiceChannels := "Todos", "Todos"
+		if len(cfg.TextChannels) > 0 {
+			textChannels = fmtChannels(cfg.TextChannels)
+		}
+		if len(cfg.VoiceChannels) > 0 {
+			voiceChannels = fmtChannels(cfg.VoiceChannels)
+		}
+
+		return i.Reply(s, &manager.InteractionResponse{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title: "Canais de música",
+				Fields: []*discordgo.MessageEmbedField{
+					{Name: "Canais de texto", Value: textChannels},
+					{Name: "Canais de voz", Value: voiceChannels},
+				},
+				Footer: utils.EmbedRequestedByFooter(i.Interaction),
+			}},
 		})
+	}
+
+	opt, err := i.GetTypedOption("channel", true, discordgo.ApplicationCommandOptionChannel)
+	if err != nil {
+		return err
+	}
+
+	channelId := opt.Value.(string)
+
+	switch subCommand {
+	case "add":
+		channel := opt.ChannelValue(s)
+		if channel.Type == 0 && channel.Name == "" {
+			return errors.New("opção `channel` precisa ser um canal válido")
+		}
+
+		voice := false
+		switch channel.Type {
+		case discordgo.ChannelTypeGuildText, discordgo.ChannelTypeGuildNews:
+		case discordgo.ChannelTypeGuildVoice, discordgo.ChannelTypeGuildStageVoice:
+			voice = true
+		default:
+			return errors.New("opção `channel` precisa ser um canal de texto ou de voz")
+		}
+
+		added, err := c.r.AddChannel(i.GuildID, channelId, voice)
 		if err != nil {
 			return err
+		} else if !added {
+			return i.Replyf(s,
+				"Configuração não mudou: as músicas já eram permitidas em <#%s>",
+				channelId,
+			)
 		}
-		changed = true
+		return i.Replyf(s,
+			"Configuração atualizada: as músicas agora são permitidas em <#%s>",
+			channelId,
+		)
+
+	case "remove":
+		removed, err := c.r.RemoveChannel(i.GuildID, channelId)
+		if err != nil {
+			return err
+		} else if !removed {
+			return i.Replyf(s,
+				"Configuração não mudou: <#%s> não era um canal permitido",
+				channelId,
+			)
+		}
+		return i.Replyf(s,
+			"Configuração atualizada: <#%s> não é mais um canal permitido",
+			channelId,
+		)
+
+	default:
+		return errors.New("opção `sub-command` inválida")
 	}
+}
+
+func (c *MusicAdminCommand) handleBlocklist(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+	subCommand string,
+) error {
+	switch subCommand {
+	case "add":
+		kindOpt, err := i.GetStringOption("kind", true)
+		if err != nil {
+			return err
+		}
+		kind, err := music.ParseBlocklistKind(kindOpt)
+		if err != nil {
+			return errors.New("opção `kind` inválida: " + err.Error())
+		}
+
+		pattern, err := i.GetStringOption("pattern", true)
+		if err != nil {
+			return err
+		}
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" || len([]rune(pattern)) > music.MaxBlocklistPatternLength {
+			return errors.Newf(
+				"a opção `pattern` precisa ter entre 1 e %d caracteres",
+				music.MaxBlocklistPatternLength,
+			)
+		}
+
+		added, err := c.r.AddBlocklistEntry(music.BlocklistEntry{
+			GuildId:   i.GuildID,
+			CreatedBy: i.Member.User.ID,
+			Kind:      kind,
+			Pattern:   pattern,
+		})
+		if err != nil {
+			return err
+		} else if !added {
+			return i.Replyf(s, "Configuração não mudou: `%s` já estava bloqueado", pattern)
+		}
 
-	if changed {
 		return i.Replyf(s,
-			"Configuração atualizada: o cargo dos DJs foi definido para `%s`",
-			role.Name,
+			"Configuração atualizada: %s `%s` bloqueado",
+			strings.ToLower(kind.StringPtBr()),
+			pattern,
 		)
-	} else {
+
+	case "remove":
+		number, err := i.GetIntegerOption("number", true)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := c.r.GetOrDefault(i.GuildID)
+		if err != nil {
+			return err
+		}
+
+		if int(number) > len(cfg.Blocklist) || 1 > number {
+			return errors.Newf(
+				"a lista de bloqueio tem apenas %d itens",
+				len(cfg.Blocklist),
+			)
+		}
+
+		entry, err := c.r.RemoveBlocklistEntry(i.GuildID, cfg.Blocklist[number-1].Id)
+		if err != nil {
+			return err
+		} else if entry == nil {
+			return errors.New("o item já foi removido da lista de bloqueio")
+		}
+
 		return i.Replyf(s,
-			"Configuração não mudou: o cargo dos DJs já era `%s`",
-			role.Name,
+			"Configuração atualizada: %s `%s` desbloqueado",
+			strings.ToLower(entry.Kind.StringPtBr()),
+			entry.Pattern,
 		)
+
+	case "list":
+		cfg, err := c.r.GetOrDefault(i.GuildID)
+		if err != nil {
+			return err
+		} else if len(cfg.Blocklist) == 0 {
+			return errors.New("a lista de bloqueio do servidor está vazia")
+		}
+
+		lines := make([]string, len(cfg.Blocklist))
+		for idx, entry := range cfg.Blocklist {
+			lines[idx] = fmt.Sprintf(
+				"**%d.** %s: `%s` - <@%s>",
+				idx+1,
+				entry.Kind.StringPtBr(),
+				entry.Pattern,
+				entry.CreatedBy,
+			)
+		}
+
+		return i.Reply(s, &manager.InteractionResponse{
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "Lista de bloqueio",
+				Description: truncate(strings.Join(lines, "\n"), 4096),
+				Footer:      utils.EmbedRequestedByFooter(i.Interaction),
+			}},
+		})
+
+	default:
+		return errors.New("opção `sub-command` inválida")
+	}
+}
+
+func (c *MusicAdminCommand) handlePlayer(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+) error {
+	cfg, err := c.r.GetByGuildId(i.GuildID)
+	if err != nil {
+		return err
+	}
+
+	player := music.DefaultMusicPlayerConfig()
+	if cfg != nil {
+		player = cfg.Player
+	}
+	before := player
+
+	opt, err := i.GetTypedOption("volume", false, discordgo.ApplicationCommandOptionInteger)
+	if err != nil {
+		return err
+	} else if opt != nil {
+		v := opt.IntValue()
+		if 1 > v || v > music.MaxConfigVolume {
+			return errors.Newf("o volume precisa estar entre 1 e %d", music.MaxConfigVolume)
+		}
+		player.Volume = int(v)
+	}
+
+	opt, err = i.GetTypedOption("idle-timeout", false, discordgo.ApplicationCommandOptionInteger)
+	if err != nil {
+		return err
+	} else if opt != nil {
+		v := time.Duration(opt.IntValue()) * time.Second
+		if time.Second > v || v > music.MaxConfigIdleTimeout {
+			return errors.Newf(
+				"o tempo de espera precisa estar entre 1 e %d segundos",
+				int(music.MaxConfigIdleTimeout/time.Second),
+			)
+		}
+		player.IdleTimeout = v
+	}
+
+	opt, err = i.GetTypedOption("pause-timeout", false, discordgo.ApplicationCommandOptionInteger)
+	if err != nil {
+		return err
+	} else if opt != nil {
+		v := time.Duration(opt.IntValue()) * time.Second
+		if time.Second > v || v > music.MaxConfigPauseTimeout {
+			return errors.Newf(
+				"o tempo de pausa precisa estar entre 1 e %d segundos",
+				int(music.MaxConfigPauseTimeout/time.Second),
+			)
+		}
+		player.PauseTimeout = v
+	}
+
+	opt, err = i.GetTypedOption("announce", false, discordgo.ApplicationCommandOptionBoolean)
+	if err != nil {
+		return err
+	} else if opt != nil {
+		player.AnnounceTracks = opt.BoolValue()
+	}
+
+	opt, err = i.GetTypedOption("always-on", false, discordgo.ApplicationCommandOptionBoolean)
+	if err != nil {
+		return err
+	} else if opt != nil {
+		player.AlwaysOn = opt.BoolValue()
+	}
+
+	opt, err = i.GetTypedOption("max-queue", false, discordgo.ApplicationCommandOptionInteger)
+	if err != nil {
+		return err
+	} else if opt != nil {
+		v := opt.IntValue()
+		if 0 > v || v > music.MaxConfigQueueSize {
+			return errors.Newf(
+				"o máximo de músicas na fila precisa estar entre 0 e %d",
+				music.MaxConfigQueueSize,
+			)
+		}
+		player.MaxQueueSize = int(v)
+	}
+
+	msg := "Configuração não mudou"
+	if player != before {
+		if cfg != nil {
+			err = c.r.UpdatePlayer(i.GuildID, player)
+		} else {
+			_, err = c.r.Create(music.MusicConfigCreateData{
+				GuildId: i.GuildID,
+				Enabled: music.DefaultConfigEnabled,
+				Player:  &player,
+			})
+		}
+		if err != nil {
+			return err
+		}
+		msg = "Configuração atualizada, vale a partir das próximas músicas adicionadas"
+	}
+
+	maxQueue := "ilimitado"
+	if player.MaxQueueSize > 0 {
+		maxQueue = fmt.Sprintf("%d músicas", player.MaxQueueSize)
+	}
+
+	return i.Reply(s, &manager.InteractionResponse{
+		Content: msg,
+		Embeds: []*discordgo.MessageEmbed{{
+			Title: "Configurações do player",
+			Fields: []*discordgo.MessageEmbedField{
+				{
+					Name:   "Volume",
+					Value:  fmt.Sprintf("%d%%", player.Volume),
+					Inline: true,
+				},
+				{
+					Name:   "Espera com a fila vazia",
+					Value:  utils.FmtDuration(player.IdleTimeout),
+					Inline: true,
+				},
+				{
+					Name:   "Tempo máximo pausado",
+					Value:  utils.FmtDuration(player.PauseTimeout),
+					Inline: true,
+				},
+				{
+					Name:   "Anunciar músicas",
+					Value:  fmtBool(player.AnnounceTracks),
+					Inline: true,
+				},
+				{
+					Name:   "Modo 24/7",
+					Value:  fmtBool(player.AlwaysOn),
+					Inline: true,
+				},
+				{
+					Name:   "Tamanho máximo da fila",
+					Value:  maxQueue,
+					Inline: true,
+				},
+			},
+			Footer: utils.EmbedRequestedByFooter(i.Interaction),
+		}},
+	})
+}
+
+var musicChannelTypes = []discordgo.ChannelType{
+	discordgo.ChannelTypeGuildText,
+	discordgo.ChannelTypeGuildNews,
+	discordgo.ChannelTypeGuildVoice,
+	discordgo.ChannelTypeGuildStageVoice,
+}
+
+func musicActionChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, len(music.MusicActions))
+	for idx, action := range music.MusicActions {
+		choices[idx] = &discordgo.ApplicationCommandOptionChoice{
+			Name: action.StringPtBr(),
+			NameLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: action.StringEnUs(),
+			},
+			Value: action,
+		}
+	}
+	return choices
+}
+
+func blocklistKindChoices() []*discordgo.ApplicationCommandOptionChoice {
+	kinds := []music.BlocklistKind{
+		music.BlocklistKindUrl,
+		music.BlocklistKindChannel,
+		music.BlocklistKindKeyword,
+	}
+
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, len(kinds))
+	for idx, kind := range kinds {
+		choices[idx] = &discordgo.ApplicationCommandOptionChoice{
+			Name: kind.StringPtBr(),
+			NameLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: kind.StringEnUs(),
+			},
+			Value: kind,
+		}
+	}
+	return choices
+}
+
+func fmtBool(v bool) string {
+	if v {
+		return "Sim"
 	}
+	return "Não"
 }
@@ -0,0 +1,245 @@
+package musiccmds
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zanz1n/duvua/internal/errors"
+	"github.com/zanz1n/duvua/internal/manager"
+	"github.com/zanz1n/duvua/internal/music"
+	"github.com/zanz1n/duvua/internal/utils"
+)
+
+const (
+	// The number of top tracks and requesters shown
+	musicStatsTopLimit = 5
+	// The width of the bars of the hour-of-day activity
+	musicStatsBarWidth = 12
+)
+
+var musicStatsPeriods = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+	"all":   0,
+}
+
+var musicStatsCommandData = discordgo.ApplicationCommand{
+	Name:        "musicstats",
+	Type:        discordgo.ChatApplicationCommand,
+	Description: "Exibe as estatísticas das músicas tocadas no servidor",
+	DescriptionLocalizations: &map[discordgo.Locale]string{
+		discordgo.EnglishUS: "Shows the statistics of the musics played in the server",
+	},
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "member",
+			Description: "O membro, se não informado as estatísticas do servidor são exibidas",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "The member, if not provided the stats of the server are shown",
+			},
+			Required: false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "period",
+			Description: "O período considerado (padrão: últimos 30 dias)",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "The considered period (default: last 30 days)",
+			},
+			Required: false,
+			Choices: []*discordgo.ApplicationCommandOptionChoice{
+				{
+					Name: "Últimas 24 horas",
+					NameLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "Last 24 hours",
+					},
+					Value: "day",
+				},
+				{
+					Name: "Últimos 7 dias",
+					NameLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "Last 7 days",
+					},
+					Value: "week",
+				},
+				{
+					Name: "Últimos 30 dias",
+					NameLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "Last 30 days",
+					},
+					Value: "month",
+				},
+				{
+					Name: "Último ano",
+					NameLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "Last year",
+					},
+					Value: "year",
+				},
+				{
+					Name: "Todo o período",
+					NameLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "All time",
+					},
+					Value: "all",
+				},
+			},
+		},
+	},
+}
+
+func NewMusicStatsCommand(r music.PlayLogRepository) *manager.Command {
+	return &manager.Command{
+		Accepts: manager.CommandAccept{
+			Slash:  true,
+			Button: false,
+		},
+		Data:     &musicStatsCommandData,
+		Category: manager.CommandCategoryMusic,
+		Handler:  &MusicStatsCommand{r: r},
+	}
+}
+
+type MusicStatsCommand struct {
+	r music.PlayLogRepository
+}
+
+func (c *MusicStatsCommand) Handle(s *discordgo.Session, i *manager.InteractionCreate) error {
+	if i.Member == nil || i.GuildID == "" {
+		return errors.New("esse comando só pode ser utilizado dentro de um servidor")
+	}
+
+	userId, err := i.GetUserOption("member", false)
+	if err != nil {
+		return err
+	}
+
+	period, err := i.GetStringOption("period", false)
+	if err != nil {
+		return err
+	} else if period == "" {
+		period = "month"
+	}
+
+	since, ok := musicStatsPeriods[period]
+	if !ok {
+		return errors.New("opção `period` inválida")
+	}
+
+	filter := music.PlayStatsFilter{GuildId: i.GuildID, UserId: userId}
+	if since != 0 {
+		filter.Since = time.Now().Add(-since)
+	}
+
+	stats, err := c.r.GetStats(filter, musicStatsTopLimit)
+	if err != nil {
+		return err
+	} else if stats.Plays == 0 {
+		return errors.New("nenhuma música foi tocada no período")
+	}
+
+	title := "Estatísticas de música do servidor"
+	if userId != "" {
+		title = "Estatísticas de música"
+	}
+
+	desc := fmt.Sprintf(
+		"**%.1f horas** ouvidas em **%d músicas**, %d puladas",
+		stats.Listened.Hours(),
+		stats.Plays,
+		stats.Skips,
+	)
+	if userId != "" {
+		desc = fmt.Sprintf("Músicas pedidas por <@%s>\n", userId) + desc
+	}
+
+	fields := []*discordgo.MessageEmbedField{{
+		Name:  "Mais tocadas",
+		Value: fmtTopTracks(stats.TopTracks),
+	}}
+
+	if userId == "" {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  "Quem mais pediu",
+			Value: fmtTopRequesters(stats.TopRequesters),
+		})
+	}
+
+	fields = append(fields, &discordgo.MessageEmbedField{
+		Name:  "Atividade por hora (UTC)",
+		Value: fmtHoursActivity(stats.Hours),
+	})
+
+	return i.Reply(s, &manager.InteractionResponse{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       title,
+			Description: desc,
+			Fields:      fields,
+			Footer:      utils.EmbedRequestedByFooter(i.Interaction),
+		}},
+	})
+}
+
+func fmtTopTracks(tracks []music.TrackPlayStats) string {
+	lines := make([]string, len(tracks))
+	for idx, t := range tracks {
+		lines[idx] = fmt.Sprintf(
+			"**%d.** [%s](<%s>) - %d vezes",
+			idx+1,
+			truncate(t.Name, 100),
+			t.Url,
+			t.Plays,
+		)
+	}
+	return truncate(strings.Join(lines, "\n"), 1024)
+}
+
+func fmtTopRequesters(requesters []music.RequesterPlayStats) string {
+	lines := make([]string, len(requesters))
+	for idx, r := range requesters {
+		lines[idx] = fmt.Sprintf(
+			"**%d.** <@%s> - %d músicas [%s]",
+			idx+1,
+			r.UserId,
+			r.Plays,
+			utils.FmtDuration(r.Listened.Round(time.Second)),
+		)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// fmtHoursActivity draws a bar for each hour of the day, proportional to
+// the number of plays.
+func fmtHoursActivity(hours [24]int) string {
+	top := 0
+	for _, plays := range hours {
+		top = max(top, plays)
+	}
+
+	b := strings.Builder{}
+	b.WriteString("```\n")
+	for hour, plays := range hours {
+		width := 0
+		if top > 0 {
+			width = plays * musicStatsBarWidth / top
+		}
+		if plays > 0 {
+			width = max(width, 1)
+		}
+
+		fmt.Fprintf(&b, "%02dh %-*s %d\n",
+			hour,
+			musicStatsBarWidth,
+			strings.Repeat("█", width),
+			plays,
+		)
+	}
+	b.WriteString("```")
+
+	return b.String()
+}
@@ -42,12 +42,12 @@ func (c *PauseCommand) Handle(s *discordgo.Session, i *manager.InteractionCreate
 		return errors.New("esse comando só pode ser utilizado dentro de um servidor")
 	}
 
-	cfg, err := c.r.GetOrDefault(i.GuildID)
+	cfg, err := musicConfig(i, c.r)
 	if err != nil {
 		return err
 	}
 
-	if err = canControl(i.Member, cfg); err != nil {
+	if err = checkPermission(i.Member, cfg, music.MusicActionPause); err != nil {
 		return err
 	}
 
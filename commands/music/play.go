@@ -3,6 +3,9 @@ package musiccmds
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -10,9 +13,14 @@ import (
 	"github.com/zanz1n/duvua/internal/manager"
 	"github.com/zanz1n/duvua/internal/music"
 	"github.com/zanz1n/duvua/internal/utils"
+	"github.com/zanz1n/duvua/internal/utils/ttlcache"
 	"github.com/zanz1n/duvua/pkg/pb/player"
 )
 
+// The max time taken by the player to page through a playlist, album or
+// artist
+const collectionFetchTimeout = 10 * time.Second
+
 var playCommandData = discordgo.ApplicationCommand{
 	Name:        "play",
 	Type:        discordgo.ChatApplicationCommand,
@@ -28,7 +36,17 @@ var playCommandData = discordgo.ApplicationCommand{
 			DescriptionLocalizations: map[discordgo.Locale]string{
 				discordgo.EnglishUS: "The name or the url of the music",
 			},
-			Required: true,
+			Required:     false,
+			Autocomplete: true,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionAttachment,
+			Name:        "attachment",
+			Description: "Um arquivo de áudio para tocar",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "An audio file to play",
+			},
+			Required: false,
 		},
 	},
 }
@@ -45,63 +63,236 @@ func NewPlayCommand(r music.MusicConfigRepository, client player.PlayerClient) *
 		},
 		Data:     &playCommandData,
 		Category: manager.CommandCategoryMusic,
-		Handler:  &PlayCommand{r: r, c: client},
+		Handler: &PlayCommand{
+			r: r,
+			c: client,
+			// The autocomplete is called for every key the user presses
+			cache: ttlcache.New[[]*player.SearchResult](ttlcache.Config{
+				Name: "autocomplete",
+				Size: autocompleteCacheSize,
+				TTL:  autocompleteCacheTTL,
+			}),
+		},
 	}
 }
 
 type PlayCommand struct {
-	r music.MusicConfigRepository
-	c player.PlayerClient
+	r     music.MusicConfigRepository
+	c     player.PlayerClient
+	cache *ttlcache.Cache[[]*player.SearchResult]
 }
 
 func (c *PlayCommand) Handle(s *discordgo.Session, i *manager.InteractionCreate) error {
+	if i.Type == discordgo.InteractionApplicationCommandAutocomplete {
+		return c.handleAutocomplete(s, i)
+	}
+
 	if i.Member == nil || i.GuildID == "" {
 		return errors.New("esse comando só pode ser utilizado dentro de um servidor")
 	}
 
-	query, err := i.GetStringOption("query", true)
+	query, err := i.GetStringOption("query", false)
 	if err != nil {
 		return err
 	}
 
-	cfg, err := c.r.GetOrDefault(i.GuildID)
+	attachment, err := i.GetAttachmentOption("attachment", false)
 	if err != nil {
 		return err
 	}
 
-	if !canPlay(i.Member, cfg) {
-		return errors.New("você não tem permissão para tocar músicas no servidor")
+	fetchTimeout := 2 * time.Second
+	if attachment != nil {
+		if !strings.HasPrefix(attachment.ContentType, "audio/") {
+			return errors.New("o arquivo enviado precisa ser um áudio")
+		}
+		// The audio file metadata needs to be probed by the player
+		query, fetchTimeout = attachment.URL, 6*time.Second
+	} else if query == "" {
+		return errors.New("opção `query` ou `attachment` é necessária")
 	}
 
-	vs, err := s.State.VoiceState(i.GuildID, i.Member.User.ID)
+	return enqueue(s, i, c.r, c.c, query, fetchTimeout, attachment != nil)
+}
+
+func (c *PlayCommand) handleAutocomplete(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+) error {
+	query, err := i.GetStringOption("query", false)
 	if err != nil {
-		if err == discordgo.ErrStateNotFound {
-			return errors.New(
-				"você precisa estar em um canal de voz para usar esse comando",
-			)
+		return err
+	}
+
+	query = strings.TrimSpace(query)
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+
+	// Urls are played as they are
+	if len(query) < 3 || strings.HasPrefix(query, "https://") ||
+		strings.HasPrefix(query, "http://") {
+		return i.ReplyChoices(s, choices)
+	}
+
+	results, err := c.cache.Get(strings.ToLower(query), func() ([]*player.SearchResult, error) {
+		// Discord discards autocomplete responses sent after 3 seconds
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		res, err := c.c.Search(ctx, &player.SearchRequest{
+			Query: query,
+			Limit: autocompleteLimit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return res.Results, nil
+	})
+	if err != nil {
+		// The user can still send the query as it is
+		slog.Warn(
+			"Failed to fetch autocomplete search results",
+			"query", query,
+			"error", err,
+		)
+		return i.ReplyChoices(s, choices)
+	}
+
+	for _, result := range results {
+		if len(result.Url) > 100 {
+			continue
 		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  truncate(searchResultLabel(result), 100),
+			Value: result.Url,
+		})
+	}
+
+	return i.ReplyChoices(s, choices)
+}
+
+// enqueue fetches the tracks found by the query and adds them to the queue of
+// the guild. If deferred is true, the reply is deferred before the fetch.
+func enqueue(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+	r music.MusicConfigRepository,
+	c player.PlayerClient,
+	query string,
+	fetchTimeout time.Duration,
+	deferred bool,
+) error {
+	cfg, vs, err := checkPlay(s, i, r)
+	if err != nil {
 		return err
 	}
 
-	ctx1, cancel1 := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel1()
+	// The tracks of collections are paged by the player, which takes a few
+	// requests
+	if isCollectionUrl(query) {
+		fetchTimeout, deferred = max(fetchTimeout, collectionFetchTimeout), true
+	}
+
+	if deferred {
+		if err = i.DeferReply(s, false); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
 
-	tracksData, err := c.c.Fetch(ctx1, &player.FetchRequest{
+	tracksData, err := c.Fetch(ctx, &player.FetchRequest{
 		Query: query,
 	})
 	if err != nil {
 		return err
 	}
 
-	ctx2, cancel2 := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel2()
+	return addTracks(s, i, c, cfg, vs, tracksData.Data, tracksData.Skipped)
+}
+
+// isCollectionUrl reports whether the query is the url of a playlist, album
+// or artist, which are loaded as many tracks.
+func isCollectionUrl(query string) bool {
+	u, err := url.Parse(query)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return false
+	}
 
-	tracksRes, err := c.c.Add(ctx2, &player.AddRequest{
+	if u.Query().Has("list") {
+		return true
+	}
+	for _, seg := range strings.Split(u.Path, "/") {
+		switch seg {
+		case "playlist", "album", "artist", "sets":
+			return true
+		}
+	}
+	return false
+}
+
+// checkPlay checks if the member can play musics, returning the music config
+// of the guild and the voice state of the member.
+func checkPlay(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+	r music.MusicConfigRepository,
+) (*music.MusicConfig, *discordgo.VoiceState, error) {
+	cfg, err := musicConfig(i, r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = checkPermission(i.Member, cfg, music.MusicActionPlay); err != nil {
+		return nil, nil, err
+	}
+
+	vs, err := s.State.VoiceState(i.GuildID, i.Member.User.ID)
+	if err != nil {
+		if err == discordgo.ErrStateNotFound {
+			return nil, nil, errors.New(
+				"você precisa estar em um canal de voz para usar esse comando",
+			)
+		}
+		return nil, nil, err
+	}
+
+	if err = checkVoiceChannel(cfg, vs.ChannelID); err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, vs, nil
+}
+
+// addTracks adds the tracks to the queue of the guild, in the voice channel
+// of the member, with the player settings of the guild.
+func addTracks(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+	c player.PlayerClient,
+	cfg *music.MusicConfig,
+	vs *discordgo.VoiceState,
+	data []*player.TrackData,
+	skipped int32,
+) error {
+	data, blocked := cfg.FilterBlocked(i.Member.User.ID, data)
+	if len(data) == 0 {
+		if blocked == 1 {
+			return errors.New("essa música está bloqueada no servidor")
+		}
+		return errors.New("todas as músicas estão bloqueadas no servidor")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	tracksRes, err := c.Add(ctx, &player.AddRequest{
 		GuildId:       cuint64(i.GuildID),
 		UserId:        cuint64(i.Member.User.ID),
 		ChannelId:     cuint64(vs.ChannelID),
 		TextChannelId: cuint64(i.ChannelID),
-		Data:          tracksData.Data,
+		Data:          data,
+		Config:        cfg.PlayerConfig(),
 	})
 	if err != nil {
 		return err
@@ -115,8 +306,14 @@ func (c *PlayCommand) Handle(s *discordgo.Session, i *manager.InteractionCreate)
 		msg := fmt.Sprintf("Música **[%s](<%s>) [%s]** adicionada à fila",
 			track.Data.Name,
 			track.Data.Url,
-			utils.FmtDuration(track.Data.Duration.AsDuration()),
+			music.FmtTrackDuration(track.Data),
 		)
+		if start := track.Data.Start.AsDuration(); start > 0 {
+			msg += fmt.Sprintf(", começando em **[%s]**", utils.FmtDuration(start))
+		}
+		if blocked > 0 {
+			msg += fmt.Sprintf(" (%d bloqueadas no servidor)", blocked)
+		}
 
 		return i.Reply(s, &manager.InteractionResponse{
 			Content: msg,
@@ -133,8 +330,16 @@ func (c *PlayCommand) Handle(s *discordgo.Session, i *manager.InteractionCreate)
 		})
 	}
 
+	msg := fmt.Sprintf("%d músicas adicionadas à fila", len(tracks))
+	if skipped > 0 {
+		msg += fmt.Sprintf(" (%d não puderam ser encontradas)", skipped)
+	}
+	if blocked > 0 {
+		msg += fmt.Sprintf(" (%d bloqueadas no servidor)", blocked)
+	}
+
 	return i.Reply(s, &manager.InteractionResponse{
-		Content: fmt.Sprintf("%d músicas adicionadas à fila", len(tracks)),
+		Content: msg,
 		Components: []discordgo.MessageComponent{discordgo.ActionsRow{
 			Components: []discordgo.MessageComponent{
 				discordgo.Button{
@@ -0,0 +1,565 @@
+package musiccmds
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zanz1n/duvua/internal/errors"
+	"github.com/zanz1n/duvua/internal/manager"
+	"github.com/zanz1n/duvua/internal/music"
+	"github.com/zanz1n/duvua/internal/utils"
+	"github.com/zanz1n/duvua/pkg/pb/player"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// The number of tracks shown by /playlist show
+const playlistShowLimit = 20
+
+func playlistNameOption(autocomplete bool) *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "name",
+		Description: "O nome da playlist",
+		DescriptionLocalizations: map[discordgo.Locale]string{
+			discordgo.EnglishUS: "The name of the playlist",
+		},
+		Required:     true,
+		Autocomplete: autocomplete,
+		MaxLength:    music.MaxPlaylistNameLength,
+	}
+}
+
+func playlistScopeOption() *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "scope",
+		Description: "Se a playlist é sua ou do servidor",
+		DescriptionLocalizations: map[discordgo.Locale]string{
+			discordgo.EnglishUS: "Whether the playlist is yours or of the server",
+		},
+		Required: false,
+		Choices: []*discordgo.ApplicationCommandOptionChoice{
+			{
+				Name: "Pessoal (padrão)",
+				NameLocalizations: map[discordgo.Locale]string{
+					discordgo.EnglishUS: "Personal (default)",
+				},
+				Value: "user",
+			},
+			{
+				Name: "Servidor",
+				NameLocalizations: map[discordgo.Locale]string{
+					discordgo.EnglishUS: "Server",
+				},
+				Value: "guild",
+			},
+		},
+	}
+}
+
+var playlistCommandData = discordgo.ApplicationCommand{
+	Name:        "playlist",
+	Type:        discordgo.ChatApplicationCommand,
+	Description: "Comandos relacionados às playlists salvas",
+	DescriptionLocalizations: &map[discordgo.Locale]string{
+		discordgo.EnglishUS: "Commands related to the saved playlists",
+	},
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "create",
+			Description: "Cria uma playlist",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Creates a playlist",
+			},
+			Options: []*discordgo.ApplicationCommandOption{
+				playlistNameOption(false),
+				playlistScopeOption(),
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "add",
+			Description: "Adiciona músicas à playlist",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Adds musics to the playlist",
+			},
+			Options: []*discordgo.ApplicationCommandOption{
+				playlistNameOption(true),
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "query",
+					Description: "O nome ou a url da música, se não informado a fila atual é salva",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "The name or the url of the music, " +
+							"if not provided the current queue is saved",
+					},
+					Required: false,
+				},
+				playlistScopeOption(),
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "remove",
+			Description: "Remove uma música da playlist com base na posição",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Removes a music from the playlist by its position",
+			},
+			Options: []*discordgo.ApplicationCommandOption{
+				playlistNameOption(true),
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "position",
+					Description: "A posição da música que deseja remover",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "The position of the music you want to remove",
+					},
+					Required: true,
+				},
+				playlistScopeOption(),
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "show",
+			Description: "Exibe as músicas da playlist",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Shows the musics of the playlist",
+			},
+			Options: []*discordgo.ApplicationCommandOption{
+				playlistNameOption(true),
+				playlistScopeOption(),
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "play",
+			Description: "Adiciona as músicas da playlist à fila",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Adds the musics of the playlist to the queue",
+			},
+			Options: []*discordgo.ApplicationCommandOption{
+				playlistNameOption(true),
+				playlistScopeOption(),
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "delete",
+			Description: "Apaga a playlist",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Deletes the playlist",
+			},
+			Options: []*discordgo.ApplicationCommandOption{
+				playlistNameOption(true),
+				playlistScopeOption(),
+			},
+		},
+	},
+}
+
+func NewPlaylistCommand(
+	r music.MusicConfigRepository,
+	pr music.PlaylistRepository,
+	client player.PlayerClient,
+) *manager.Command {
+	return &manager.Command{
+		Accepts: manager.CommandAccept{
+			Slash:  true,
+			Button: false,
+		},
+		Data:     &playlistCommandData,
+		Category: manager.CommandCategoryMusic,
+		Handler:  &PlaylistCommand{r: r, pr: pr, c: client},
+	}
+}
+
+type PlaylistCommand struct {
+	r  music.MusicConfigRepository
+	pr music.PlaylistRepository
+	c  player.PlayerClient
+}
+
+func (c *PlaylistCommand) Handle(s *discordgo.Session, i *manager.InteractionCreate) error {
+	if i.Member == nil || i.GuildID == "" {
+		if i.Type == discordgo.InteractionApplicationCommandAutocomplete {
+			return i.ReplyChoices(s, nil)
+		}
+		return errors.New("esse comando só pode ser utilizado dentro de um servidor")
+	}
+
+	owner, err := c.getOwner(i)
+	if err != nil {
+		return err
+	}
+
+	if i.Type == discordgo.InteractionApplicationCommandAutocomplete {
+		return c.handleAutocomplete(s, i, owner)
+	}
+
+	subCommand, err := i.GetSubCommand()
+	if err != nil {
+		return err
+	}
+
+	name, err := i.GetStringOption("name", true)
+	if err != nil {
+		return err
+	}
+	name = strings.TrimSpace(name)
+
+	// Changing the playlists of the server requires the same permission of
+	// removing tracks of other members
+	if owner.IsGuild() && subCommand.Name != "show" && subCommand.Name != "play" {
+		cfg, err := musicConfig(i, c.r)
+		if err != nil {
+			return err
+		}
+		if err = checkPermission(i.Member, cfg, music.MusicActionRemove); err != nil {
+			return err
+		}
+	}
+
+	if subCommand.Name == "create" {
+		return c.handleCreate(s, i, owner, name)
+	}
+
+	playlist, err := c.pr.GetByName(owner, name)
+	if err != nil {
+		return err
+	} else if playlist == nil {
+		return errors.Newf("a playlist `%s` não foi encontrada", name)
+	}
+
+	switch subCommand.Name {
+	case "add":
+		query, err := i.GetStringOption("query", false)
+		if err != nil {
+			return err
+		}
+		return c.handleAdd(s, i, playlist, query)
+
+	case "remove":
+		pos, err := i.GetIntegerOption("position", true)
+		if err != nil {
+			return err
+		}
+		return c.handleRemove(s, i, playlist, int(pos))
+
+	case "show":
+		return c.handleShow(s, i, playlist)
+
+	case "play":
+		return c.handlePlay(s, i, playlist)
+
+	case "delete":
+		return c.handleDelete(s, i, playlist)
+
+	default:
+		return errors.New("opção `sub-command` inválida")
+	}
+}
+
+func (c *PlaylistCommand) getOwner(i *manager.InteractionCreate) (music.PlaylistOwner, error) {
+	scope, err := i.GetStringOption("scope", false)
+	if err != nil {
+		return music.PlaylistOwner{}, err
+	}
+
+	switch scope {
+	case "", "user":
+		return music.UserPlaylistOwner(i.Member.User.ID), nil
+	case "guild":
+		return music.GuildPlaylistOwner(i.GuildID), nil
+	default:
+		return music.PlaylistOwner{}, errors.New("opção `scope` inválida")
+	}
+}
+
+func (c *PlaylistCommand) handleCreate(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+	owner music.PlaylistOwner,
+	name string,
+) error {
+	if name == "" || len([]rune(name)) > music.MaxPlaylistNameLength {
+		return errors.Newf(
+			"o nome da playlist precisa ter entre 1 e %d caracteres",
+			music.MaxPlaylistNameLength,
+		)
+	}
+
+	playlists, err := c.pr.GetByOwner(owner)
+	if err != nil {
+		return err
+	}
+
+	if len(playlists) >= music.MaxPlaylists {
+		return errors.Newf(
+			"só é possível ter até %d playlists, apague alguma antes de criar outra",
+			music.MaxPlaylists,
+		)
+	}
+
+	playlist, err := c.pr.Create(owner, name, i.Member.User.ID)
+	if err != nil {
+		return err
+	}
+
+	return i.Replyf(s,
+		"Playlist **%s** criada! Use `/playlist add` para adicionar músicas",
+		playlist.Name,
+	)
+}
+
+func (c *PlaylistCommand) handleAdd(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+	playlist *music.Playlist,
+	query string,
+) error {
+	if err := i.DeferReply(s, false); err != nil {
+		return err
+	}
+
+	data := []*player.TrackData{}
+	skipped := int32(0)
+
+	if query != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		res, err := c.c.Fetch(ctx, &player.FetchRequest{Query: query})
+		if err != nil {
+			return err
+		}
+		data, skipped = res.Data, res.Skipped
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		res, err := c.c.GetAll(ctx, &player.GetAllRequest{
+			GuildId: cuint64(i.GuildID),
+			Offset:  0,
+			Limit:   music.MaxPlaylistTracks,
+		})
+		if err != nil {
+			return err
+		}
+
+		if res.Playing != nil {
+			data = append(data, res.Playing.Data)
+		}
+		for _, track := range res.Tracks {
+			data = append(data, track.Data)
+		}
+	}
+
+	if len(data) == 0 {
+		return errors.New("nenhuma música para adicionar")
+	}
+
+	tracks := make([]music.PlaylistTrack, len(data))
+	for idx, d := range data {
+		tracks[idx] = playlistTrackFromData(d)
+	}
+
+	if err := c.pr.AddTracks(playlist.Id, tracks); err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("%d músicas adicionadas à playlist **%s**", len(tracks), playlist.Name)
+	if len(tracks) == 1 {
+		msg = fmt.Sprintf("Música **[%s](<%s>)** adicionada à playlist **%s**",
+			tracks[0].Name,
+			tracks[0].Url,
+			playlist.Name,
+		)
+	}
+	if skipped > 0 {
+		msg += fmt.Sprintf(" (%d não puderam ser encontradas)", skipped)
+	}
+
+	return i.Replyf(s, "%s", msg)
+}
+
+func (c *PlaylistCommand) handleRemove(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+	playlist *music.Playlist,
+	pos int,
+) error {
+	track, err := c.pr.RemoveTrack(playlist.Id, pos-1)
+	if err != nil {
+		return err
+	} else if track == nil {
+		return errors.Newf("não existe uma música na posição %d da playlist", pos)
+	}
+
+	return i.Replyf(s,
+		"Música **[%s](<%s>)** removida da playlist **%s**",
+		track.Name,
+		track.Url,
+		playlist.Name,
+	)
+}
+
+func (c *PlaylistCommand) handleShow(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+	playlist *music.Playlist,
+) error {
+	tracks, err := c.pr.GetTracks(playlist.Id)
+	if err != nil {
+		return err
+	}
+
+	fields := make([]*discordgo.MessageEmbedField, 0, min(len(tracks), playlistShowLimit))
+	for _, track := range tracks[:min(len(tracks), playlistShowLimit)] {
+		duration := utils.FmtDuration(track.Duration)
+		if track.Live {
+			duration = "🔴 AO VIVO"
+		}
+
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("[%d°] Duração: [%s]", track.Position+1, duration),
+			Value: fmt.Sprintf("**[%s](%s)**", track.Name, track.Url),
+		})
+	}
+
+	desc := fmt.Sprintf(
+		"%d músicas, duração total: **[%s]**",
+		playlist.TrackCount,
+		utils.FmtDuration(playlist.Duration),
+	)
+	if len(tracks) > playlistShowLimit {
+		desc += fmt.Sprintf("\nExibindo as primeiras %d músicas", playlistShowLimit)
+	}
+
+	owner := "Playlist pessoal de <@" + playlist.UserId + ">"
+	if playlist.GuildId != "" {
+		owner = "Playlist do servidor"
+	}
+
+	return i.Reply(s, &manager.InteractionResponse{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       "Playlist " + playlist.Name,
+			Description: owner + "\n" + desc,
+			Fields:      fields,
+			Footer:      utils.EmbedRequestedByFooter(i.Interaction),
+		}},
+	})
+}
+
+func (c *PlaylistCommand) handlePlay(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+	playlist *music.Playlist,
+) error {
+	cfg, vs, err := checkPlay(s, i, c.r)
+	if err != nil {
+		return err
+	}
+
+	tracks, err := c.pr.GetTracks(playlist.Id)
+	if err != nil {
+		return err
+	} else if len(tracks) == 0 {
+		return errors.Newf("a playlist **%s** está vazia", playlist.Name)
+	}
+
+	data := make([]*player.TrackData, len(tracks))
+	for idx, track := range tracks {
+		data[idx] = playlistTrackIntoData(track)
+	}
+
+	return addTracks(s, i, c.c, cfg, vs, data, 0)
+}
+
+func (c *PlaylistCommand) handleDelete(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+	playlist *music.Playlist,
+) error {
+	deleted, err := c.pr.Delete(playlist.Id)
+	if err != nil {
+		return err
+	} else if !deleted {
+		return errors.Newf("a playlist `%s` não foi encontrada", playlist.Name)
+	}
+
+	return i.Replyf(s, "Playlist **%s** apagada", playlist.Name)
+}
+
+func (c *PlaylistCommand) handleAutocomplete(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+	owner music.PlaylistOwner,
+) error {
+	name, err := i.GetStringOption("name", false)
+	if err != nil {
+		return err
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+
+	playlists, err := c.pr.GetByOwner(owner)
+	if err != nil {
+		slog.Warn(
+			"Failed to fetch autocomplete playlists",
+			"guild_id", i.GuildID,
+			"error", err,
+		)
+		return i.ReplyChoices(s, choices)
+	}
+
+	for _, playlist := range playlists {
+		if !strings.Contains(strings.ToLower(playlist.Name), name) {
+			continue
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name: truncate(
+				fmt.Sprintf("%s (%d músicas)", playlist.Name, playlist.TrackCount),
+				100,
+			),
+			Value: playlist.Name,
+		})
+	}
+
+	return i.ReplyChoices(s, choices)
+}
+
+func playlistTrackFromData(data *player.TrackData) music.PlaylistTrack {
+	return music.PlaylistTrack{
+		Name:      data.Name,
+		Url:       data.Url,
+		PlayQuery: data.PlayQuery,
+		Thumbnail: data.Thumbnail,
+		Duration:  data.Duration.AsDuration(),
+		Live:      data.Live,
+		Start:     data.Start.AsDuration(),
+		Author:    data.Author,
+	}
+}
+
+func playlistTrackIntoData(track music.PlaylistTrack) *player.TrackData {
+	data := &player.TrackData{
+		Name:      track.Name,
+		Url:       track.Url,
+		PlayQuery: track.PlayQuery,
+		Thumbnail: track.Thumbnail,
+		Duration:  durationpb.New(track.Duration),
+		Live:      track.Live,
+		Author:    track.Author,
+	}
+	if track.Start > 0 {
+		data.Start = durationpb.New(track.Start)
+	}
+	return data
+}
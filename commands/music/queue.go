@@ -1,10 +1,15 @@
 package musiccmds
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -13,8 +18,12 @@ import (
 	"github.com/zanz1n/duvua/internal/music"
 	"github.com/zanz1n/duvua/internal/utils"
 	"github.com/zanz1n/duvua/pkg/pb/player"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
+// The number of entries of an imported queue fetched at the same time
+const queueImportConcurrency = 4
+
 var queueCommandData = discordgo.ApplicationCommand{
 	Name:        "queue",
 	Type:        discordgo.ChatApplicationCommand,
@@ -30,6 +39,17 @@ var queueCommandData = discordgo.ApplicationCommand{
 			DescriptionLocalizations: map[discordgo.Locale]string{
 				discordgo.EnglishUS: "Shows all the musics that are in the queue",
 			},
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "mine",
+					Description: "Exibe apenas as músicas que você pediu",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "Shows only the musics you requested",
+					},
+					Required: false,
+				},
+			},
 		},
 		{
 			Type:        discordgo.ApplicationCommandOptionSubCommand,
@@ -50,6 +70,112 @@ var queueCommandData = discordgo.ApplicationCommand{
 				},
 			},
 		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "remove-range",
+			Description: "Remove as músicas entre duas posições da fila",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Removes the musics between two positions of the queue",
+			},
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "start",
+					Description: "A posição da primeira música que deseja remover",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "The position of the first music you want to remove",
+					},
+					Required: true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "end",
+					Description: "A posição da última música que deseja remover",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "The position of the last music you want to remove",
+					},
+					Required: true,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "remove-member",
+			Description: "Remove todas as músicas pedidas por um membro",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Removes all the musics requested by a member",
+			},
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "member",
+					Description: "O membro que pediu as músicas",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "The member that requested the musics",
+					},
+					Required: true,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "clear",
+			Description: "Remove todas as músicas da fila sem parar a música atual",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Removes all the musics of the queue without stopping the current one",
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "dedupe",
+			Description: "Remove as músicas repetidas da fila",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Removes the repeated musics of the queue",
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "export",
+			Description: "Exporta as músicas da fila como um arquivo",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Exports the musics of the queue as a file",
+			},
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "format",
+					Description: "O formato do arquivo (padrão: JSON)",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "The format of the file (default: JSON)",
+					},
+					Required: false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "JSON", Value: string(music.QueueFileFormatJson)},
+						{Name: "M3U", Value: string(music.QueueFileFormatM3u)},
+						{Name: "XSPF", Value: string(music.QueueFileFormatXspf)},
+					},
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "import",
+			Description: "Adiciona à fila as músicas de um arquivo exportado",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Adds to the queue the musics of an exported file",
+			},
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionAttachment,
+					Name:        "file",
+					Description: "O arquivo JSON, M3U ou XSPF da fila",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "The JSON, M3U or XSPF file of the queue",
+					},
+					Required: true,
+				},
+			},
+		},
 	},
 }
 
@@ -84,12 +210,15 @@ func (c *QueueCommand) Handle(s *discordgo.Session, i *manager.InteractionCreate
 
 		switch ids[1] {
 		case "list":
-			off := 0
+			page, userId := 0, ""
 			if len(ids) > 2 {
-				off, _ = strconv.Atoi(ids[2])
+				page, _ = strconv.Atoi(ids[2])
+			}
+			if len(ids) > 3 {
+				userId = ids[3]
 			}
 
-			embeds, components, err := c.handleList(i.GuildID, off)
+			embeds, components, err := c.handleList(i.GuildID, page, userId)
 			if err != nil {
 				return err
 			}
@@ -114,6 +243,9 @@ func (c *QueueCommand) Handle(s *discordgo.Session, i *manager.InteractionCreate
 			uid := ids[2]
 			return c.handleRemove(s, i, uid)
 
+		case "skipto":
+			return c.handleSkipTo(s, i)
+
 		default:
 			return errors.New("interação inválida")
 		}
@@ -126,7 +258,21 @@ func (c *QueueCommand) Handle(s *discordgo.Session, i *manager.InteractionCreate
 
 	switch subCommand.Name {
 	case "list":
-		embeds, components, err := c.handleList(i.GuildID, 0)
+		if _, err = musicConfig(i, c.r); err != nil {
+			return err
+		}
+
+		mine, err := i.GetBooleanOption("mine", false)
+		if err != nil {
+			return err
+		}
+
+		userId := ""
+		if mine {
+			userId = i.Member.User.ID
+		}
+
+		embeds, components, err := c.handleList(i.GuildID, 0, userId)
 		if err != nil {
 			return err
 		}
@@ -144,91 +290,191 @@ func (c *QueueCommand) Handle(s *discordgo.Session, i *manager.InteractionCreate
 
 		return c.handleRemoveByPosition(s, i, int(pos))
 
+	case "remove-range":
+		start, err := i.GetIntegerOption("start", true)
+		if err != nil {
+			return err
+		}
+		end, err := i.GetIntegerOption("end", true)
+		if err != nil {
+			return err
+		}
+
+		return c.handleRemoveRange(s, i, int(start), int(end))
+
+	case "remove-member":
+		userId, err := i.GetUserOption("member", true)
+		if err != nil {
+			return err
+		}
+
+		return c.handleRemoveMember(s, i, userId)
+
+	case "clear":
+		return c.handleClear(s, i)
+
+	case "dedupe":
+		return c.handleDedupe(s, i)
+
+	case "export":
+		format, err := i.GetStringOption("format", false)
+		if err != nil {
+			return err
+		} else if format == "" {
+			format = string(music.QueueFileFormatJson)
+		}
+
+		return c.handleExport(s, i, music.QueueFileFormat(format))
+
+	case "import":
+		attachment, err := i.GetAttachmentOption("file", true)
+		if err != nil {
+			return err
+		}
+
+		return c.handleImport(s, i, attachment)
+
 	default:
 		return errors.New("opção `sub-command` inválida")
 	}
 }
 
+// The number of tracks shown in each page of /queue list
+const queuePageSize = 10
+
 func (c *QueueCommand) handleList(
 	guildId string,
 	page int,
+	userId string,
 ) ([]*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
-	const pageSize = 10
-
-	offset, paddedOff := pageSize*page, pageSize*page
-
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
+	// The tracks before the page are needed to estimate when they play, and
+	// the whole queue to filter the tracks of the member
+	limit := queuePageSize * (page + 1)
+	if userId != "" {
+		// The queue size is unlimited if the guild has no max size
+		limit = math.MaxInt32
+	}
+
 	data, err := c.c.GetAll(ctx, &player.GetAllRequest{
 		GuildId: cuint64(guildId),
-		Offset:  int32(paddedOff),
-		Limit:   pageSize,
+		Offset:  0,
+		Limit:   int32(limit),
 	})
 	if err != nil {
 		return nil, nil, err
 	}
 
-	if offset > int(data.TotalSize) {
+	// The time until each track plays, -1 if unknown because of a livestream
+	// before it
+	etas := make([]time.Duration, len(data.Tracks))
+	eta := time.Duration(0)
+	if data.Playing != nil {
+		if data.Playing.Data.Live {
+			eta = -1
+		} else {
+			eta = data.Playing.Data.Duration.AsDuration() -
+				data.Playing.State.Progress.AsDuration()
+		}
+	}
+	for idx, track := range data.Tracks {
+		etas[idx] = eta
+		if eta >= 0 && !track.Data.Live {
+			eta += track.Data.Duration.AsDuration()
+		} else {
+			eta = -1
+		}
+	}
+
+	// The positions of the tracks in the queue, starting at 1
+	positions := make([]int, 0, len(data.Tracks))
+	for idx, track := range data.Tracks {
+		if userId == "" || strconv.FormatUint(track.UserId, 10) == userId {
+			positions = append(positions, idx+1)
+		}
+	}
+
+	offset := queuePageSize * page
+	if 0 > page || (offset >= len(positions) && page > 0) {
 		return nil, nil, errors.New("interação inválida")
 	}
+	pagePositions := positions[offset:min(offset+queuePageSize, len(positions))]
 
-	fields := make([]*discordgo.MessageEmbedField, 0, len(data.Tracks)+1)
-	totalDuration := time.Duration(0)
+	fields := make([]*discordgo.MessageEmbedField, 0, len(pagePositions)+1)
+	options := make([]discordgo.SelectMenuOption, 0, len(pagePositions))
 
 	if page == 0 && data.Playing != nil {
-		progress := data.Playing.State.Progress.AsDuration()
+		fields = append(fields, currentTrackField(data.Playing))
+	}
 
-		totalDuration += data.Playing.Data.Duration.AsDuration() - progress
+	for _, pos := range pagePositions {
+		track := data.Tracks[pos-1]
 
-		status := "Tocando"
-		if data.Playing.State.Looping {
-			status = "Em loop"
+		name := fmt.Sprintf("[%d°] Duração: [%s]", pos, music.FmtTrackDuration(track.Data))
+		if e := etas[pos-1]; e >= 0 {
+			name += fmt.Sprintf(" - Toca em: [%s]", utils.FmtDuration(e))
 		}
+
 		fields = append(fields, &discordgo.MessageEmbedField{
-			Name: fmt.Sprintf("[%s] Progresso: [%s/%s]",
-				status,
-				utils.FmtDuration(progress),
-				utils.FmtDuration(data.Playing.Data.Duration.AsDuration()),
-			),
-			Value: fmt.Sprintf("**[%s](%s)**",
-				data.Playing.Data.Name,
-				data.Playing.Data.Url,
+			Name: name,
+			Value: fmt.Sprintf("**[%s](%s)**\nPedida por <@%d>",
+				track.Data.Name,
+				track.Data.Url,
+				track.UserId,
 			),
 		})
-	}
-
-	for i, track := range data.Tracks {
-		dur := track.Data.Duration.AsDuration()
-
-		value := fmt.Sprintf("**[%s](%s)**", track.Data.Name, track.Data.Url)
 
-		totalDuration += dur
-
-		fields = append(fields, &discordgo.MessageEmbedField{
-			Name: fmt.Sprintf(
-				"[%d°] Duração: [%s]",
-				offset+i+1,
-				utils.FmtDuration(dur),
-			),
-			Value: value,
+		options = append(options, discordgo.SelectMenuOption{
+			Label: truncate(fmt.Sprintf("%d. %s", pos, track.Data.Name), 100),
+			Value: track.Id,
+			Emoji: emoji("⏭️"),
 		})
 	}
 
+	// Only the tracks up to the page are fetched without a filter
+	total := int(data.TotalSize)
+	if userId != "" {
+		total = len(positions)
+	}
+	pages := max((total+queuePageSize-1)/queuePageSize, 1)
+
 	title := "Fila de músicas"
-	if data.TotalSize > pageSize {
-		title += fmt.Sprintf(". Pág. %d/%d", page+1, (data.TotalSize/pageSize)+1)
+	if userId != "" {
+		title = "Suas músicas na fila"
+	}
+	if pages > 1 {
+		title += fmt.Sprintf(". Pág. %d/%d", page+1, pages)
+	}
+
+	desc := fmt.Sprintf(
+		"Duração total da playlist: **[%s]**",
+		utils.FmtDuration(data.TotalDuration.AsDuration()),
+	)
+	if userId != "" {
+		desc = fmt.Sprintf("%d músicas de <@%s> na fila\n", len(positions), userId) + desc
+	}
+	if data.Sleep != nil {
+		desc += "\n💤 " + music.FmtSleepTimer(data.Sleep)
 	}
 
 	embeds := []*discordgo.MessageEmbed{{
-		Title: title,
-		Description: fmt.Sprintf(
-			"Duração total da playlist: **[%s]**",
-			utils.FmtDuration(totalDuration),
-		),
-		Fields: fields,
+		Title:       title,
+		Description: desc,
+		Fields:      fields,
 	}}
 
+	// The filter is kept in the custom ids, so the pages are stable for
+	// everyone that clicks them
+	listCustomId := func(page int) string {
+		id := "queue/list/" + strconv.Itoa(page)
+		if userId != "" {
+			id += "/" + userId
+		}
+		return id
+	}
+
 	components := []discordgo.MessageComponent{
 		discordgo.ActionsRow{
 			Components: []discordgo.MessageComponent{
@@ -236,40 +482,133 @@ func (c *QueueCommand) handleList(
 					Label:    "Anterior",
 					Emoji:    emoji("◀️"),
 					Style:    discordgo.PrimaryButton,
-					CustomID: "queue/list/" + strconv.Itoa(page-1),
+					CustomID: listCustomId(page - 1),
 					Disabled: 0 >= page,
 				},
 				discordgo.Button{
 					Label:    "Próximo",
 					Emoji:    emoji("▶️"),
 					Style:    discordgo.PrimaryButton,
-					Disabled: pageSize*(page+1) >= int(data.TotalSize),
-					CustomID: "queue/list/" + strconv.Itoa(page+1),
+					CustomID: listCustomId(page + 1),
+					Disabled: page+1 >= pages,
 				},
 			},
 		},
 	}
 
+	if len(options) > 0 {
+		components = append(components, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID:    "queue/skipto",
+					Placeholder: "Pular para uma música da fila",
+					MenuType:    discordgo.StringSelectMenu,
+					MaxValues:   1,
+					Options:     options,
+				},
+			},
+		})
+	}
+
 	return embeds, components, nil
 }
 
+// currentTrackField shows the progress of the current track.
+func currentTrackField(track *player.Track) *discordgo.MessageEmbedField {
+	progress := track.State.Progress.AsDuration()
+
+	status := "Tocando"
+	if track.State.Looping {
+		status = "Em loop"
+	}
+
+	name := fmt.Sprintf("[%s] Progresso: [%s/%s]",
+		status,
+		utils.FmtDuration(progress),
+		utils.FmtDuration(track.Data.Duration.AsDuration()),
+	)
+	if track.Data.Live {
+		name = fmt.Sprintf("[%s] %s", status, music.FmtTrackDuration(track.Data))
+	}
+
+	value := fmt.Sprintf("**[%s](%s)**\nPedida por <@%d>",
+		track.Data.Name,
+		track.Data.Url,
+		track.UserId,
+	)
+	if title := track.State.StreamTitle; title != "" {
+		value += "\nNo ar: " + title
+	}
+
+	return &discordgo.MessageEmbedField{
+		Name:  name,
+		Value: value,
+	}
+}
+
+// handleSkipTo skips to the track selected in the menu of /queue list.
+func (c *QueueCommand) handleSkipTo(s *discordgo.Session, i *manager.InteractionCreate) error {
+	values := i.MessageComponentData().Values
+	if len(values) != 1 {
+		return errors.New("interação inválida")
+	}
+
+	cfg, err := musicConfig(i, c.r)
+	if err != nil {
+		return err
+	}
+
+	// The tracks of the other members before it are skipped as well
+	if err = checkPermission(i.Member, cfg, music.MusicActionSkip); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	res, err := c.c.SkipTo(ctx, &player.TrackIdRequest{
+		GuildId: cuint64(i.GuildID),
+		Id:      values[0],
+	})
+	if err != nil {
+		return err
+	}
+
+	return i.Replyf(s,
+		"⏭️ Pulando para a música **[%s](<%s>)**",
+		res.Track.Data.Name,
+		res.Track.Data.Url,
+	)
+}
+
 func (c *QueueCommand) handleRemove(
 	s *discordgo.Session,
 	i *manager.InteractionCreate,
 	id string,
 ) error {
-	cfg, err := c.r.GetOrDefault(i.GuildID)
+	cfg, err := musicConfig(i, c.r)
 	if err != nil {
 		return err
 	}
 
-	if err = canControl(i.Member, cfg); err != nil {
-		return err
-	}
-
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
+	if !canDo(i.Member, cfg, music.MusicActionRemove) {
+		res, err := c.c.GetById(ctx, &player.TrackIdRequest{
+			GuildId: cuint64(i.GuildID),
+			Id:      id,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = checkTrackPermission(i.Member, cfg, music.MusicActionRemove, res.Track)
+		if err != nil {
+			return err
+		}
+	}
+
 	track, err := c.c.Remove(ctx, &player.TrackIdRequest{
 		GuildId: cuint64(i.GuildID),
 		Id:      id,
@@ -290,18 +629,39 @@ func (c *QueueCommand) handleRemoveByPosition(
 	i *manager.InteractionCreate,
 	pos int,
 ) error {
-	cfg, err := c.r.GetOrDefault(i.GuildID)
+	cfg, err := musicConfig(i, c.r)
 	if err != nil {
 		return err
 	}
 
-	if err = canControl(i.Member, cfg); err != nil {
-		return err
-	}
-
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
+	if !canDo(i.Member, cfg, music.MusicActionRemove) {
+		// The position 0 is the current track
+		res, err := c.c.GetAll(ctx, &player.GetAllRequest{
+			GuildId: cuint64(i.GuildID),
+			Offset:  int32(pos - 1),
+			Limit:   1,
+		})
+		if err != nil {
+			return err
+		}
+
+		track := res.Playing
+		if pos != 0 {
+			track = nil
+			if len(res.Tracks) > 0 {
+				track = res.Tracks[0]
+			}
+		}
+
+		err = checkTrackPermission(i.Member, cfg, music.MusicActionRemove, track)
+		if err != nil {
+			return err
+		}
+	}
+
 	track, err := c.c.RemoveByPosition(ctx, &player.RemoveByPositionRequest{
 		GuildId:  cuint64(i.GuildID),
 		Position: int32(pos),
@@ -316,3 +676,269 @@ func (c *QueueCommand) handleRemoveByPosition(
 		track.Track.Data.Url,
 	)
 }
+
+func (c *QueueCommand) handleRemoveRange(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+	start, end int,
+) error {
+	if 1 > start || start > end {
+		return errors.New("o intervalo de posições é inválido")
+	}
+
+	if err := c.checkRemove(i); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	res, err := c.c.RemoveRange(ctx, &player.RemoveRangeRequest{
+		GuildId: cuint64(i.GuildID),
+		Start:   int32(start),
+		End:     int32(end),
+	})
+	if err != nil {
+		return err
+	}
+
+	return i.Replyf(s, "%d músicas removidas da fila", res.Removed)
+}
+
+func (c *QueueCommand) handleRemoveMember(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+	userId string,
+) error {
+	// The members can always remove their own tracks
+	if userId != i.Member.User.ID {
+		if err := c.checkRemove(i); err != nil {
+			return err
+		}
+	} else if _, err := musicConfig(i, c.r); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	res, err := c.c.RemoveByUser(ctx, &player.RemoveByUserRequest{
+		GuildId: cuint64(i.GuildID),
+		UserId:  cuint64(userId),
+	})
+	if err != nil {
+		return err
+	} else if res.Removed == 0 {
+		return errors.Newf("não há músicas de <@%s> na fila", userId)
+	}
+
+	return i.Replyf(s, "%d músicas de <@%s> removidas da fila", res.Removed, userId)
+}
+
+func (c *QueueCommand) handleClear(s *discordgo.Session, i *manager.InteractionCreate) error {
+	if err := c.checkRemove(i); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	res, err := c.c.Clear(ctx, &player.GuildIdRequest{
+		GuildId: cuint64(i.GuildID),
+	})
+	if err != nil {
+		return err
+	} else if res.Removed == 0 {
+		return errors.New("a fila está vazia")
+	}
+
+	return i.Replyf(s, "🧹 %d músicas removidas da fila", res.Removed)
+}
+
+func (c *QueueCommand) handleDedupe(s *discordgo.Session, i *manager.InteractionCreate) error {
+	if err := c.checkRemove(i); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	res, err := c.c.Dedupe(ctx, &player.GuildIdRequest{
+		GuildId: cuint64(i.GuildID),
+	})
+	if err != nil {
+		return err
+	} else if res.Removed == 0 {
+		return errors.New("não há músicas repetidas na fila")
+	}
+
+	return i.Replyf(s, "%d músicas repetidas removidas da fila", res.Removed)
+}
+
+// checkRemove checks if the member can remove the tracks of the others,
+// required by the bulk removals.
+func (c *QueueCommand) checkRemove(i *manager.InteractionCreate) error {
+	cfg, err := musicConfig(i, c.r)
+	if err != nil {
+		return err
+	}
+	return checkPermission(i.Member, cfg, music.MusicActionRemove)
+}
+
+func (c *QueueCommand) handleExport(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+	format music.QueueFileFormat,
+) error {
+	if _, err := musicConfig(i, c.r); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	res, err := c.c.GetAll(ctx, &player.GetAllRequest{
+		GuildId: cuint64(i.GuildID),
+		Offset:  0,
+		Limit:   music.MaxQueueFileEntries,
+	})
+	if err != nil {
+		return err
+	}
+
+	tracks := res.Tracks
+	if res.Playing != nil {
+		tracks = append([]*player.Track{res.Playing}, tracks...)
+	}
+	// The same limit of the imported files
+	tracks = tracks[:min(len(tracks), music.MaxQueueFileEntries)]
+	if len(tracks) == 0 {
+		return errors.New("a fila está vazia")
+	}
+
+	entries := make([]music.QueueFileEntry, len(tracks))
+	for idx, track := range tracks {
+		entries[idx] = music.QueueFileEntry{
+			Name:     track.Data.Name,
+			Url:      track.Data.Url,
+			Author:   track.Data.Author,
+			Duration: track.Data.Duration.AsDuration(),
+			Live:     track.Data.Live,
+			Start:    track.Data.Start.AsDuration(),
+		}
+	}
+
+	buf := bytes.Buffer{}
+	if err = music.EncodeQueueFile(&buf, format, entries); err != nil {
+		return err
+	}
+
+	return i.Reply(s, &manager.InteractionResponse{
+		Content: fmt.Sprintf("📄 Fila exportada com %d músicas", len(entries)),
+		Files: []*discordgo.File{{
+			Name:        "queue." + string(format),
+			ContentType: format.ContentType(),
+			Reader:      &buf,
+		}},
+	})
+}
+
+func (c *QueueCommand) handleImport(
+	s *discordgo.Session,
+	i *manager.InteractionCreate,
+	attachment *discordgo.MessageAttachment,
+) error {
+	format, err := music.QueueFileFormatFromName(attachment.Filename)
+	if err != nil {
+		return err
+	} else if attachment.Size > music.MaxQueueFileSize {
+		return errors.New("o arquivo enviado é muito grande")
+	}
+
+	cfg, vs, err := checkPlay(s, i, c.r)
+	if err != nil {
+		return err
+	}
+
+	// Each of the entries needs to be fetched by the player
+	if err = i.DeferReply(s, false); err != nil {
+		return err
+	}
+
+	entries, err := downloadQueueFile(attachment.URL, format)
+	if err != nil {
+		return err
+	}
+
+	skipped := int32(max(len(entries)-music.MaxQueueFileEntries, 0))
+	entries = entries[:min(len(entries), music.MaxQueueFileEntries)]
+
+	fetched := make([][]*player.TrackData, len(entries))
+
+	wg := sync.WaitGroup{}
+	sem := make(chan struct{}, queueImportConcurrency)
+	for idx, entry := range entries {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			res, err := c.c.Fetch(ctx, &player.FetchRequest{Query: entry.Url})
+			if err != nil {
+				slog.Warn(
+					"Failed to fetch imported queue entry",
+					"url", entry.Url,
+					"error", err,
+				)
+				return
+			}
+			// The start is only kept for the entries of a single track
+			if len(res.Data) == 1 && !res.Data[0].Live && entry.Start > 0 &&
+				entry.Start < res.Data[0].Duration.AsDuration() {
+				res.Data[0].Start = durationpb.New(entry.Start)
+			}
+			fetched[idx] = res.Data
+		}()
+	}
+	wg.Wait()
+
+	data := []*player.TrackData{}
+	for _, d := range fetched {
+		if len(d) == 0 {
+			skipped++
+		}
+		data = append(data, d...)
+	}
+
+	if len(data) == 0 {
+		return errors.New("nenhuma das músicas do arquivo pôde ser encontrada")
+	}
+
+	return addTracks(s, i, c.c, cfg, vs, data, skipped)
+}
+
+func downloadQueueFile(
+	url string,
+	format music.QueueFileFormat,
+) ([]music.QueueFileEntry, error) {
+	client := http.Client{Timeout: 5 * time.Second}
+
+	res, err := client.Get(url)
+	if err != nil {
+		return nil, errors.Unexpectedf("download queue file: %s", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Unexpectedf("download queue file: status %s", res.Status)
+	}
+
+	return music.DecodeQueueFile(res.Body, format)
+}
@@ -0,0 +1,82 @@
+package musiccmds_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	musiccmds "github.com/zanz1n/duvua/commands/music"
+	"github.com/zanz1n/duvua/pkg/pb/player"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// queuePlayerClient serves GetAll with a fixed queue, the other methods are
+// not implemented.
+type queuePlayerClient struct {
+	player.PlayerClient
+	tracks []*player.Track
+}
+
+func (c *queuePlayerClient) GetAll(
+	ctx context.Context,
+	req *player.GetAllRequest,
+	opts ...grpc.CallOption,
+) (*player.GetAllResponse, error) {
+	start := min(int(req.Offset), len(c.tracks))
+	end := min(start+int(req.Limit), len(c.tracks))
+
+	return &player.GetAllResponse{
+		TotalSize:     int32(len(c.tracks)),
+		TotalDuration: durationpb.New(0),
+		Tracks:        c.tracks[start:end],
+	}, nil
+}
+
+func listButtons(t *testing.T, components []discordgo.MessageComponent) (prev, next discordgo.Button) {
+	row, ok := components[0].(discordgo.ActionsRow)
+	require.True(t, ok)
+	require.Len(t, row.Components, 2)
+
+	prev, ok = row.Components[0].(discordgo.Button)
+	require.True(t, ok)
+	next, ok = row.Components[1].(discordgo.Button)
+	require.True(t, ok)
+	return
+}
+
+func TestQueueListPages(t *testing.T) {
+	tracks := make([]*player.Track, 25)
+	for idx := range tracks {
+		tracks[idx] = &player.Track{
+			Id:     strconv.Itoa(idx),
+			UserId: uint64(idx%2 + 1),
+			Data: &player.TrackData{
+				Name:     "Track " + strconv.Itoa(idx),
+				Url:      "https://example.com/" + strconv.Itoa(idx),
+				Duration: durationpb.New(0),
+			},
+		}
+	}
+	c := musiccmds.NewTestQueueCommand(&queuePlayerClient{tracks: tracks})
+
+	for page, wantNext := range []bool{true, true, false} {
+		embeds, components, err := c.HandleList("1", page, "")
+		require.NoError(t, err)
+
+		prev, next := listButtons(t, components)
+		assert.Equal(t, page == 0, prev.Disabled, "Unexpected previous button on page %d", page)
+		assert.Equal(t, !wantNext, next.Disabled, "Unexpected next button on page %d", page)
+		assert.Contains(t, embeds[0].Title, strconv.Itoa(page+1)+"/3")
+	}
+
+	// 13 of the tracks were added by the user 1
+	_, components, err := c.HandleList("1", 1, "1")
+	require.NoError(t, err)
+
+	_, next := listButtons(t, components)
+	assert.True(t, next.Disabled, "Expected the last page of the member")
+}
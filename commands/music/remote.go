@@ -0,0 +1,121 @@
+package musiccmds
+
+import (
+	"net/url"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zanz1n/duvua/internal/errors"
+	"github.com/zanz1n/duvua/internal/manager"
+	"github.com/zanz1n/duvua/internal/music"
+)
+
+var remoteCommandData = discordgo.ApplicationCommand{
+	Name:        "remote",
+	Type:        discordgo.ChatApplicationCommand,
+	Description: "Comandos relacionados ao controle remoto do player",
+	DescriptionLocalizations: &map[discordgo.Locale]string{
+		discordgo.EnglishUS: "Commands related to the remote control of the player",
+	},
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "token",
+			Description: "Gera um link de acesso ao controle remoto do player",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Generates an access link to the remote control of the player",
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "revoke",
+			Description: "Revoga os seus links de acesso ao controle remoto",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Revokes your access links to the remote control",
+			},
+		},
+	},
+}
+
+func NewRemoteCommand(
+	r music.MusicConfigRepository,
+	tr music.RemoteTokenRepository,
+	remoteUrl string,
+) *manager.Command {
+	return &manager.Command{
+		Accepts: manager.CommandAccept{
+			Slash:  true,
+			Button: false,
+		},
+		Data:     &remoteCommandData,
+		Category: manager.CommandCategoryMusic,
+		Handler:  &RemoteCommand{r: r, tr: tr, url: remoteUrl},
+	}
+}
+
+type RemoteCommand struct {
+	r   music.MusicConfigRepository
+	tr  music.RemoteTokenRepository
+	url string
+}
+
+func (c *RemoteCommand) Handle(s *discordgo.Session, i *manager.InteractionCreate) error {
+	if i.Member == nil || i.GuildID == "" {
+		return errors.New("esse comando só pode ser utilizado dentro de um servidor")
+	}
+
+	if c.url == "" {
+		return errors.New("o controle remoto não está disponível")
+	}
+
+	subCommand, err := i.GetSubCommand()
+	if err != nil {
+		return err
+	}
+
+	switch subCommand.Name {
+	case "token":
+		return c.handleToken(s, i)
+	case "revoke":
+		return c.handleRevoke(s, i)
+	default:
+		return errors.New("opção `sub-command` inválida")
+	}
+}
+
+func (c *RemoteCommand) handleToken(s *discordgo.Session, i *manager.InteractionCreate) error {
+	// The text channel restriction also applies to the messages of the
+	// tracks added with the remote
+	if _, err := musicConfig(i, c.r); err != nil {
+		return err
+	}
+
+	token, t, err := c.tr.Create(music.RemoteTokenCreateData{
+		GuildId:   i.GuildID,
+		UserId:    i.Member.User.ID,
+		ChannelId: i.ChannelID,
+	})
+	if err != nil {
+		return err
+	}
+
+	link := c.url + "?token=" + url.QueryEscape(token)
+
+	return i.ReplyEphemeralf(s,
+		"🎛️ [Abrir o controle remoto](<%s>)\n"+
+			"O link expira <t:%d:R>, não o compartilhe com ninguém. "+
+			"Use `/remote revoke` para invalidá-lo.",
+		link,
+		t.ExpiresAt.Unix(),
+	)
+}
+
+func (c *RemoteCommand) handleRevoke(s *discordgo.Session, i *manager.InteractionCreate) error {
+	n, err := c.tr.DeleteByUser(i.GuildID, i.Member.User.ID)
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return errors.New("você não possui links de acesso ao controle remoto")
+	}
+
+	return i.ReplyEphemeralf(s, "🔒 %d link(s) de acesso revogado(s)", n)
+}
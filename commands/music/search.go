@@ -0,0 +1,176 @@
+package musiccmds
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zanz1n/duvua/internal/errors"
+	"github.com/zanz1n/duvua/internal/manager"
+	"github.com/zanz1n/duvua/internal/music"
+	"github.com/zanz1n/duvua/internal/utils"
+	"github.com/zanz1n/duvua/pkg/pb/player"
+)
+
+const (
+	searchLimit           = 10
+	autocompleteLimit     = 8
+	autocompleteCacheTTL  = 2 * time.Minute
+	autocompleteCacheSize = 1024
+)
+
+var searchCommandData = discordgo.ApplicationCommand{
+	Name:        "search",
+	Type:        discordgo.ChatApplicationCommand,
+	Description: "Pesquisa uma música e permite escolher qual tocar",
+	DescriptionLocalizations: &map[discordgo.Locale]string{
+		discordgo.EnglishUS: "Searches for a music and lets you choose which one to play",
+	},
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "query",
+			Description: "O nome da música",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "The name of the music",
+			},
+			Required: true,
+		},
+	},
+}
+
+func NewSearchCommand(r music.MusicConfigRepository, client player.PlayerClient) *manager.Command {
+	if client == nil {
+		panic("NewSearchCommand() client must not be nil")
+	}
+
+	return &manager.Command{
+		Accepts: manager.CommandAccept{
+			Slash:  true,
+			Button: true,
+		},
+		Data:     &searchCommandData,
+		Category: manager.CommandCategoryMusic,
+		Handler:  &SearchCommand{r: r, c: client},
+	}
+}
+
+type SearchCommand struct {
+	r music.MusicConfigRepository
+	c player.PlayerClient
+}
+
+func (c *SearchCommand) Handle(s *discordgo.Session, i *manager.InteractionCreate) error {
+	if i.Member == nil || i.GuildID == "" {
+		return errors.New("esse comando só pode ser utilizado dentro de um servidor")
+	}
+
+	if i.Type == discordgo.InteractionMessageComponent {
+		data := i.MessageComponentData()
+		if len(data.Values) != 1 {
+			return errors.New("o input selecionado é inválido")
+		}
+
+		// Playlists may take a while to be loaded
+		return enqueue(s, i, c.r, c.c, data.Values[0], 5*time.Second, true)
+	}
+
+	query, err := i.GetStringOption("query", true)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	res, err := c.c.Search(ctx, &player.SearchRequest{
+		Query: query,
+		Limit: searchLimit,
+	})
+	if err != nil {
+		return err
+	}
+
+	fields := make([]*discordgo.MessageEmbedField, 0, len(res.Results))
+	options := make([]discordgo.SelectMenuOption, 0, len(res.Results))
+
+	for _, result := range res.Results {
+		// Select menu values are limited to 100 characters
+		if len(result.Url) > 100 {
+			continue
+		}
+
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name: fmt.Sprintf("[%d°] %s", len(fields)+1, searchResultInfo(result)),
+			Value: fmt.Sprintf("**[%s](%s)**",
+				result.Name,
+				result.Url,
+			),
+		})
+
+		e := emoji("🎵")
+		if result.Kind == player.SearchResultKind_SearchResultPlaylist {
+			e = emoji("📜")
+		}
+
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       truncate(fmt.Sprintf("%d. %s", len(options)+1, result.Name), 100),
+			Description: truncate(searchResultInfo(result), 100),
+			Value:       result.Url,
+			Emoji:       e,
+		})
+	}
+
+	if len(options) == 0 {
+		return errors.New("nenhum resultado encontrado")
+	}
+
+	return i.Reply(s, &manager.InteractionResponse{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:  "Resultados da pesquisa",
+			Fields: fields,
+			Footer: utils.EmbedRequestedByFooter(i.Interaction),
+		}},
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.SelectMenu{
+						CustomID:    "search",
+						Placeholder: "Selecione o que deseja tocar!",
+						MenuType:    discordgo.StringSelectMenu,
+						MaxValues:   1,
+						Options:     options,
+					},
+				},
+			},
+		},
+	})
+}
+
+// searchResultInfo returns the channel and the duration of the result.
+func searchResultInfo(result *player.SearchResult) string {
+	info := ""
+	if result.Kind == player.SearchResultKind_SearchResultPlaylist {
+		info = fmt.Sprintf("Playlist com %d vídeos", result.VideoCount)
+	} else {
+		info = utils.FmtDuration(result.Duration.AsDuration())
+	}
+
+	if result.Channel != "" {
+		info = result.Channel + " • " + info
+	}
+	return info
+}
+
+// searchResultLabel returns the text shown in /play autocomplete choices.
+func searchResultLabel(result *player.SearchResult) string {
+	return fmt.Sprintf("%s [%s]", result.Name, searchResultInfo(result))
+}
+
+func truncate(s string, max int) string {
+	if r := []rune(s); len(r) > max {
+		return string(r[:max-3]) + "..."
+	}
+	return s
+}
@@ -42,21 +42,39 @@ func (c *SkipCommand) Handle(s *discordgo.Session, i *manager.InteractionCreate)
 		return errors.New("esse comando só pode ser utilizado dentro de um servidor")
 	}
 
-	cfg, err := c.r.GetOrDefault(i.GuildID)
+	cfg, err := musicConfig(i, c.r)
 	if err != nil {
 		return err
 	}
 
-	if err = canControl(i.Member, cfg); err != nil {
-		return err
-	}
-
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	track, err := c.c.Skip(ctx, &player.GuildIdRequest{
-		GuildId: cuint64(i.GuildID),
-	})
+	var track *player.TrackResponse
+	if canDo(i.Member, cfg, music.MusicActionSkip) {
+		track, err = c.c.Skip(ctx, &player.GuildIdRequest{
+			GuildId: cuint64(i.GuildID),
+		})
+	} else {
+		var current *player.TrackResponse
+		current, err = c.c.GetCurrent(ctx, &player.GuildIdRequest{
+			GuildId: cuint64(i.GuildID),
+		})
+		if err != nil {
+			return err
+		}
+
+		err = checkTrackPermission(i.Member, cfg, music.MusicActionSkip, current.Track)
+		if err != nil {
+			return err
+		}
+
+		// Removed by id, so a track that started meanwhile is never skipped
+		track, err = c.c.Remove(ctx, &player.TrackIdRequest{
+			GuildId: cuint64(i.GuildID),
+			Id:      current.Track.Id,
+		})
+	}
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,183 @@
+package musiccmds
+
+import (
+	"context"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zanz1n/duvua/internal/errors"
+	"github.com/zanz1n/duvua/internal/manager"
+	"github.com/zanz1n/duvua/internal/music"
+	"github.com/zanz1n/duvua/pkg/pb/player"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	maxSleepMinutes = 12 * 60
+	maxSleepTracks  = 1000
+)
+
+var sleepCommandData = discordgo.ApplicationCommand{
+	Name:        "sleep",
+	Type:        discordgo.ChatApplicationCommand,
+	Description: "Comandos relacionados ao timer de desligamento do player",
+	DescriptionLocalizations: &map[discordgo.Locale]string{
+		discordgo.EnglishUS: "Commands related to the sleep timer of the player",
+	},
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "time",
+			Description: "Desliga o player depois de alguns minutos",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Stops the player after some minutes",
+			},
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "minutes",
+					Description: "Os minutos até o player ser desligado",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "The minutes until the player is stopped",
+					},
+					Required: true,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "current",
+			Description: "Desliga o player ao fim da música atual",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Stops the player at the end of the current music",
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "tracks",
+			Description: "Desliga o player depois de mais algumas músicas",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Stops the player after some more musics",
+			},
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "count",
+					Description: "O número de músicas tocadas depois da atual",
+					DescriptionLocalizations: map[discordgo.Locale]string{
+						discordgo.EnglishUS: "The number of musics played after the current one",
+					},
+					Required: true,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        "cancel",
+			Description: "Cancela o timer de desligamento",
+			DescriptionLocalizations: map[discordgo.Locale]string{
+				discordgo.EnglishUS: "Cancels the sleep timer",
+			},
+		},
+	},
+}
+
+func NewSleepCommand(r music.MusicConfigRepository, client player.PlayerClient) *manager.Command {
+	return &manager.Command{
+		Accepts: manager.CommandAccept{
+			Slash:  true,
+			Button: false,
+		},
+		Data:     &sleepCommandData,
+		Category: manager.CommandCategoryMusic,
+		Handler:  &SleepCommand{r: r, c: client},
+	}
+}
+
+type SleepCommand struct {
+	r music.MusicConfigRepository
+	c player.PlayerClient
+}
+
+func (c *SleepCommand) Handle(s *discordgo.Session, i *manager.InteractionCreate) error {
+	if i.Member == nil || i.GuildID == "" {
+		return errors.New("esse comando só pode ser utilizado dentro de um servidor")
+	}
+
+	cfg, err := musicConfig(i, c.r)
+	if err != nil {
+		return err
+	}
+
+	// The sleep timer stops the player, so it follows the same permission
+	if err = checkPermission(i.Member, cfg, music.MusicActionStop); err != nil {
+		return err
+	}
+
+	subCommand, err := i.GetSubCommand()
+	if err != nil {
+		return err
+	}
+
+	req := &player.SetSleepRequest{GuildId: cuint64(i.GuildID)}
+
+	switch subCommand.Name {
+	case "time":
+		minutes, err := i.GetIntegerOption("minutes", true)
+		if err != nil {
+			return err
+		} else if 1 > minutes || minutes > maxSleepMinutes {
+			return errors.Newf("o timer precisa ter entre 1 e %d minutos", maxSleepMinutes)
+		}
+
+		req.Mode = player.SleepMode_SleepModeTime
+		req.Duration = durationpb.New(time.Duration(minutes) * time.Minute)
+
+	case "current":
+		req.Mode = player.SleepMode_SleepModeTracks
+		req.Tracks = 0
+
+	case "tracks":
+		count, err := i.GetIntegerOption("count", true)
+		if err != nil {
+			return err
+		} else if 1 > count || count > maxSleepTracks {
+			return errors.Newf("o timer precisa ter entre 1 e %d músicas", maxSleepTracks)
+		}
+
+		req.Mode = player.SleepMode_SleepModeTracks
+		req.Tracks = int32(count)
+
+	case "cancel":
+		return c.handleCancel(s, i)
+
+	default:
+		return errors.New("opção `sub-command` inválida")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	res, err := c.c.SetSleep(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return i.Replyf(s, "💤 %s", music.FmtSleepTimer(res.Timer))
+}
+
+func (c *SleepCommand) handleCancel(s *discordgo.Session, i *manager.InteractionCreate) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	res, err := c.c.CancelSleep(ctx, &player.GuildIdRequest{
+		GuildId: cuint64(i.GuildID),
+	})
+	if err != nil {
+		return err
+	} else if !res.Changed {
+		return errors.New("o timer de desligamento não está ativo")
+	}
+
+	return i.Replyf(s, "Timer de desligamento cancelado")
+}
@@ -42,12 +42,12 @@ func (c *StopCommand) Handle(s *discordgo.Session, i *manager.InteractionCreate)
 		return errors.New("esse comando só pode ser utilizado dentro de um servidor")
 	}
 
-	cfg, err := c.r.GetOrDefault(i.GuildID)
+	cfg, err := musicConfig(i, c.r)
 	if err != nil {
 		return err
 	}
 
-	if err = canControl(i.Member, cfg); err != nil {
+	if err = checkPermission(i.Member, cfg, music.MusicActionStop); err != nil {
 		return err
 	}
 
@@ -42,12 +42,12 @@ func (c *UnpauseCommand) Handle(s *discordgo.Session, i *manager.InteractionCrea
 		return errors.New("esse comando só pode ser utilizado dentro de um servidor")
 	}
 
-	cfg, err := c.r.GetOrDefault(i.GuildID)
+	cfg, err := musicConfig(i, c.r)
 	if err != nil {
 		return err
 	}
 
-	if err = canControl(i.Member, cfg); err != nil {
+	if err = checkPermission(i.Member, cfg, music.MusicActionPause); err != nil {
 		return err
 	}
 
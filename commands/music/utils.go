@@ -3,11 +3,14 @@ package musiccmds
 import (
 	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/zanz1n/duvua/internal/errors"
+	"github.com/zanz1n/duvua/internal/manager"
 	"github.com/zanz1n/duvua/internal/music"
 	"github.com/zanz1n/duvua/internal/utils"
+	"github.com/zanz1n/duvua/pkg/pb/player"
 )
 
 func cuint64(s string) uint64 {
@@ -15,38 +18,81 @@ func cuint64(s string) uint64 {
 	return v
 }
 
-func canPlay(m *discordgo.Member, cfg *music.MusicConfig) bool {
-	switch cfg.PlayMode {
-	case music.MusicPermissionAll:
-		return true
-	case music.MusicPermissionAdm:
-		return utils.HasPerm(m.Permissions, discordgo.PermissionAdministrator)
-	case music.MusicPermissionDJ:
-		return utils.HasPerm(m.Permissions, discordgo.PermissionAdministrator) ||
-			slices.Contains(m.Roles, cfg.DjRole)
-	default:
-		return false
+// musicConfig returns the music config of the guild, checking if the music
+// commands can be used in the channel of the interaction.
+func musicConfig(
+	i *manager.InteractionCreate,
+	r music.MusicConfigRepository,
+) (*music.MusicConfig, error) {
+	cfg, err := r.GetOrDefault(i.GuildID)
+	if err != nil {
+		return nil, err
 	}
+
+	if len(cfg.TextChannels) > 0 && !slices.Contains(cfg.TextChannels, i.ChannelID) {
+		return nil, errors.Newf(
+			"os comandos de música só podem ser usados em %s",
+			fmtChannels(cfg.TextChannels),
+		)
+	}
+
+	return cfg, nil
 }
 
-func canControl(m *discordgo.Member, cfg *music.MusicConfig) error {
-	can := false
-	switch cfg.ControlMode {
-	case music.MusicPermissionAll:
-		can = true
-	case music.MusicPermissionAdm:
-		can = utils.HasPerm(m.Permissions, discordgo.PermissionAdministrator)
-	case music.MusicPermissionDJ:
-		can = utils.HasPerm(m.Permissions, discordgo.PermissionAdministrator) ||
-			slices.Contains(m.Roles, cfg.DjRole)
+// checkVoiceChannel checks if the musics can be played in the voice channel.
+func checkVoiceChannel(cfg *music.MusicConfig, channelId string) error {
+	if !cfg.VoiceChannelAllowed(channelId) {
+		return errors.Newf(
+			"as músicas só podem ser tocadas em %s",
+			fmtChannels(cfg.VoiceChannels),
+		)
 	}
+	return nil
+}
 
-	if !can {
-		return errors.New("você não tem permissão para controlar a playlist no servidor")
+func fmtChannels(ids []string) string {
+	mentions := make([]string, len(ids))
+	for idx, id := range ids {
+		mentions[idx] = "<#" + id + ">"
+	}
+	return strings.Join(mentions, ", ")
+}
+
+// canDo reports whether the member is allowed to do the action in the
+// guild, administrators can do all the actions.
+func canDo(m *discordgo.Member, cfg *music.MusicConfig, action music.MusicAction) bool {
+	admin := utils.HasPerm(m.Permissions, discordgo.PermissionAdministrator)
+	return cfg.CanDo(m.Roles, admin, action)
+}
+
+func checkPermission(
+	m *discordgo.Member,
+	cfg *music.MusicConfig,
+	action music.MusicAction,
+) error {
+	if !canDo(m, cfg, action) {
+		return errors.Newf(
+			"você não tem permissão para %s no servidor",
+			strings.ToLower(action.StringPtBr()),
+		)
 	}
 	return nil
 }
 
+// checkTrackPermission is like checkPermission, but the members can always
+// skip or remove the tracks they added.
+func checkTrackPermission(
+	m *discordgo.Member,
+	cfg *music.MusicConfig,
+	action music.MusicAction,
+	track *player.Track,
+) error {
+	if track != nil && strconv.FormatUint(track.UserId, 10) == m.User.ID {
+		return nil
+	}
+	return checkPermission(m, cfg, action)
+}
+
 func emoji(name string) *discordgo.ComponentEmoji {
 	return &discordgo.ComponentEmoji{Name: name}
 }
@@ -164,6 +164,24 @@ func (i *InteractionCreate) ReplyEphemeral(s *discordgo.Session, resp *Interacti
 	}
 }
 
+func (i *InteractionCreate) ReplyChoices(
+	s *discordgo.Session,
+	choices []*discordgo.ApplicationCommandOptionChoice,
+) error {
+	i.State.Lock()
+	defer i.State.Unlock()
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: choices},
+	})
+	if err == nil {
+		i.State.Replied = true
+	}
+
+	return err
+}
+
 func (i *InteractionCreate) GetTypedOption(
 	name string,
 	required bool,
@@ -187,7 +205,8 @@ func (i *InteractionCreate) GetOption(
 	name string,
 	required bool,
 ) (*discordgo.ApplicationCommandInteractionDataOption, error) {
-	if i.Type != discordgo.InteractionApplicationCommand {
+	if i.Type != discordgo.InteractionApplicationCommand &&
+		i.Type != discordgo.InteractionApplicationCommandAutocomplete {
 		return nil, errors.New("interação de tipo inesperado")
 	}
 	data := i.ApplicationCommandData()
@@ -226,7 +245,8 @@ func (i *InteractionCreate) GetSubCommand() (
 	*discordgo.ApplicationCommandInteractionDataOption,
 	error,
 ) {
-	if i.Type != discordgo.InteractionApplicationCommand {
+	if i.Type != discordgo.InteractionApplicationCommand &&
+		i.Type != discordgo.InteractionApplicationCommandAutocomplete {
 		return nil, errors.New("interação de tipo inesperado")
 	}
 	data := i.ApplicationCommandData()
@@ -341,3 +361,23 @@ func (i *InteractionCreate) GetChannelOption(name string, required bool) (string
 
 	return channelId, nil
 }
+
+func (i *InteractionCreate) GetAttachmentOption(
+	name string,
+	required bool,
+) (*discordgo.MessageAttachment, error) {
+	opt, err := i.GetTypedOption(name, required, discordgo.ApplicationCommandOptionAttachment)
+	if err != nil {
+		return nil, err
+	} else if opt == nil {
+		return nil, nil
+	}
+	attachmentId := opt.Value.(string)
+
+	resolved := i.ApplicationCommandData().Resolved
+	if resolved == nil || resolved.Attachments[attachmentId] == nil {
+		return nil, errors.Newf("opção `%s` é necessária", name)
+	}
+
+	return resolved.Attachments[attachmentId], nil
+}
@@ -5,4 +5,39 @@ import "github.com/zanz1n/duvua/internal/errors"
 var (
 	ErrInvalidRolelId = errors.Unexpected("music: roleId is not a valid int64")
 	ErrInvalidGuildId = errors.Unexpected("music: guildId is not a valid int64")
+	ErrInvalidUserId  = errors.Unexpected("music: userId is not a valid int64")
+
+	ErrInvalidChannelId = errors.Unexpected("music: channelId is not a valid int64")
+
+	ErrInvalidRemoteToken = errors.New("o token do controle remoto é inválido ou expirou")
+
+	ErrInvalidQueueFile       = errors.New("o arquivo não é uma fila válida ou está vazio")
+	ErrInvalidQueueFileFormat = errors.New(
+		"o formato do arquivo precisa ser JSON, M3U ou XSPF",
+	)
+
+	ErrInvalidPlaylistOwner = errors.Unexpected(
+		"music: the playlist must be owned by either a guild or an user",
+	)
+	ErrPlaylistNameTaken = errors.New("já existe uma playlist com esse nome")
+	ErrPlaylistFull      = errors.Newf(
+		"as playlists podem ter no máximo %d músicas",
+		MaxPlaylistTracks,
+	)
+	ErrDjRolesFull = errors.Newf(
+		"o servidor pode ter no máximo %d cargos de DJ",
+		MaxDjRoles,
+	)
+	ErrChannelsFull = errors.Newf(
+		"o servidor pode ter no máximo %d canais de música",
+		MaxChannels,
+	)
+	ErrBlocklistFull = errors.Newf(
+		"a lista de bloqueio pode ter no máximo %d itens",
+		MaxBlocklistEntries,
+	)
+	ErrFavoritesFull = errors.Newf(
+		"você pode ter no máximo %d músicas favoritas",
+		MaxFavorites,
+	)
 )
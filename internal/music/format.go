@@ -0,0 +1,33 @@
+package music
+
+import (
+	"fmt"
+
+	"github.com/zanz1n/duvua/internal/utils"
+	"github.com/zanz1n/duvua/pkg/pb/player"
+)
+
+// FmtTrackDuration formats the duration of the track, livestreams have no
+// duration.
+func FmtTrackDuration(data *player.TrackData) string {
+	if data.Live {
+		return "🔴 AO VIVO"
+	}
+	return utils.FmtDuration(data.Duration.AsDuration())
+}
+
+// FmtSleepTimer describes when the player is stopped by the sleep timer.
+func FmtSleepTimer(sleep *player.SleepTimer) string {
+	if sleep.Mode == player.SleepMode_SleepModeTime {
+		return fmt.Sprintf("O player será desligado <t:%d:R>", sleep.Deadline.AsTime().Unix())
+	}
+
+	switch sleep.Tracks {
+	case 0:
+		return "O player será desligado ao fim da música atual"
+	case 1:
+		return "O player será desligado após mais 1 música"
+	default:
+		return fmt.Sprintf("O player será desligado após mais %d músicas", sleep.Tracks)
+	}
+}
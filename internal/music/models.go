@@ -1,6 +1,8 @@
 package music
 
 import (
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/zanz1n/duvua/internal/errors"
@@ -11,6 +13,28 @@ const (
 
 	DefaultConfigPlayMode    = MusicPermissionAll
 	DefaultConfigControlMode = MusicPermissionDJ
+
+	DefaultConfigVolume         = 100
+	DefaultConfigIdleTimeout    = 10 * time.Second
+	DefaultConfigPauseTimeout   = 5 * time.Minute
+	DefaultConfigAnnounceTracks = true
+	DefaultConfigAlwaysOn       = false
+	// Unlimited
+	DefaultConfigMaxQueueSize = 0
+)
+
+const (
+	MaxDjRoles = 10
+	// The max number of text and voice channels allowed
+	MaxChannels = 25
+
+	MaxBlocklistEntries       = 100
+	MaxBlocklistPatternLength = 128
+
+	MaxConfigVolume       = 200
+	MaxConfigIdleTimeout  = time.Hour
+	MaxConfigPauseTimeout = time.Hour
+	MaxConfigQueueSize    = 10000
 )
 
 type MusicPermission string
@@ -57,6 +81,85 @@ func (m MusicPermission) StringPtBr() string {
 	}
 }
 
+// MusicAction is an action on the player that can be restricted.
+type MusicAction string
+
+func ParseMusicAction(s string) (MusicAction, error) {
+	v := MusicAction(s)
+	switch v {
+	case MusicActionPlay, MusicActionSkip, MusicActionStop, MusicActionPause,
+		MusicActionLoop, MusicActionVolume, MusicActionRemove:
+		return v, nil
+	default:
+		return "", errors.Newf("valor inválido `%s`", s)
+	}
+}
+
+const (
+	MusicActionPlay  MusicAction = "play"
+	MusicActionSkip  MusicAction = "skip"
+	MusicActionStop  MusicAction = "stop"
+	MusicActionPause MusicAction = "pause"
+	MusicActionLoop  MusicAction = "loop"
+	// Changing the volume of the player
+	MusicActionVolume MusicAction = "volume"
+	// Removing tracks added by other members, the members can always remove
+	// their own tracks
+	MusicActionRemove MusicAction = "remove"
+)
+
+var MusicActions = []MusicAction{
+	MusicActionPlay,
+	MusicActionSkip,
+	MusicActionStop,
+	MusicActionPause,
+	MusicActionLoop,
+	MusicActionVolume,
+	MusicActionRemove,
+}
+
+func (a MusicAction) StringEnUs() string {
+	switch a {
+	case MusicActionPlay:
+		return "Play musics"
+	case MusicActionSkip:
+		return "Skip musics"
+	case MusicActionStop:
+		return "Stop the player"
+	case MusicActionPause:
+		return "Pause the player"
+	case MusicActionLoop:
+		return "Loop musics"
+	case MusicActionVolume:
+		return "Change the volume"
+	case MusicActionRemove:
+		return "Remove musics of other members"
+	default:
+		return "Unknown"
+	}
+}
+
+func (a MusicAction) StringPtBr() string {
+	switch a {
+	case MusicActionPlay:
+		return "Tocar músicas"
+	case MusicActionSkip:
+		return "Pular músicas"
+	case MusicActionStop:
+		return "Parar o player"
+	case MusicActionPause:
+		return "Pausar o player"
+	case MusicActionLoop:
+		return "Repetir músicas"
+	case MusicActionVolume:
+		return "Mudar o volume"
+	case MusicActionRemove:
+		return "Remover músicas de outros membros"
+	default:
+		return "Desconhecido"
+	}
+}
+
 type MusicConfig struct {
 	GuildId     string
 	CreatedAt   time.Time
@@ -64,8 +167,163 @@ type MusicConfig struct {
 	Enabled     bool
 	PlayMode    MusicPermission
 	ControlMode MusicPermission
-	// Nullable: coallessed to empty string
-	DjRole string
+	DjRoles     []string
+	// The permissions that override the PlayMode and the ControlMode
+	Permissions map[MusicAction]MusicPermission
+	// All the text channels are allowed if empty
+	TextChannels []string
+	// All the voice channels are allowed if empty
+	VoiceChannels []string
+	Blocklist     []BlocklistEntry
+
+	Player MusicPlayerConfig
+}
+
+// Permission returns who can do the action, if it was not overridden the
+// play action falls back to the PlayMode and the others to the ControlMode.
+func (c *MusicConfig) Permission(action MusicAction) MusicPermission {
+	if mode, ok := c.Permissions[action]; ok {
+		return mode
+	}
+	if action == MusicActionPlay {
+		return c.PlayMode
+	}
+	return c.ControlMode
+}
+
+// CanDo reports whether a member with the roles can do the action,
+// administrators can do all the actions.
+func (c *MusicConfig) CanDo(roles []string, admin bool, action MusicAction) bool {
+	if admin {
+		return true
+	}
+
+	switch c.Permission(action) {
+	case MusicPermissionAll:
+		return true
+	case MusicPermissionDJ:
+		return slices.ContainsFunc(roles, func(role string) bool {
+			return slices.Contains(c.DjRoles, role)
+		})
+	default:
+		return false
+	}
+}
+
+// VoiceChannelAllowed reports whether the musics can be played in the voice
+// channel.
+func (c *MusicConfig) VoiceChannelAllowed(channelId string) bool {
+	return len(c.VoiceChannels) == 0 || slices.Contains(c.VoiceChannels, channelId)
+}
+
+// Blocked returns the first blocklist entry that matches the track, or nil
+// if the track is not blocked.
+func (c *MusicConfig) Blocked(name, url, author string) *BlocklistEntry {
+	for idx := range c.Blocklist {
+		if c.Blocklist[idx].Matches(name, url, author) {
+			return &c.Blocklist[idx]
+		}
+	}
+	return nil
+}
+
+type BlocklistKind string
+
+func ParseBlocklistKind(s string) (BlocklistKind, error) {
+	v := BlocklistKind(s)
+	switch v {
+	case BlocklistKindUrl, BlocklistKindChannel, BlocklistKindKeyword:
+		return v, nil
+	default:
+		return "", errors.Newf("valor inválido `%s`", s)
+	}
+}
+
+const (
+	// Matches the urls that contain the pattern, like video ids
+	BlocklistKindUrl BlocklistKind = "Url"
+	// Matches the tracks published by the channel or artist
+	BlocklistKindChannel BlocklistKind = "Channel"
+	// Matches the tracks whose names contain the pattern
+	BlocklistKindKeyword BlocklistKind = "Keyword"
+)
+
+func (k BlocklistKind) StringEnUs() string {
+	switch k {
+	case BlocklistKindUrl:
+		return "Url or id"
+	case BlocklistKindChannel:
+		return "Channel"
+	case BlocklistKindKeyword:
+		return "Keyword"
+	default:
+		return "Unknown"
+	}
+}
+
+func (k BlocklistKind) StringPtBr() string {
+	switch k {
+	case BlocklistKindUrl:
+		return "Url ou id"
+	case BlocklistKindChannel:
+		return "Canal"
+	case BlocklistKindKeyword:
+		return "Palavra-chave"
+	default:
+		return "Desconhecido"
+	}
+}
+
+type BlocklistEntry struct {
+	Id        int64
+	CreatedAt time.Time
+	GuildId   string
+	CreatedBy string
+	Kind      BlocklistKind
+	Pattern   string
+}
+
+// Matches reports whether the track is blocked by the entry, all the
+// comparisons are case insensitive.
+func (e *BlocklistEntry) Matches(name, url, author string) bool {
+	pattern := strings.ToLower(e.Pattern)
+
+	switch e.Kind {
+	case BlocklistKindUrl:
+		return strings.Contains(strings.ToLower(url), pattern)
+	case BlocklistKindChannel:
+		return author != "" && strings.ToLower(author) == pattern
+	case BlocklistKindKeyword:
+		return strings.Contains(strings.ToLower(name), pattern)
+	default:
+		return false
+	}
+}
+
+// MusicPlayerConfig are the settings sent to the player with the tracks.
+type MusicPlayerConfig struct {
+	// In percent, 100 is the original volume
+	Volume int
+	// How long the player waits for new tracks when the queue ends
+	IdleTimeout time.Duration
+	// How long the player can stay paused before leaving
+	PauseTimeout   time.Duration
+	AnnounceTracks bool
+	// The player never leaves the voice channel by itself (24/7 mode)
+	AlwaysOn bool
+	// Unlimited if zero
+	MaxQueueSize int
+}
+
+func DefaultMusicPlayerConfig() MusicPlayerConfig {
+	return MusicPlayerConfig{
+		Volume:         DefaultConfigVolume,
+		IdleTimeout:    DefaultConfigIdleTimeout,
+		PauseTimeout:   DefaultConfigPauseTimeout,
+		AnnounceTracks: DefaultConfigAnnounceTracks,
+		AlwaysOn:       DefaultConfigAlwaysOn,
+		MaxQueueSize:   DefaultConfigMaxQueueSize,
+	}
 }
 
 type MusicConfigCreateData struct {
@@ -73,5 +331,151 @@ type MusicConfigCreateData struct {
 	Enabled     bool
 	PlayMode    MusicPermission
 	ControlMode MusicPermission
-	DjRole      string
+	// Defaulted if nil
+	Player *MusicPlayerConfig
+}
+
+const (
+	MaxPlaylists          = 25
+	MaxPlaylistTracks     = 200
+	MaxPlaylistNameLength = 64
+)
+
+// PlaylistOwner is either a guild or an user, only one of the ids is set.
+type PlaylistOwner struct {
+	GuildId string
+	UserId  string
+}
+
+func GuildPlaylistOwner(guildId string) PlaylistOwner {
+	return PlaylistOwner{GuildId: guildId}
+}
+
+func UserPlaylistOwner(userId string) PlaylistOwner {
+	return PlaylistOwner{UserId: userId}
+}
+
+func (o PlaylistOwner) IsGuild() bool {
+	return o.GuildId != ""
+}
+
+type Playlist struct {
+	Id        int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Name      string
+	// Nullable: coallessed to empty string
+	GuildId string
+	// Nullable: coallessed to empty string
+	UserId    string
+	CreatedBy string
+
+	TrackCount int
+	Duration   time.Duration
+}
+
+func (p *Playlist) Owner() PlaylistOwner {
+	return PlaylistOwner{GuildId: p.GuildId, UserId: p.UserId}
+}
+
+// PlaylistTrack keeps the data of the track as it was fetched, so it can be
+// played again without searching it.
+type PlaylistTrack struct {
+	// Starts at zero
+	Position  int
+	Name      string
+	Url       string
+	PlayQuery string
+	Thumbnail string
+	Duration  time.Duration
+	Live      bool
+	// Where the playback starts
+	Start time.Duration
+	// The channel or artist that published the track, may be empty
+	Author string
+}
+
+const MaxFavorites = 200
+
+// Favorite is a track liked by an user, kept the same way as the
+// PlaylistTracks.
+type Favorite struct {
+	Id        int64
+	CreatedAt time.Time
+	UserId    string
+	Name      string
+	Url       string
+	PlayQuery string
+	Thumbnail string
+	Duration  time.Duration
+	Live      bool
+	// The channel or artist that published the track, may be empty
+	Author string
+}
+
+type PlayLogCreateData struct {
+	GuildId string
+	// The user that requested the track
+	UserId    string
+	StartedAt time.Time
+	Name      string
+	Url       string
+	Duration  time.Duration
+	// How long the track was actually played
+	Listened time.Duration
+	// If the track was skipped or stopped before it ended
+	Skipped bool
+}
+
+type PlayStatsFilter struct {
+	GuildId string
+	// Optional: the stats of the whole guild are returned if empty
+	UserId string
+	// Optional: all the plays are considered if zero
+	Since time.Time
+}
+
+type PlayStats struct {
+	Plays    int
+	Skips    int
+	Listened time.Duration
+
+	TopTracks     []TrackPlayStats
+	TopRequesters []RequesterPlayStats
+	// The number of plays started on each hour of the day, in UTC
+	Hours [24]int
+}
+
+type TrackPlayStats struct {
+	Name  string
+	Url   string
+	Plays int
+}
+
+type RequesterPlayStats struct {
+	UserId   string
+	Plays    int
+	Listened time.Duration
+}
+
+const (
+	RemoteTokenLength = 43
+	RemoteTokenTTL    = 12 * time.Hour
+)
+
+// RemoteToken grants a member access to the web remote of the player of a
+// guild.
+type RemoteToken struct {
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	GuildId   string
+	UserId    string
+	// The text channel where the tracks added by the remote are announced
+	ChannelId string
+}
+
+type RemoteTokenCreateData struct {
+	GuildId   string
+	UserId    string
+	ChannelId string
 }
@@ -0,0 +1,72 @@
+package music_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zanz1n/duvua/internal/music"
+)
+
+func TestBlocklistEntryMatches(t *testing.T) {
+	tests := []struct {
+		entry  music.BlocklistEntry
+		name   string
+		url    string
+		author string
+		want   bool
+	}{
+		{
+			entry: music.BlocklistEntry{Kind: music.BlocklistKindUrl, Pattern: "dQw4w9WgXcQ"},
+			url:   "https://youtu.be/dQw4w9WgXcQ",
+			want:  true,
+		},
+		{
+			entry: music.BlocklistEntry{Kind: music.BlocklistKindUrl, Pattern: "dQw4w9WgXcQ"},
+			name:  "dQw4w9WgXcQ",
+			url:   "https://youtu.be/aaaaaaaaaaa",
+			want:  false,
+		},
+		{
+			entry:  music.BlocklistEntry{Kind: music.BlocklistKindChannel, Pattern: "Some Channel"},
+			author: "some channel",
+			want:   true,
+		},
+		{
+			entry:  music.BlocklistEntry{Kind: music.BlocklistKindChannel, Pattern: "Some"},
+			author: "Some Channel",
+			want:   false,
+		},
+		{
+			entry: music.BlocklistEntry{Kind: music.BlocklistKindKeyword, Pattern: "earrape"},
+			name:  "Song (EARRAPE version)",
+			want:  true,
+		},
+		{
+			entry: music.BlocklistEntry{Kind: music.BlocklistKindKeyword, Pattern: "earrape"},
+			name:  "Song",
+			url:   "https://example.com/earrape.mp3",
+			want:  false,
+		},
+	}
+
+	for _, test := range tests {
+		got := test.entry.Matches(test.name, test.url, test.author)
+		assert.Equal(t, test.want, got,
+			"Unexpected match of %s `%s`", test.entry.Kind, test.entry.Pattern,
+		)
+	}
+}
+
+func TestMusicConfigPermission(t *testing.T) {
+	cfg := music.MusicConfig{
+		PlayMode:    music.MusicPermissionAll,
+		ControlMode: music.MusicPermissionDJ,
+		Permissions: map[music.MusicAction]music.MusicPermission{
+			music.MusicActionStop: music.MusicPermissionAdm,
+		},
+	}
+
+	assert.Equal(t, music.MusicPermissionAll, cfg.Permission(music.MusicActionPlay))
+	assert.Equal(t, music.MusicPermissionDJ, cfg.Permission(music.MusicActionSkip))
+	assert.Equal(t, music.MusicPermissionAdm, cfg.Permission(music.MusicActionStop))
+}
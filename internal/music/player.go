@@ -0,0 +1,97 @@
+package music
+
+import (
+	"log/slog"
+
+	"github.com/zanz1n/duvua/pkg/pb/player"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// PlayerConfig returns the settings sent to the player with the added
+// tracks, including the blocklist of the guild.
+func (c *MusicConfig) PlayerConfig() *player.PlayerConfig {
+	cfg := c.Player.Into()
+	cfg.Blocklist = make([]*player.BlocklistEntry, len(c.Blocklist))
+	for idx, entry := range c.Blocklist {
+		cfg.Blocklist[idx] = entry.Into()
+	}
+	return cfg
+}
+
+// Into converts the player settings of the guild.
+func (c MusicPlayerConfig) Into() *player.PlayerConfig {
+	return &player.PlayerConfig{
+		Volume:         int32(c.Volume),
+		IdleTimeout:    durationpb.New(c.IdleTimeout),
+		PauseTimeout:   durationpb.New(c.PauseTimeout),
+		AnnounceTracks: c.AnnounceTracks,
+		AlwaysOn:       c.AlwaysOn,
+		MaxQueueSize:   int32(c.MaxQueueSize),
+	}
+}
+
+// FilterBlocked returns the tracks that are not in the blocklist of the
+// guild and the number of blocked ones, the rejected attempts of the user
+// are logged.
+func (c *MusicConfig) FilterBlocked(
+	userId string,
+	data []*player.TrackData,
+) ([]*player.TrackData, int) {
+	if len(c.Blocklist) == 0 {
+		return data, 0
+	}
+
+	allowed := make([]*player.TrackData, 0, len(data))
+	for _, t := range data {
+		entry := c.Blocked(t.Name, t.Url, t.Author)
+		if entry == nil {
+			allowed = append(allowed, t)
+			continue
+		}
+
+		slog.Info(
+			"Music: Rejected blocked track",
+			"guild_id", c.GuildId,
+			"user_id", userId,
+			"url", t.Url,
+			"blocklist_id", entry.Id,
+		)
+	}
+
+	return allowed, len(data) - len(allowed)
+}
+
+// Into converts the entry to the one checked by the player.
+func (e *BlocklistEntry) Into() *player.BlocklistEntry {
+	kind := player.BlocklistKind_BlocklistKindUrl
+	switch e.Kind {
+	case BlocklistKindChannel:
+		kind = player.BlocklistKind_BlocklistKindChannel
+	case BlocklistKindKeyword:
+		kind = player.BlocklistKind_BlocklistKindKeyword
+	}
+
+	return &player.BlocklistEntry{Id: e.Id, Kind: kind, Pattern: e.Pattern}
+}
+
+// BlockedTrack returns the entry of the blocklist sent to the player that
+// matches the track, nil if none does.
+func BlockedTrack(
+	blocklist []*player.BlocklistEntry,
+	data *player.TrackData,
+) *player.BlocklistEntry {
+	for _, e := range blocklist {
+		entry := BlocklistEntry{Id: e.Id, Kind: BlocklistKindUrl, Pattern: e.Pattern}
+		switch e.Kind {
+		case player.BlocklistKind_BlocklistKindChannel:
+			entry.Kind = BlocklistKindChannel
+		case player.BlocklistKind_BlocklistKindKeyword:
+			entry.Kind = BlocklistKindKeyword
+		}
+
+		if entry.Matches(data.Name, data.Url, data.Author) {
+			return e
+		}
+	}
+	return nil
+}
@@ -8,6 +8,12 @@ import (
 
 var _ MusicConfigRepository = &PgMusicConfigRepository{}
 
+const pgBlocklistColumns = "id, created_at, guild_id, created_by, kind, pattern"
+
+const pgMusicConfigColumns = "guild_id, created_at, updated_at, enabled, " +
+	"play_mode, control_mode, volume, idle_timeout, pause_timeout, " +
+	"announce_tracks, always_on, max_queue_size"
+
 func NewPgMusicConfigRepository(db *sql.DB) *PgMusicConfigRepository {
 	return &PgMusicConfigRepository{
 		db:        db,
@@ -23,8 +29,9 @@ type PgMusicConfigRepository struct {
 // Create implements MusicConfigRepository.
 func (r *PgMusicConfigRepository) Create(data MusicConfigCreateData) (*MusicConfig, error) {
 	const Query = "INSERT INTO music_config (guild_id, enabled, play_mode, " +
-		"control_mode, dj_role) VALUES ($1, $2, $3, $4, $5) RETURNING " +
-		"guild_id, created_at, updated_at, enabled, play_mode, control_mode, dj_role"
+		"control_mode, volume, idle_timeout, pause_timeout, announce_tracks, " +
+		"always_on, max_queue_size) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, " +
+		"$9, $10) RETURNING " + pgMusicConfigColumns
 
 	pgdata, err := newPgMusicConfigCreateData(data)
 	if err != nil {
@@ -37,14 +44,19 @@ func (r *PgMusicConfigRepository) Create(data MusicConfigCreateData) (*MusicConf
 		pgdata.Enabled,
 		pgdata.PlayMode,
 		pgdata.ControlMode,
-		pgdata.DjRole,
+		pgdata.Player.Volume,
+		int(pgdata.Player.IdleTimeout/time.Second),
+		int(pgdata.Player.PauseTimeout/time.Second),
+		pgdata.Player.AnnounceTracks,
+		pgdata.Player.AlwaysOn,
+		pgdata.Player.MaxQueueSize,
 	)
 }
 
 // GetByGuildId implements MusicConfigRepository.
 func (r *PgMusicConfigRepository) GetByGuildId(guildId string) (*MusicConfig, error) {
-	const Query = "SELECT guild_id, created_at, updated_at, enabled, play_mode, " +
-		"control_mode, dj_role FROM music_config WHERE guild_id = $1"
+	const Query = "SELECT " + pgMusicConfigColumns + " FROM music_config " +
+		"WHERE guild_id = $1"
 
 	guildId2, err := atoi(guildId)
 	if err != nil {
@@ -70,7 +82,14 @@ func (r *PgMusicConfigRepository) GetOrDefault(guildId string) (*MusicConfig, er
 			Enabled:     DefaultConfigEnabled,
 			PlayMode:    DefaultConfigPlayMode,
 			ControlMode: DefaultConfigControlMode,
-			DjRole:      "",
+			DjRoles:     []string{},
+			Permissions: map[MusicAction]MusicPermission{},
+
+			TextChannels:  []string{},
+			VoiceChannels: []string{},
+			Blocklist:     []BlocklistEntry{},
+
+			Player: DefaultMusicPlayerConfig(),
 		}
 	}
 
@@ -92,51 +111,351 @@ func (r *PgMusicConfigRepository) UpdateControlMode(
 	return r.exec(Query, controlMode, guildId2)
 }
 
-// UpdateDjRole implements MusicConfigRepository.
-func (r *PgMusicConfigRepository) UpdateDjRole(guildId string, djRole string) error {
-	const Query = "UPDATE music_config SET dj_role = $1 WHERE guild_id = $2"
+// UpdateEnabled implements MusicConfigRepository.
+func (r *PgMusicConfigRepository) UpdateEnabled(guildId string, enabled bool) error {
+	const Query = "UPDATE music_config SET enabled = $1 WHERE guild_id = $2"
 
 	guildId2, err := atoi(guildId)
 	if err != nil {
 		return ErrInvalidGuildId
 	}
 
-	djRole2 := sql.NullInt64{}
-	if djRole != "" {
-		if djRole2.Int64, err = atoi(djRole); err != nil {
-			return ErrInvalidRolelId
-		}
-		djRole2.Valid = true
+	return r.exec(Query, enabled, guildId2)
+}
+
+// UpdatePlayMode implements MusicConfigRepository.
+func (r *PgMusicConfigRepository) UpdatePlayMode(
+	guildId string,
+	playMode MusicPermission,
+) error {
+	const Query = "UPDATE music_config SET play_mode = $1 WHERE guild_id = $2"
+
+	guildId2, err := atoi(guildId)
+	if err != nil {
+		return ErrInvalidGuildId
 	}
 
-	return r.exec(Query, djRole2, guildId2)
+	return r.exec(Query, playMode, guildId2)
 }
 
-// UpdateEnabled implements MusicConfigRepository.
-func (r *PgMusicConfigRepository) UpdateEnabled(guildId string, enabled bool) error {
-	const Query = "UPDATE music_config SET enabled = $1 WHERE guild_id = $2"
+// UpdatePlayer implements MusicConfigRepository.
+func (r *PgMusicConfigRepository) UpdatePlayer(
+	guildId string,
+	player MusicPlayerConfig,
+) error {
+	const Query = "UPDATE music_config SET volume = $1, idle_timeout = $2, " +
+		"pause_timeout = $3, announce_tracks = $4, always_on = $5, " +
+		"max_queue_size = $6 WHERE guild_id = $7"
 
 	guildId2, err := atoi(guildId)
 	if err != nil {
 		return ErrInvalidGuildId
 	}
 
-	return r.exec(Query, enabled, guildId2)
+	return r.exec(Query,
+		player.Volume,
+		int(player.IdleTimeout/time.Second),
+		int(player.PauseTimeout/time.Second),
+		player.AnnounceTracks,
+		player.AlwaysOn,
+		player.MaxQueueSize,
+		guildId2,
+	)
 }
 
-// UpdatePlayMode implements MusicConfigRepository.
-func (r *PgMusicConfigRepository) UpdatePlayMode(
+// AddDjRole implements MusicConfigRepository.
+func (r *PgMusicConfigRepository) AddDjRole(guildId string, roleId string) (bool, error) {
+	const CountQuery = "SELECT count(*) FROM music_dj_role WHERE guild_id = $1"
+	const InsertQuery = "INSERT INTO music_dj_role (guild_id, role_id) " +
+		"VALUES ($1, $2) ON CONFLICT DO NOTHING"
+
+	guildId2, err := atoi(guildId)
+	if err != nil {
+		return false, ErrInvalidGuildId
+	}
+	roleId2, err := atoi(roleId)
+	if err != nil {
+		return false, ErrInvalidRolelId
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if err = r.ensureCreated(ctx, tx, guildId2); err != nil {
+		return false, err
+	}
+
+	count := 0
+	if err = tx.QueryRowContext(ctx, CountQuery, guildId2).Scan(&count); err != nil {
+		return false, err
+	}
+
+	if count >= MaxDjRoles {
+		return false, ErrDjRolesFull
+	}
+
+	res, err := tx.ExecContext(ctx, InsertQuery, guildId2, roleId2)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, tx.Commit()
+}
+
+// RemoveDjRole implements MusicConfigRepository.
+func (r *PgMusicConfigRepository) RemoveDjRole(guildId string, roleId string) (bool, error) {
+	const Query = "DELETE FROM music_dj_role WHERE guild_id = $1 AND role_id = $2"
+
+	guildId2, err := atoi(guildId)
+	if err != nil {
+		return false, ErrInvalidGuildId
+	}
+	roleId2, err := atoi(roleId)
+	if err != nil {
+		return false, ErrInvalidRolelId
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, Query, guildId2, roleId2)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// UpdatePermission implements MusicConfigRepository.
+func (r *PgMusicConfigRepository) UpdatePermission(
 	guildId string,
-	playMode MusicPermission,
+	action MusicAction,
+	mode MusicPermission,
 ) error {
-	const Query = "UPDATE music_config SET play_mode = $1 WHERE guild_id = $2"
+	const UpsertQuery = "INSERT INTO music_permission (guild_id, action, mode) " +
+		"VALUES ($1, $2, $3) ON CONFLICT (guild_id, action) DO UPDATE " +
+		"SET mode = EXCLUDED.mode"
+	const DeleteQuery = "DELETE FROM music_permission WHERE guild_id = $1 " +
+		"AND action = $2"
 
 	guildId2, err := atoi(guildId)
 	if err != nil {
 		return ErrInvalidGuildId
 	}
 
-	return r.exec(Query, playMode, guildId2)
+	if mode == "" {
+		return r.exec(DeleteQuery, guildId2, action)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = r.ensureCreated(ctx, tx, guildId2); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, UpsertQuery, guildId2, action, mode); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AddChannel implements MusicConfigRepository.
+func (r *PgMusicConfigRepository) AddChannel(
+	guildId string,
+	channelId string,
+	voice bool,
+) (bool, error) {
+	const CountQuery = "SELECT count(*) FROM music_channel WHERE guild_id = $1"
+	const InsertQuery = "INSERT INTO music_channel (guild_id, channel_id, " +
+		"voice) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING"
+
+	guildId2, err := atoi(guildId)
+	if err != nil {
+		return false, ErrInvalidGuildId
+	}
+	channelId2, err := atoi(channelId)
+	if err != nil {
+		return false, ErrInvalidChannelId
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if err = r.ensureCreated(ctx, tx, guildId2); err != nil {
+		return false, err
+	}
+
+	count := 0
+	if err = tx.QueryRowContext(ctx, CountQuery, guildId2).Scan(&count); err != nil {
+		return false, err
+	}
+
+	if count >= MaxChannels {
+		return false, ErrChannelsFull
+	}
+
+	res, err := tx.ExecContext(ctx, InsertQuery, guildId2, channelId2, voice)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, tx.Commit()
+}
+
+// RemoveChannel implements MusicConfigRepository.
+func (r *PgMusicConfigRepository) RemoveChannel(guildId string, channelId string) (bool, error) {
+	const Query = "DELETE FROM music_channel WHERE guild_id = $1 AND channel_id = $2"
+
+	guildId2, err := atoi(guildId)
+	if err != nil {
+		return false, ErrInvalidGuildId
+	}
+	channelId2, err := atoi(channelId)
+	if err != nil {
+		return false, ErrInvalidChannelId
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, Query, guildId2, channelId2)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// AddBlocklistEntry implements MusicConfigRepository.
+func (r *PgMusicConfigRepository) AddBlocklistEntry(entry BlocklistEntry) (bool, error) {
+	const CountQuery = "SELECT count(*) FROM music_blocklist WHERE guild_id = $1"
+	const InsertQuery = "INSERT INTO music_blocklist (guild_id, created_by, " +
+		"kind, pattern) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING"
+
+	guildId, err := atoi(entry.GuildId)
+	if err != nil {
+		return false, ErrInvalidGuildId
+	}
+	createdBy, err := atoi(entry.CreatedBy)
+	if err != nil {
+		return false, ErrInvalidUserId
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if err = r.ensureCreated(ctx, tx, guildId); err != nil {
+		return false, err
+	}
+
+	count := 0
+	if err = tx.QueryRowContext(ctx, CountQuery, guildId).Scan(&count); err != nil {
+		return false, err
+	}
+
+	if count >= MaxBlocklistEntries {
+		return false, ErrBlocklistFull
+	}
+
+	res, err := tx.ExecContext(ctx, InsertQuery,
+		guildId,
+		createdBy,
+		entry.Kind,
+		entry.Pattern,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	// Nothing is inserted if the pattern is already blocked
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, tx.Commit()
+}
+
+// RemoveBlocklistEntry implements MusicConfigRepository.
+func (r *PgMusicConfigRepository) RemoveBlocklistEntry(
+	guildId string,
+	id int64,
+) (*BlocklistEntry, error) {
+	const Query = "DELETE FROM music_blocklist WHERE guild_id = $1 AND id = $2 " +
+		"RETURNING " + pgBlocklistColumns
+
+	guildId2, err := atoi(guildId)
+	if err != nil {
+		return nil, ErrInvalidGuildId
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	var e BlocklistEntry
+	row := r.db.QueryRowContext(ctx, Query, guildId2, id)
+
+	if err = scanBlocklistEntry(row, &e); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+// ensureCreated creates the config of the guild with the default values if
+// it does not exist, the DJ roles, the permissions, the channels and the
+// blocklist reference it.
+func (r *PgMusicConfigRepository) ensureCreated(
+	ctx context.Context,
+	tx *sql.Tx,
+	guildId int64,
+) error {
+	const Query = "INSERT INTO music_config (guild_id) VALUES ($1) " +
+		"ON CONFLICT DO NOTHING"
+
+	_, err := tx.ExecContext(ctx, Query, guildId)
+	return err
 }
 
 func (r *PgMusicConfigRepository) exec(query string, args ...any) error {
@@ -161,7 +480,12 @@ func (r *PgMusicConfigRepository) fetch(query string, args ...any) (*MusicConfig
 		&t.Enabled,
 		&t.PlayMode,
 		&t.ControlMode,
-		&t.DjRole,
+		&t.Volume,
+		&t.IdleTimeout,
+		&t.PauseTimeout,
+		&t.AnnounceTracks,
+		&t.AlwaysOn,
+		&t.MaxQueueSize,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -171,5 +495,138 @@ func (r *PgMusicConfigRepository) fetch(query string, args ...any) (*MusicConfig
 	}
 
 	t2 := t.Into()
+	if err = r.fetchPermissions(ctx, &t2, t.GuildId); err != nil {
+		return nil, err
+	}
+	if err = r.fetchChannels(ctx, &t2, t.GuildId); err != nil {
+		return nil, err
+	}
+	if err = r.fetchBlocklist(ctx, &t2, t.GuildId); err != nil {
+		return nil, err
+	}
+
 	return &t2, nil
 }
+
+// fetchPermissions fills the DJ roles and the permissions of the config.
+func (r *PgMusicConfigRepository) fetchPermissions(
+	ctx context.Context,
+	c *MusicConfig,
+	guildId int64,
+) error {
+	const RolesQuery = "SELECT role_id FROM music_dj_role WHERE guild_id = $1 " +
+		"ORDER BY role_id"
+	const PermissionsQuery = "SELECT action, mode FROM music_permission " +
+		"WHERE guild_id = $1"
+
+	rows, err := r.db.QueryContext(ctx, RolesQuery, guildId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		roleId := int64(0)
+		if err = rows.Scan(&roleId); err != nil {
+			return err
+		}
+		c.DjRoles = append(c.DjRoles, itoa(roleId))
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = r.db.QueryContext(ctx, PermissionsQuery, guildId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			action MusicAction
+			mode   MusicPermission
+		)
+		if err = rows.Scan(&action, &mode); err != nil {
+			return err
+		}
+		c.Permissions[action] = mode
+	}
+
+	return rows.Err()
+}
+
+// fetchChannels fills the allowed text and voice channels of the config.
+func (r *PgMusicConfigRepository) fetchChannels(
+	ctx context.Context,
+	c *MusicConfig,
+	guildId int64,
+) error {
+	const Query = "SELECT channel_id, voice FROM music_channel " +
+		"WHERE guild_id = $1 ORDER BY channel_id"
+
+	rows, err := r.db.QueryContext(ctx, Query, guildId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			channelId int64
+			voice     bool
+		)
+		if err = rows.Scan(&channelId, &voice); err != nil {
+			return err
+		}
+
+		if voice {
+			c.VoiceChannels = append(c.VoiceChannels, itoa(channelId))
+		} else {
+			c.TextChannels = append(c.TextChannels, itoa(channelId))
+		}
+	}
+
+	return rows.Err()
+}
+
+// fetchBlocklist fills the blocklist of the config.
+func (r *PgMusicConfigRepository) fetchBlocklist(
+	ctx context.Context,
+	c *MusicConfig,
+	guildId int64,
+) error {
+	const Query = "SELECT " + pgBlocklistColumns + " FROM music_blocklist " +
+		"WHERE guild_id = $1 ORDER BY created_at, id"
+
+	rows, err := r.db.QueryContext(ctx, Query, guildId)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e BlocklistEntry
+		if err = scanBlocklistEntry(rows, &e); err != nil {
+			return err
+		}
+		c.Blocklist = append(c.Blocklist, e)
+	}
+
+	return rows.Err()
+}
+
+func scanBlocklistEntry(row scanner, e *BlocklistEntry) error {
+	guildId, createdBy := int64(0), int64(0)
+	err := row.Scan(
+		&e.Id,
+		&e.CreatedAt,
+		&guildId,
+		&createdBy,
+		&e.Kind,
+		&e.Pattern,
+	)
+	e.GuildId = itoa(guildId)
+	e.CreatedBy = itoa(createdBy)
+	return err
+}
@@ -0,0 +1,154 @@
+package music
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+var _ FavoriteRepository = &PgFavoriteRepository{}
+
+func NewPgFavoriteRepository(db *sql.DB) *PgFavoriteRepository {
+	return &PgFavoriteRepository{
+		db:        db,
+		opTimeout: 2 * time.Second,
+	}
+}
+
+type PgFavoriteRepository struct {
+	db        *sql.DB
+	opTimeout time.Duration
+}
+
+// GetByUser implements FavoriteRepository.
+func (r *PgFavoriteRepository) GetByUser(userId string) ([]Favorite, error) {
+	const Query = "SELECT id, created_at, user_id, name, url, play_query, " +
+		"thumbnail, duration, live, author FROM music_favorite " +
+		"WHERE user_id = $1 ORDER BY created_at DESC, id DESC"
+
+	userId2, err := atoi(userId)
+	if err != nil {
+		return nil, ErrInvalidUserId
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, Query, userId2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	favorites := []Favorite{}
+
+	for rows.Next() {
+		var f Favorite
+		if err = scanFavorite(rows, &f); err != nil {
+			return nil, err
+		}
+
+		favorites = append(favorites, f)
+	}
+
+	return favorites, rows.Err()
+}
+
+// Add implements FavoriteRepository.
+func (r *PgFavoriteRepository) Add(userId string, f Favorite) (bool, error) {
+	const CountQuery = "SELECT count(*) FROM music_favorite WHERE user_id = $1"
+	const InsertQuery = "INSERT INTO music_favorite (user_id, name, url, " +
+		"play_query, thumbnail, duration, live, author) VALUES " +
+		"($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT DO NOTHING"
+
+	userId2, err := atoi(userId)
+	if err != nil {
+		return false, ErrInvalidUserId
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	count := 0
+	if err = tx.QueryRowContext(ctx, CountQuery, userId2).Scan(&count); err != nil {
+		return false, err
+	}
+
+	if count >= MaxFavorites {
+		return false, ErrFavoritesFull
+	}
+
+	res, err := tx.ExecContext(ctx, InsertQuery,
+		userId2,
+		f.Name,
+		f.Url,
+		f.PlayQuery,
+		f.Thumbnail,
+		f.Duration.Milliseconds(),
+		f.Live,
+		f.Author,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	// Nothing is inserted if the url is already a favorite of the user
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, tx.Commit()
+}
+
+// Remove implements FavoriteRepository.
+func (r *PgFavoriteRepository) Remove(userId string, id int64) (*Favorite, error) {
+	const Query = "DELETE FROM music_favorite WHERE user_id = $1 AND id = $2 " +
+		"RETURNING id, created_at, user_id, name, url, play_query, " +
+		"thumbnail, duration, live, author"
+
+	userId2, err := atoi(userId)
+	if err != nil {
+		return nil, ErrInvalidUserId
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	var f Favorite
+	row := r.db.QueryRowContext(ctx, Query, userId2, id)
+
+	if err = scanFavorite(row, &f); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+func scanFavorite(row scanner, f *Favorite) error {
+	userId, duration := int64(0), int64(0)
+	err := row.Scan(
+		&f.Id,
+		&f.CreatedAt,
+		&userId,
+		&f.Name,
+		&f.Url,
+		&f.PlayQuery,
+		&f.Thumbnail,
+		&duration,
+		&f.Live,
+		&f.Author,
+	)
+	f.UserId = itoa(userId)
+	f.Duration = time.Duration(duration) * time.Millisecond
+	return err
+}
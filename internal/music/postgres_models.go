@@ -14,15 +14,17 @@ type pgMusicConfig struct {
 	PlayMode    MusicPermission
 	ControlMode MusicPermission
 
-	DjRole sql.NullInt64
+	Volume int
+	// In seconds
+	IdleTimeout int
+	// In seconds
+	PauseTimeout   int
+	AnnounceTracks bool
+	AlwaysOn       bool
+	MaxQueueSize   int
 }
 
 func (mc pgMusicConfig) Into() MusicConfig {
-	djRole := ""
-	if mc.DjRole.Valid {
-		djRole = itoa(mc.DjRole.Int64)
-	}
-
 	return MusicConfig{
 		GuildId:     itoa(mc.GuildId),
 		CreatedAt:   mc.CreatedAt,
@@ -30,7 +32,21 @@ func (mc pgMusicConfig) Into() MusicConfig {
 		Enabled:     mc.Enabled,
 		PlayMode:    mc.PlayMode,
 		ControlMode: mc.ControlMode,
-		DjRole:      djRole,
+		DjRoles:     []string{},
+		Permissions: map[MusicAction]MusicPermission{},
+
+		TextChannels:  []string{},
+		VoiceChannels: []string{},
+		Blocklist:     []BlocklistEntry{},
+
+		Player: MusicPlayerConfig{
+			Volume:         mc.Volume,
+			IdleTimeout:    time.Duration(mc.IdleTimeout) * time.Second,
+			PauseTimeout:   time.Duration(mc.PauseTimeout) * time.Second,
+			AnnounceTracks: mc.AnnounceTracks,
+			AlwaysOn:       mc.AlwaysOn,
+			MaxQueueSize:   mc.MaxQueueSize,
+		},
 	}
 }
 
@@ -39,7 +55,7 @@ type pgMusicConfigCreateData struct {
 	Enabled     bool
 	PlayMode    MusicPermission
 	ControlMode MusicPermission
-	DjRole      sql.NullInt64
+	Player      MusicPlayerConfig
 }
 
 func newPgMusicConfigCreateData(data MusicConfigCreateData) (*pgMusicConfigCreateData, error) {
@@ -48,23 +64,17 @@ func newPgMusicConfigCreateData(data MusicConfigCreateData) (*pgMusicConfigCreat
 		return nil, ErrInvalidGuildId
 	}
 
-	djRole := sql.NullInt64{}
-	if data.DjRole != "" {
-		djRole.Int64, err = atoi(data.DjRole)
-		if err != nil {
-			return nil, ErrInvalidRolelId
-		}
-		djRole.Valid = true
-	}
-
 	pgData := pgMusicConfigCreateData{
 		GuildId:     guildId,
 		Enabled:     data.Enabled,
 		PlayMode:    data.PlayMode,
 		ControlMode: data.ControlMode,
-		DjRole:      djRole,
+		Player:      DefaultMusicPlayerConfig(),
 	}
 
+	if data.Player != nil {
+		pgData.Player = *data.Player
+	}
 	if data.PlayMode == "" {
 		pgData.PlayMode = DefaultConfigPlayMode
 	}
@@ -82,3 +92,68 @@ func atoi(s string) (int64, error) {
 func itoa(i int64) string {
 	return strconv.FormatInt(i, 10)
 }
+
+type pgPlaylist struct {
+	Id        int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Name      string
+	GuildId   sql.NullInt64
+	UserId    sql.NullInt64
+	CreatedBy int64
+
+	TrackCount int
+	// In milliseconds
+	Duration int64
+}
+
+func (p pgPlaylist) Into() Playlist {
+	guildId := ""
+	if p.GuildId.Valid {
+		guildId = itoa(p.GuildId.Int64)
+	}
+	userId := ""
+	if p.UserId.Valid {
+		userId = itoa(p.UserId.Int64)
+	}
+
+	return Playlist{
+		Id:         p.Id,
+		CreatedAt:  p.CreatedAt,
+		UpdatedAt:  p.UpdatedAt,
+		Name:       p.Name,
+		GuildId:    guildId,
+		UserId:     userId,
+		CreatedBy:  itoa(p.CreatedBy),
+		TrackCount: p.TrackCount,
+		Duration:   time.Duration(p.Duration) * time.Millisecond,
+	}
+}
+
+type pgPlaylistOwner struct {
+	GuildId sql.NullInt64
+	UserId  sql.NullInt64
+}
+
+func newPgPlaylistOwner(owner PlaylistOwner) (*pgPlaylistOwner, error) {
+	if (owner.GuildId == "") == (owner.UserId == "") {
+		return nil, ErrInvalidPlaylistOwner
+	}
+
+	var err error
+	pgOwner := pgPlaylistOwner{}
+
+	if owner.GuildId != "" {
+		if pgOwner.GuildId.Int64, err = atoi(owner.GuildId); err != nil {
+			return nil, ErrInvalidGuildId
+		}
+		pgOwner.GuildId.Valid = true
+	} else {
+		if pgOwner.UserId.Int64, err = atoi(owner.UserId); err != nil {
+			return nil, ErrInvalidUserId
+		}
+		pgOwner.UserId.Valid = true
+	}
+
+	return &pgOwner, nil
+}
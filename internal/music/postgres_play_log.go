@@ -0,0 +1,158 @@
+package music
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+var _ PlayLogRepository = &PgPlayLogRepository{}
+
+func NewPgPlayLogRepository(db *sql.DB) *PgPlayLogRepository {
+	return &PgPlayLogRepository{
+		db:        db,
+		opTimeout: 2 * time.Second,
+	}
+}
+
+type PgPlayLogRepository struct {
+	db        *sql.DB
+	opTimeout time.Duration
+}
+
+const pgPlayLogFilter = "WHERE guild_id = $1 AND ($2::bigint IS NULL " +
+	"OR user_id = $2) AND created_at >= $3 "
+
+// Create implements PlayLogRepository.
+func (r *PgPlayLogRepository) Create(data PlayLogCreateData) error {
+	const Query = "INSERT INTO music_play_log (created_at, guild_id, user_id, " +
+		"name, url, duration, listened, skipped) VALUES " +
+		"($1, $2, $3, $4, $5, $6, $7, $8)"
+
+	guildId, err := atoi(data.GuildId)
+	if err != nil {
+		return ErrInvalidGuildId
+	}
+	userId, err := atoi(data.UserId)
+	if err != nil {
+		return ErrInvalidUserId
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	_, err = r.db.ExecContext(ctx, Query,
+		data.StartedAt,
+		guildId,
+		userId,
+		data.Name,
+		data.Url,
+		data.Duration.Milliseconds(),
+		data.Listened.Milliseconds(),
+		data.Skipped,
+	)
+	return err
+}
+
+// GetStats implements PlayLogRepository.
+func (r *PgPlayLogRepository) GetStats(filter PlayStatsFilter, limit int) (*PlayStats, error) {
+	const TotalQuery = "SELECT count(*), count(*) FILTER (WHERE skipped), " +
+		"COALESCE(sum(listened), 0) FROM music_play_log " + pgPlayLogFilter
+	const TracksQuery = "SELECT max(name), url, count(*) AS plays " +
+		"FROM music_play_log " + pgPlayLogFilter +
+		"GROUP BY url ORDER BY plays DESC, url LIMIT $4"
+	const RequestersQuery = "SELECT user_id, count(*) AS plays, " +
+		"sum(listened) FROM music_play_log " + pgPlayLogFilter +
+		"GROUP BY user_id ORDER BY plays DESC, user_id LIMIT $4"
+	const HoursQuery = "SELECT extract(hour FROM created_at AT TIME ZONE " +
+		"'UTC')::int AS hour, count(*) FROM music_play_log " +
+		pgPlayLogFilter + "GROUP BY hour"
+
+	guildId, err := atoi(filter.GuildId)
+	if err != nil {
+		return nil, ErrInvalidGuildId
+	}
+
+	userId := sql.NullInt64{}
+	if filter.UserId != "" {
+		if userId.Int64, err = atoi(filter.UserId); err != nil {
+			return nil, ErrInvalidUserId
+		}
+		userId.Valid = true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	stats := PlayStats{
+		TopTracks:     []TrackPlayStats{},
+		TopRequesters: []RequesterPlayStats{},
+	}
+
+	listened := int64(0)
+	err = r.db.QueryRowContext(ctx, TotalQuery, guildId, userId, filter.Since).
+		Scan(&stats.Plays, &stats.Skips, &listened)
+	if err != nil {
+		return nil, err
+	}
+	stats.Listened = time.Duration(listened) * time.Millisecond
+
+	rows, err := r.db.QueryContext(ctx, TracksQuery, guildId, userId, filter.Since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t TrackPlayStats
+		if err = rows.Scan(&t.Name, &t.Url, &t.Plays); err != nil {
+			return nil, err
+		}
+		stats.TopTracks = append(stats.TopTracks, t)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.QueryContext(ctx, RequestersQuery, guildId, userId, filter.Since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			rs       RequesterPlayStats
+			id       int64
+			listened int64
+		)
+		if err = rows.Scan(&id, &rs.Plays, &listened); err != nil {
+			return nil, err
+		}
+		rs.UserId = itoa(id)
+		rs.Listened = time.Duration(listened) * time.Millisecond
+
+		stats.TopRequesters = append(stats.TopRequesters, rs)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.QueryContext(ctx, HoursQuery, guildId, userId, filter.Since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		hour, plays := 0, 0
+		if err = rows.Scan(&hour, &plays); err != nil {
+			return nil, err
+		}
+		if hour >= 0 && hour < len(stats.Hours) {
+			stats.Hours[hour] = plays
+		}
+	}
+
+	return &stats, rows.Err()
+}
@@ -0,0 +1,291 @@
+package music
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+var _ PlaylistRepository = &PgPlaylistRepository{}
+
+func NewPgPlaylistRepository(db *sql.DB) *PgPlaylistRepository {
+	return &PgPlaylistRepository{
+		db:        db,
+		opTimeout: 2 * time.Second,
+	}
+}
+
+type PgPlaylistRepository struct {
+	db        *sql.DB
+	opTimeout time.Duration
+}
+
+const pgPlaylistSelect = "SELECT p.id, p.created_at, p.updated_at, p.name, " +
+	"p.guild_id, p.user_id, p.created_by, count(t.id), " +
+	"COALESCE(sum(t.duration), 0) FROM music_playlist p " +
+	"LEFT JOIN music_playlist_track t ON t.playlist_id = p.id "
+
+// Create implements PlaylistRepository.
+func (r *PgPlaylistRepository) Create(
+	owner PlaylistOwner,
+	name string,
+	createdBy string,
+) (*Playlist, error) {
+	const Query = "INSERT INTO music_playlist (name, guild_id, user_id, " +
+		"created_by) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING " +
+		"RETURNING id, created_at, updated_at, name, guild_id, user_id, " +
+		"created_by, 0, 0"
+
+	pgOwner, err := newPgPlaylistOwner(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	createdBy2, err := atoi(createdBy)
+	if err != nil {
+		return nil, ErrInvalidUserId
+	}
+
+	p, err := r.fetch(Query, name, pgOwner.GuildId, pgOwner.UserId, createdBy2)
+	if err != nil {
+		return nil, err
+	} else if p == nil {
+		// Nothing is returned if the name conflicts with another playlist
+		return nil, ErrPlaylistNameTaken
+	}
+
+	return p, nil
+}
+
+// Delete implements PlaylistRepository.
+func (r *PgPlaylistRepository) Delete(id int64) (bool, error) {
+	const Query = "DELETE FROM music_playlist WHERE id = $1"
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, Query, id)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// GetByName implements PlaylistRepository.
+func (r *PgPlaylistRepository) GetByName(owner PlaylistOwner, name string) (*Playlist, error) {
+	const Query = pgPlaylistSelect + "WHERE (p.guild_id = $1 OR p.user_id = $2) " +
+		"AND p.name = $3 GROUP BY p.id"
+
+	pgOwner, err := newPgPlaylistOwner(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.fetch(Query, pgOwner.GuildId, pgOwner.UserId, name)
+}
+
+// GetByOwner implements PlaylistRepository.
+func (r *PgPlaylistRepository) GetByOwner(owner PlaylistOwner) ([]Playlist, error) {
+	const Query = pgPlaylistSelect + "WHERE p.guild_id = $1 OR p.user_id = $2 " +
+		"GROUP BY p.id ORDER BY p.name"
+
+	pgOwner, err := newPgPlaylistOwner(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, Query, pgOwner.GuildId, pgOwner.UserId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	playlists := []Playlist{}
+
+	for rows.Next() {
+		var p pgPlaylist
+		if err = scanPlaylist(rows, &p); err != nil {
+			return nil, err
+		}
+
+		playlists = append(playlists, p.Into())
+	}
+
+	return playlists, rows.Err()
+}
+
+// GetTracks implements PlaylistRepository.
+func (r *PgPlaylistRepository) GetTracks(playlistId int64) ([]PlaylistTrack, error) {
+	const Query = "SELECT position, name, url, play_query, thumbnail, duration, " +
+		"live, start, author FROM music_playlist_track " +
+		"WHERE playlist_id = $1 ORDER BY position"
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, Query, playlistId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tracks := []PlaylistTrack{}
+
+	for rows.Next() {
+		var t PlaylistTrack
+		if err = scanPlaylistTrack(rows, &t); err != nil {
+			return nil, err
+		}
+
+		tracks = append(tracks, t)
+	}
+
+	return tracks, rows.Err()
+}
+
+// AddTracks implements PlaylistRepository.
+func (r *PgPlaylistRepository) AddTracks(playlistId int64, tracks []PlaylistTrack) error {
+	// Locks the playlist, so concurrent additions do not get the same
+	// positions
+	const LockQuery = "UPDATE music_playlist SET updated_at = " +
+		"CURRENT_TIMESTAMP WHERE id = $1"
+	const CountQuery = "SELECT count(*) FROM music_playlist_track " +
+		"WHERE playlist_id = $1"
+	const InsertQuery = "INSERT INTO music_playlist_track (playlist_id, " +
+		"position, name, url, play_query, thumbnail, duration, live, start, " +
+		"author) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)"
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, LockQuery, playlistId); err != nil {
+		return err
+	}
+
+	count := 0
+	if err = tx.QueryRowContext(ctx, CountQuery, playlistId).Scan(&count); err != nil {
+		return err
+	}
+
+	if count+len(tracks) > MaxPlaylistTracks {
+		return ErrPlaylistFull
+	}
+
+	for i, t := range tracks {
+		_, err = tx.ExecContext(ctx, InsertQuery,
+			playlistId,
+			count+i,
+			t.Name,
+			t.Url,
+			t.PlayQuery,
+			t.Thumbnail,
+			t.Duration.Milliseconds(),
+			t.Live,
+			t.Start.Milliseconds(),
+			t.Author,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RemoveTrack implements PlaylistRepository.
+func (r *PgPlaylistRepository) RemoveTrack(playlistId int64, position int) (*PlaylistTrack, error) {
+	const DeleteQuery = "DELETE FROM music_playlist_track WHERE playlist_id = $1 " +
+		"AND position = $2 RETURNING position, name, url, play_query, " +
+		"thumbnail, duration, live, start, author"
+	const ShiftQuery = "UPDATE music_playlist_track SET position = position - 1 " +
+		"WHERE playlist_id = $1 AND position > $2"
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var t PlaylistTrack
+	row := tx.QueryRowContext(ctx, DeleteQuery, playlistId, position)
+	if err = scanPlaylistTrack(row, &t); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if _, err = tx.ExecContext(ctx, ShiftQuery, playlistId, position); err != nil {
+		return nil, err
+	}
+
+	return &t, tx.Commit()
+}
+
+func (r *PgPlaylistRepository) fetch(query string, args ...any) (*Playlist, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	var p pgPlaylist
+	row := r.db.QueryRowContext(ctx, query, args...)
+
+	if err := scanPlaylist(row, &p); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	p2 := p.Into()
+	return &p2, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPlaylist(row scanner, p *pgPlaylist) error {
+	return row.Scan(
+		&p.Id,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+		&p.Name,
+		&p.GuildId,
+		&p.UserId,
+		&p.CreatedBy,
+		&p.TrackCount,
+		&p.Duration,
+	)
+}
+
+func scanPlaylistTrack(row scanner, t *PlaylistTrack) error {
+	duration, start := int64(0), int64(0)
+	err := row.Scan(
+		&t.Position,
+		&t.Name,
+		&t.Url,
+		&t.PlayQuery,
+		&t.Thumbnail,
+		&duration,
+		&t.Live,
+		&start,
+		&t.Author,
+	)
+	t.Duration = time.Duration(duration) * time.Millisecond
+	t.Start = time.Duration(start) * time.Millisecond
+	return err
+}
@@ -0,0 +1,145 @@
+package music
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"time"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+)
+
+var _ RemoteTokenRepository = &PgRemoteTokenRepository{}
+
+func NewPgRemoteTokenRepository(db *sql.DB) *PgRemoteTokenRepository {
+	return &PgRemoteTokenRepository{
+		db:        db,
+		opTimeout: 2 * time.Second,
+	}
+}
+
+type PgRemoteTokenRepository struct {
+	db        *sql.DB
+	opTimeout time.Duration
+}
+
+// Create implements RemoteTokenRepository.
+func (r *PgRemoteTokenRepository) Create(
+	data RemoteTokenCreateData,
+) (string, *RemoteToken, error) {
+	const PurgeQuery = "DELETE FROM music_remote_token WHERE " +
+		"expires_at <= CURRENT_TIMESTAMP"
+	const Query = "INSERT INTO music_remote_token (token_hash, expires_at, " +
+		"guild_id, user_id, channel_id) VALUES ($1, $2, $3, $4, $5) " +
+		"RETURNING created_at, expires_at, guild_id, user_id, channel_id"
+
+	guildId, err := atoi(data.GuildId)
+	if err != nil {
+		return "", nil, ErrInvalidGuildId
+	}
+	userId, err := atoi(data.UserId)
+	if err != nil {
+		return "", nil, ErrInvalidUserId
+	}
+	channelId, err := atoi(data.ChannelId)
+	if err != nil {
+		return "", nil, ErrInvalidChannelId
+	}
+
+	token, err := gonanoid.New(RemoteTokenLength)
+	if err != nil {
+		return "", nil, err
+	}
+	hash := sha256.Sum256([]byte(token))
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	// The expired tokens are purged when new ones are issued
+	if _, err = r.db.ExecContext(ctx, PurgeQuery); err != nil {
+		return "", nil, err
+	}
+
+	var t RemoteToken
+	row := r.db.QueryRowContext(ctx, Query,
+		hash[:],
+		time.Now().Add(RemoteTokenTTL),
+		guildId,
+		userId,
+		channelId,
+	)
+
+	if err = scanRemoteToken(row, &t); err != nil {
+		return "", nil, err
+	}
+
+	return token, &t, nil
+}
+
+// GetByToken implements RemoteTokenRepository.
+func (r *PgRemoteTokenRepository) GetByToken(token string) (*RemoteToken, error) {
+	const Query = "SELECT created_at, expires_at, guild_id, user_id, " +
+		"channel_id FROM music_remote_token WHERE token_hash = $1 AND " +
+		"expires_at > CURRENT_TIMESTAMP"
+
+	if len(token) != RemoteTokenLength {
+		return nil, ErrInvalidRemoteToken
+	}
+	hash := sha256.Sum256([]byte(token))
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	var t RemoteToken
+	row := r.db.QueryRowContext(ctx, Query, hash[:])
+
+	if err := scanRemoteToken(row, &t); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidRemoteToken
+		}
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// DeleteByUser implements RemoteTokenRepository.
+func (r *PgRemoteTokenRepository) DeleteByUser(guildId string, userId string) (int, error) {
+	const Query = "DELETE FROM music_remote_token WHERE guild_id = $1 " +
+		"AND user_id = $2"
+
+	guildId2, err := atoi(guildId)
+	if err != nil {
+		return 0, ErrInvalidGuildId
+	}
+	userId2, err := atoi(userId)
+	if err != nil {
+		return 0, ErrInvalidUserId
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.opTimeout)
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, Query, guildId2, userId2)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func scanRemoteToken(row scanner, t *RemoteToken) error {
+	guildId, userId, channelId := int64(0), int64(0), int64(0)
+	err := row.Scan(
+		&t.CreatedAt,
+		&t.ExpiresAt,
+		&guildId,
+		&userId,
+		&channelId,
+	)
+	t.GuildId = itoa(guildId)
+	t.UserId = itoa(userId)
+	t.ChannelId = itoa(channelId)
+	return err
+}
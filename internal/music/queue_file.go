@@ -0,0 +1,331 @@
+package music
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MaxQueueFileSize = 1 << 20
+	// The max number of tracks exported to or imported from a queue file
+	MaxQueueFileEntries = 100
+)
+
+// The m3u option used by players like vlc to start the playback at a position
+const m3uStartOption = "#EXTVLCOPT:start-time="
+
+// The xspf meta of the start of the playback, in milliseconds
+const xspfStartRel = "https://github.com/zanz1n/duvua/start"
+
+const queueFileVersion = 1
+
+type QueueFileFormat string
+
+const (
+	QueueFileFormatJson QueueFileFormat = "json"
+	QueueFileFormatM3u  QueueFileFormat = "m3u"
+	QueueFileFormatXspf QueueFileFormat = "xspf"
+)
+
+// QueueFileFormatFromName returns the format of a queue file by its
+// extension.
+func QueueFileFormatFromName(name string) (QueueFileFormat, error) {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(name), "."))
+	switch ext {
+	case "json":
+		return QueueFileFormatJson, nil
+	case "m3u", "m3u8":
+		return QueueFileFormatM3u, nil
+	case "xspf":
+		return QueueFileFormatXspf, nil
+	default:
+		return "", ErrInvalidQueueFileFormat
+	}
+}
+
+func (f QueueFileFormat) ContentType() string {
+	switch f {
+	case QueueFileFormatJson:
+		return "application/json"
+	case QueueFileFormatM3u:
+		return "audio/x-mpegurl"
+	case QueueFileFormatXspf:
+		return "application/xspf+xml"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// QueueFileEntry is a track of an exported queue. Only the url is required
+// to import it, the tracks are fetched again when imported.
+type QueueFileEntry struct {
+	Name     string
+	Url      string
+	Author   string
+	Duration time.Duration
+	Live     bool
+	// Where the playback starts
+	Start time.Duration
+}
+
+// EncodeQueueFile writes the entries to w in the provided format.
+func EncodeQueueFile(w io.Writer, format QueueFileFormat, entries []QueueFileEntry) error {
+	switch format {
+	case QueueFileFormatJson:
+		return encodeQueueJson(w, entries)
+	case QueueFileFormatM3u:
+		return encodeQueueM3u(w, entries)
+	case QueueFileFormatXspf:
+		return encodeQueueXspf(w, entries)
+	default:
+		return ErrInvalidQueueFileFormat
+	}
+}
+
+// DecodeQueueFile reads the entries of a queue file, the entries without an
+// http url are discarded. Returns ErrInvalidQueueFile if the file is
+// malformed or has no entries.
+func DecodeQueueFile(r io.Reader, format QueueFileFormat) ([]QueueFileEntry, error) {
+	var (
+		entries []QueueFileEntry
+		err     error
+	)
+
+	r = io.LimitReader(r, MaxQueueFileSize)
+
+	switch format {
+	case QueueFileFormatJson:
+		entries, err = decodeQueueJson(r)
+	case QueueFileFormatM3u:
+		entries, err = decodeQueueM3u(r)
+	case QueueFileFormatXspf:
+		entries, err = decodeQueueXspf(r)
+	default:
+		return nil, ErrInvalidQueueFileFormat
+	}
+	if err != nil {
+		return nil, ErrInvalidQueueFile
+	}
+
+	valid := make([]QueueFileEntry, 0, len(entries))
+	for _, entry := range entries {
+		entry.Url = strings.TrimSpace(entry.Url)
+		if strings.HasPrefix(entry.Url, "https://") ||
+			strings.HasPrefix(entry.Url, "http://") {
+			valid = append(valid, entry)
+		}
+	}
+
+	if len(valid) == 0 {
+		return nil, ErrInvalidQueueFile
+	}
+	return valid, nil
+}
+
+type queueJsonFile struct {
+	Version int              `json:"version"`
+	Tracks  []queueJsonEntry `json:"tracks"`
+}
+
+// queueJsonEntry is a QueueFileEntry with the duration in milliseconds.
+type queueJsonEntry struct {
+	Name     string `json:"name"`
+	Url      string `json:"url"`
+	Author   string `json:"author,omitempty"`
+	Duration int64  `json:"duration_ms"`
+	Live     bool   `json:"live,omitempty"`
+	Start    int64  `json:"start_ms,omitempty"`
+}
+
+func encodeQueueJson(w io.Writer, entries []QueueFileEntry) error {
+	file := queueJsonFile{
+		Version: queueFileVersion,
+		Tracks:  make([]queueJsonEntry, len(entries)),
+	}
+	for idx, entry := range entries {
+		file.Tracks[idx] = queueJsonEntry{
+			Name:     entry.Name,
+			Url:      entry.Url,
+			Author:   entry.Author,
+			Duration: entry.Duration.Milliseconds(),
+			Live:     entry.Live,
+			Start:    entry.Start.Milliseconds(),
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(file)
+}
+
+func decodeQueueJson(r io.Reader) ([]QueueFileEntry, error) {
+	var file queueJsonFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, err
+	}
+
+	entries := make([]QueueFileEntry, len(file.Tracks))
+	for idx, track := range file.Tracks {
+		entries[idx] = QueueFileEntry{
+			Name:     track.Name,
+			Url:      track.Url,
+			Author:   track.Author,
+			Duration: time.Duration(track.Duration) * time.Millisecond,
+			Live:     track.Live,
+			Start:    time.Duration(track.Start) * time.Millisecond,
+		}
+	}
+	return entries, nil
+}
+
+func encodeQueueM3u(w io.Writer, entries []QueueFileEntry) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("#EXTM3U\n")
+
+	for _, entry := range entries {
+		// Live tracks have an unknown length
+		seconds := int64(-1)
+		if !entry.Live {
+			seconds = int64(entry.Duration.Seconds())
+		}
+
+		title := entry.Name
+		if entry.Author != "" {
+			title = entry.Author + " - " + entry.Name
+		}
+		title = strings.ReplaceAll(title, "\n", " ")
+
+		fmt.Fprintf(bw, "#EXTINF:%d,%s\n", seconds, title)
+		if entry.Start > 0 {
+			fmt.Fprintf(bw, "%s%d\n", m3uStartOption, int64(entry.Start.Seconds()))
+		}
+		fmt.Fprintf(bw, "%s\n", entry.Url)
+	}
+
+	return bw.Flush()
+}
+
+func decodeQueueM3u(r io.Reader) ([]QueueFileEntry, error) {
+	entries := []QueueFileEntry{}
+	entry := QueueFileEntry{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if info, ok := strings.CutPrefix(line, "#EXTINF:"); ok {
+			seconds, title, _ := strings.Cut(info, ",")
+			// The attributes of the extended m3u are not supported
+			seconds, _, _ = strings.Cut(seconds, " ")
+
+			entry.Name = strings.TrimSpace(title)
+			if v, err := strconv.ParseInt(seconds, 10, 64); err == nil {
+				if v < 0 {
+					entry.Live = true
+				} else {
+					entry.Duration = time.Duration(v) * time.Second
+				}
+			}
+			continue
+		} else if v, ok := strings.CutPrefix(line, m3uStartOption); ok {
+			if seconds, err := strconv.ParseFloat(v, 64); err == nil && seconds > 0 {
+				entry.Start = time.Duration(seconds * float64(time.Second))
+			}
+			continue
+		} else if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entry.Url = line
+		entries = append(entries, entry)
+		entry = QueueFileEntry{}
+	}
+
+	return entries, scanner.Err()
+}
+
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"http://xspf.org/ns/0/ playlist"`
+	Version int         `xml:"version,attr"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location"`
+	Title    string `xml:"title,omitempty"`
+	Creator  string `xml:"creator,omitempty"`
+	// In milliseconds
+	Duration int64      `xml:"duration,omitempty"`
+	Meta     []xspfMeta `xml:"meta,omitempty"`
+}
+
+type xspfMeta struct {
+	Rel   string `xml:"rel,attr"`
+	Value string `xml:",chardata"`
+}
+
+func encodeQueueXspf(w io.Writer, entries []QueueFileEntry) error {
+	playlist := xspfPlaylist{
+		Version: 1,
+		Tracks:  make([]xspfTrack, len(entries)),
+	}
+	for idx, entry := range entries {
+		playlist.Tracks[idx] = xspfTrack{
+			Location: entry.Url,
+			Title:    entry.Name,
+			Creator:  entry.Author,
+			Duration: entry.Duration.Milliseconds(),
+		}
+		if entry.Start > 0 {
+			playlist.Tracks[idx].Meta = []xspfMeta{{
+				Rel:   xspfStartRel,
+				Value: strconv.FormatInt(entry.Start.Milliseconds(), 10),
+			}}
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(playlist)
+}
+
+func decodeQueueXspf(r io.Reader) ([]QueueFileEntry, error) {
+	var playlist xspfPlaylist
+	if err := xml.NewDecoder(r).Decode(&playlist); err != nil {
+		return nil, err
+	}
+
+	entries := make([]QueueFileEntry, len(playlist.Tracks))
+	for idx, track := range playlist.Tracks {
+		entries[idx] = QueueFileEntry{
+			Name:     track.Title,
+			Url:      track.Location,
+			Author:   track.Creator,
+			Duration: time.Duration(track.Duration) * time.Millisecond,
+		}
+		for _, meta := range track.Meta {
+			if meta.Rel != xspfStartRel {
+				continue
+			}
+			v, err := strconv.ParseInt(strings.TrimSpace(meta.Value), 10, 64)
+			if err == nil && v > 0 {
+				entries[idx].Start = time.Duration(v) * time.Millisecond
+			}
+		}
+	}
+	return entries, nil
+}
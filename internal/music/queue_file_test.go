@@ -0,0 +1,85 @@
+package music_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zanz1n/duvua/internal/music"
+)
+
+var queueFileEntries = []music.QueueFileEntry{
+	{
+		Name:     "Never Gonna Give You Up",
+		Url:      "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+		Duration: 3*time.Minute + 33*time.Second,
+		Start:    42 * time.Second,
+	},
+	{
+		Name: "Lofi Radio",
+		Url:  "https://www.youtube.com/watch?v=jfKfPfyJRdk&t=10",
+		Live: true,
+	},
+}
+
+func TestQueueFileRoundTrip(t *testing.T) {
+	formats := []music.QueueFileFormat{
+		music.QueueFileFormatJson,
+		music.QueueFileFormatM3u,
+		music.QueueFileFormatXspf,
+	}
+
+	for _, format := range formats {
+		buf := bytes.Buffer{}
+		err := music.EncodeQueueFile(&buf, format, queueFileEntries)
+		require.NoError(t, err, "Failed to encode %s", format)
+
+		entries, err := music.DecodeQueueFile(&buf, format)
+		require.NoError(t, err, "Failed to decode %s", format)
+		require.Len(t, entries, len(queueFileEntries))
+
+		for idx, entry := range entries {
+			want := queueFileEntries[idx]
+			assert.Equal(t, want.Url, entry.Url, "Unexpected url in %s", format)
+			assert.Equal(t, want.Name, entry.Name, "Unexpected name in %s", format)
+			assert.Equal(t, want.Duration, entry.Duration,
+				"Unexpected duration in %s", format,
+			)
+			assert.Equal(t, want.Start, entry.Start, "Unexpected start in %s", format)
+		}
+	}
+}
+
+func TestDecodeQueueFileM3u(t *testing.T) {
+	file := "#EXTM3U\n" +
+		"#EXTINF:120 tvg-id=\"a\",Some Track\n" +
+		"https://example.com/a.mp3\n" +
+		"\n" +
+		"/home/user/music/b.mp3\n" +
+		"https://example.com/c.mp3\n"
+
+	entries, err := music.DecodeQueueFile(strings.NewReader(file), music.QueueFileFormatM3u)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "Some Track", entries[0].Name)
+	assert.Equal(t, 2*time.Minute, entries[0].Duration)
+	assert.Equal(t, "https://example.com/c.mp3", entries[1].Url)
+}
+
+func TestDecodeQueueFileInvalid(t *testing.T) {
+	_, err := music.DecodeQueueFile(strings.NewReader("{"), music.QueueFileFormatJson)
+	assert.Equal(t, music.ErrInvalidQueueFile, err)
+
+	_, err = music.DecodeQueueFile(
+		strings.NewReader(`{"version":1,"tracks":[]}`),
+		music.QueueFileFormatJson,
+	)
+	assert.Equal(t, music.ErrInvalidQueueFile, err)
+
+	_, err = music.QueueFileFormatFromName("queue.txt")
+	assert.Equal(t, music.ErrInvalidQueueFileFormat, err)
+}
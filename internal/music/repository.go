@@ -12,5 +12,77 @@ type MusicConfigRepository interface {
 	UpdateEnabled(guildId string, enabled bool) error
 	UpdatePlayMode(guildId string, playMode MusicPermission) error
 	UpdateControlMode(guildId string, controlMode MusicPermission) error
-	UpdateDjRole(guildId string, djRole string) error
+	UpdatePlayer(guildId string, player MusicPlayerConfig) error
+
+	// Returns false if the role was already a DJ role, the config of the
+	// guild is created if it does not exist.
+	AddDjRole(guildId string, roleId string) (bool, error)
+	// Returns false if the role was not a DJ role
+	RemoveDjRole(guildId string, roleId string) (bool, error)
+	// If mode is empty the permission of the action is reset to the default,
+	// the config of the guild is created if it does not exist.
+	UpdatePermission(guildId string, action MusicAction, mode MusicPermission) error
+
+	// Returns false if the channel was already allowed, the config of the
+	// guild is created if it does not exist.
+	AddChannel(guildId string, channelId string, voice bool) (bool, error)
+	// Returns false if the channel was not allowed
+	RemoveChannel(guildId string, channelId string) (bool, error)
+
+	// Returns false if the pattern was already blocked, the id and the
+	// creation date of the entry are ignored. The config of the guild is
+	// created if it does not exist.
+	AddBlocklistEntry(entry BlocklistEntry) (bool, error)
+	// The returned BlocklistEntry clould be nil
+	RemoveBlocklistEntry(guildId string, id int64) (*BlocklistEntry, error)
+}
+
+type PlaylistRepository interface {
+	// The returned Playlists clould be nil
+	GetByOwner(owner PlaylistOwner) ([]Playlist, error)
+	// The returned Playlist clould be nil
+	GetByName(owner PlaylistOwner, name string) (*Playlist, error)
+
+	// The returned Playlist must not be nil if err != nil
+	Create(owner PlaylistOwner, name, createdBy string) (*Playlist, error)
+	// Returns false if the playlist was not found
+	Delete(id int64) (bool, error)
+
+	// The returned PlaylistTracks are ordered by their positions
+	GetTracks(playlistId int64) ([]PlaylistTrack, error)
+	// AddTracks appends the tracks to the end of the playlist, the positions
+	// of the tracks are ignored.
+	AddTracks(playlistId int64, tracks []PlaylistTrack) error
+	// The returned PlaylistTrack clould be nil
+	RemoveTrack(playlistId int64, position int) (*PlaylistTrack, error)
+}
+
+type FavoriteRepository interface {
+	// The returned Favorites are ordered from the newest to the oldest
+	GetByUser(userId string) ([]Favorite, error)
+
+	// Returns false if the track was already a favorite of the user, the id
+	// and the creation date of the favorite are ignored.
+	Add(userId string, favorite Favorite) (bool, error)
+	// The returned Favorite clould be nil
+	Remove(userId string, id int64) (*Favorite, error)
+}
+
+type PlayLogRepository interface {
+	Create(data PlayLogCreateData) error
+
+	// The returned PlayStats must not be nil if err != nil, the top tracks
+	// and requesters have at most limit entries.
+	GetStats(filter PlayStatsFilter, limit int) (*PlayStats, error)
+}
+
+type RemoteTokenRepository interface {
+	// Create issues a new token, valid for RemoteTokenTTL. The returned
+	// string is the token itself, that can not be retrieved again.
+	Create(data RemoteTokenCreateData) (string, *RemoteToken, error)
+	// Returns ErrInvalidRemoteToken if the token does not exist or expired
+	GetByToken(token string) (*RemoteToken, error)
+	// DeleteByUser revokes all the tokens of the member, returning how many
+	// were revoked.
+	DeleteByUser(guildId string, userId string) (int, error)
 }
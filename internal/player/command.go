@@ -0,0 +1,163 @@
+package player
+
+import (
+	"context"
+	"time"
+
+	"github.com/zanz1n/duvua/internal/player/errcodes"
+	"github.com/zanz1n/duvua/pkg/pb/player"
+	"google.golang.org/protobuf/proto"
+)
+
+// command is a request to the queue of the guild, which owns the playback
+// state. The queue replies once the command is applied.
+type command struct {
+	kind InterruptType
+	ctx  context.Context
+	// Only skips the current track if it has this id, used by InterruptSkip
+	trackId string
+	// The position requested by InterruptSeek
+	position time.Duration
+
+	// Buffered, so the queue never blocks on callers that gave up
+	reply chan commandResult
+}
+
+type commandResult struct {
+	track   *player.Track
+	changed bool
+	err     error
+}
+
+// send delivers the command to the queue and waits for its reply, until the
+// context is done or the queue ends.
+func (p *GuildPlayer) send(ctx context.Context, cmd command) commandResult {
+	cmd.ctx = ctx
+	cmd.reply = make(chan commandResult, 1)
+
+	select {
+	case p.mailbox <- cmd:
+	case <-p.done:
+		return commandResult{err: errcodes.ErrNoActivePlayer}
+	case <-ctx.Done():
+		return commandResult{err: ctx.Err()}
+	}
+
+	select {
+	case res := <-cmd.reply:
+		return res
+	case <-p.done:
+		return commandResult{err: errcodes.ErrNoActivePlayer}
+	case <-ctx.Done():
+		return commandResult{err: ctx.Err()}
+	}
+}
+
+// Skip skips the current track, returning it.
+func (p *GuildPlayer) Skip(ctx context.Context) (*player.Track, error) {
+	return p.skip(ctx, "")
+}
+
+func (p *GuildPlayer) skip(ctx context.Context, trackId string) (*player.Track, error) {
+	res := p.send(ctx, command{kind: InterruptSkip, trackId: trackId})
+	return res.track, res.err
+}
+
+// Stop stops the queue, the player leaves the voice channel.
+func (p *GuildPlayer) Stop(ctx context.Context) error {
+	return p.send(ctx, command{kind: InterruptStop}).err
+}
+
+// Pause pauses the current track, returning false if it was already paused.
+func (p *GuildPlayer) Pause(ctx context.Context) (bool, error) {
+	res := p.send(ctx, command{kind: InterruptPause})
+	return res.changed, res.err
+}
+
+// Unpause resumes the current track, returning false if it was not paused.
+func (p *GuildPlayer) Unpause(ctx context.Context) (bool, error) {
+	res := p.send(ctx, command{kind: InterruptUnpause})
+	return res.changed, res.err
+}
+
+// Seek restarts the current track at the position. Livestreams can not be
+// seeked.
+func (p *GuildPlayer) Seek(ctx context.Context, position time.Duration) (*player.Track, error) {
+	res := p.send(ctx, command{kind: InterruptSeek, position: position})
+	return res.track, res.err
+}
+
+// close rejects the commands sent after the queue ended.
+func (p *GuildPlayer) close() {
+	close(p.done)
+}
+
+// handleCommand applies a command received by the queue while playing the
+// track, nil if the queue is idle, and replies to it. Returns the interrupt
+// the playback must handle, InterruptNone if it goes on.
+func (p *GuildPlayer) handleCommand(cmd command, track *player.Track) InterruptType {
+	// The caller already gave up, so it is not applied
+	if err := cmd.ctx.Err(); err != nil {
+		cmd.reply <- commandResult{err: err}
+		return InterruptNone
+	}
+
+	res := commandResult{}
+	evt := InterruptNone
+
+	switch cmd.kind {
+	case InterruptStop:
+		evt = InterruptStop
+
+	case InterruptSkip:
+		if track == nil {
+			res.err = errcodes.ErrNoActivePlayer
+		} else if cmd.trackId != "" && cmd.trackId != track.Id {
+			res.err = errcodes.ErrTrackNotFoundInQueue
+		} else {
+			res.track = p.cloneTrack(track)
+			evt = InterruptSkip
+		}
+
+	case InterruptPause:
+		if track != nil {
+			res.changed = !p.paused.Swap(true)
+		}
+		if res.changed {
+			evt = InterruptPause
+		}
+
+	case InterruptUnpause:
+		if track != nil {
+			res.changed = p.paused.Swap(false)
+		}
+		if res.changed {
+			evt = InterruptUnpause
+		}
+
+	case InterruptSeek:
+		if track == nil {
+			res.err = errcodes.ErrNoActivePlayer
+		} else if track.Data.Live {
+			res.err = errcodes.ErrTrackNotSeekable
+		} else if 0 > cmd.position || cmd.position >= track.Data.Duration.AsDuration() {
+			res.err = errcodes.ErrSeekOutOfRange
+		} else {
+			p.seekPosition.Store(int64(cmd.position))
+			// Seeking resumes the playback
+			p.paused.Store(false)
+
+			res.track = p.cloneTrack(track)
+			evt = InterruptSeek
+		}
+	}
+
+	cmd.reply <- res
+	return evt
+}
+
+func (p *GuildPlayer) cloneTrack(track *player.Track) *player.Track {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return proto.Clone(track).(*player.Track)
+}
@@ -0,0 +1,127 @@
+package player_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	guildplayer "github.com/zanz1n/duvua/internal/player"
+	"github.com/zanz1n/duvua/internal/player/errcodes"
+)
+
+func TestConcurrentCommands(t *testing.T) {
+	const Senders = 50
+
+	p := guildplayer.NewGuildPlayer(1)
+	require.NoError(t, p.AddTracks(newTestTracks(1)))
+	p.Pool()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range Senders {
+			p.HandleNextCommand(nil)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	changed := atomic.Int32{}
+	wg := sync.WaitGroup{}
+	for range Senders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ok, err := p.Pause(ctx)
+			assert.NoError(t, err)
+			if ok {
+				changed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	<-done
+
+	assert.Equal(t, int32(1), changed.Load(), "Expected only one pause to change the state")
+	assert.True(t, p.Paused())
+}
+
+func TestCommandAfterClose(t *testing.T) {
+	p := guildplayer.NewGuildPlayer(1)
+	p.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err := p.Skip(ctx)
+	assert.Equal(t, errcodes.ErrNoActivePlayer, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "Expected the command not to wait")
+
+	assert.Equal(t, errcodes.ErrNoActivePlayer, p.Stop(ctx))
+}
+
+func TestCommandCloseWhileWaiting(t *testing.T) {
+	p := guildplayer.NewGuildPlayer(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// The queue receives the command, but ends before replying
+	go p.HandleNextCommand(func() { p.Close() })
+
+	_, err := p.Pause(ctx)
+	if err != nil {
+		assert.Equal(t, errcodes.ErrNoActivePlayer, err)
+	}
+	assert.NoError(t, ctx.Err(), "Expected the command not to wait for the timeout")
+}
+
+func TestCommandCallerGaveUp(t *testing.T) {
+	p := guildplayer.NewGuildPlayer(1)
+	require.NoError(t, p.AddTracks(newTestTracks(1)))
+	p.Pool()
+
+	// Nothing receives the command
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	_, err := p.Pause(ctx)
+	cancel()
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// The command is received, but the caller gives up before it is applied
+	evt := make(chan guildplayer.InterruptType, 1)
+	go func() {
+		evt <- p.HandleNextCommand(func() { time.Sleep(50 * time.Millisecond) })
+	}()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	_, err = p.Pause(ctx)
+	cancel()
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	select {
+	case e := <-evt:
+		assert.Equal(t, guildplayer.InterruptNone, e)
+	case <-time.After(time.Second):
+		t.Fatal("Expected the queue not to block on the reply")
+	}
+	assert.False(t, p.Paused(), "Expected the command not to be applied")
+}
+
+func TestGetQueueClones(t *testing.T) {
+	p := guildplayer.NewGuildPlayer(1)
+	require.NoError(t, p.AddTracks(newTestTracks(2)))
+
+	_, tracks, size := p.GetQueue(0, 10)
+	require.Equal(t, 2, size)
+	tracks[0].Data.Name = "Changed"
+
+	_, tracks, _ = p.GetQueue(0, 10)
+	assert.Equal(t, "Track", tracks[0].Data.Name)
+}
@@ -2,6 +2,7 @@ package encoder
 
 import (
 	"fmt"
+	"time"
 )
 
 var DefaultEncodeOptions = &EncodeOptions{
@@ -63,6 +64,18 @@ func (d FrameDuration) String() string {
 	}
 }
 
+// Duration returns the time span of a single opus frame.
+func (d FrameDuration) Duration() time.Duration {
+	switch d {
+	case OpusFrameDuration40MS:
+		return 40 * time.Millisecond
+	case OpusFrameDuration60MS:
+		return 60 * time.Millisecond
+	default:
+		return 20 * time.Millisecond
+	}
+}
+
 type EncodeMode uint8
 
 var _ fmt.Stringer = EncodeMode(0)
@@ -25,6 +25,9 @@ type Session struct {
 
 	running    atomic.Bool
 	frameCount atomic.Uint32
+	// The error that stopped the session, returned by ReadOpus after the
+	// buffered frames are read
+	err error
 
 	sync.Mutex
 }
@@ -67,7 +70,12 @@ func NewSession(r io.ReadCloser, opts *EncodeOptions) *Session {
 	return s
 }
 
-func (s *Session) start() error {
+func (s *Session) start() (err error) {
+	defer func() {
+		s.err = err
+		close(s.ch)
+	}()
+
 	if s.running.Load() {
 		return errors.Unexpected("already running")
 	}
@@ -142,7 +150,6 @@ func (s *Session) start() error {
 		"pid", s.proc.Pid,
 	)
 
-	defer close(s.ch)
 	err = s.readStdout(stdout)
 	if err != nil {
 		return err
@@ -190,9 +197,14 @@ func (s *Session) readStdout(r io.Reader) error {
 	return nil
 }
 
+// ReadOpus returns io.EOF when the stream ends, or the error that stopped the
+// session, like a failure reading the input.
 func (s *Session) ReadOpus() ([]byte, error) {
 	buf, ok := <-s.ch
 	if !ok {
+		if s.err != nil {
+			return nil, s.err
+		}
 		return nil, io.EOF
 	}
 	return buf, nil
@@ -49,6 +49,29 @@ var (
 		"%d: spotify playlist are not supported",
 		player.PlayerError_ErrSpotifyPlaylistsNotSupported,
 	)
+
+	ErrTrackNotSeekable = status.Errorf(
+		codes.FailedPrecondition,
+		"%d: the track can not be seeked",
+		player.PlayerError_ErrTrackNotSeekable,
+	)
+	ErrSeekOutOfRange = status.Errorf(
+		codes.OutOfRange,
+		"%d: the seek position is out of the track duration",
+		player.PlayerError_ErrSeekOutOfRange,
+	)
+
+	ErrQueueFull = status.Errorf(
+		codes.ResourceExhausted,
+		"%d: the queue is full",
+		player.PlayerError_ErrQueueFull,
+	)
+
+	ErrTrackBlocked = status.Errorf(
+		codes.PermissionDenied,
+		"%d: the track is in the blocklist of the guild",
+		player.PlayerError_ErrTrackBlocked,
+	)
 )
 
 func ErrToErrCode(err error) player.PlayerError {
@@ -69,6 +92,14 @@ func ErrToErrCode(err error) player.PlayerError {
 		return player.PlayerError_ErrNoActivePlayer
 	case ErrSpotifyPlaylistsNotSupported:
 		return player.PlayerError_ErrSpotifyPlaylistsNotSupported
+	case ErrTrackNotSeekable:
+		return player.PlayerError_ErrTrackNotSeekable
+	case ErrSeekOutOfRange:
+		return player.PlayerError_ErrSeekOutOfRange
+	case ErrQueueFull:
+		return player.PlayerError_ErrQueueFull
+	case ErrTrackBlocked:
+		return player.PlayerError_ErrTrackBlocked
 	default:
 		return player.PlayerError_ErrAny
 	}
@@ -0,0 +1,23 @@
+package player
+
+var NewGuildPlayer = newGuildPlayer
+
+// HandleNextCommand applies the next command sent to the queue, like the
+// queue does while playing the current track. The before function runs
+// right before it is applied.
+func (p *GuildPlayer) HandleNextCommand(before func()) InterruptType {
+	cmd := <-p.mailbox
+	if before != nil {
+		before()
+	}
+
+	p.mu.Lock()
+	current := p.current
+	p.mu.Unlock()
+
+	return p.handleCommand(cmd, current)
+}
+
+func (p *GuildPlayer) Close() {
+	p.close()
+}
@@ -1,6 +1,7 @@
 package player
 
 import (
+	"context"
 	"io"
 	"log/slog"
 	"strconv"
@@ -9,6 +10,8 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/zanz1n/duvua/internal/errors"
+	"github.com/zanz1n/duvua/internal/music"
+	"github.com/zanz1n/duvua/internal/player/encoder"
 	"github.com/zanz1n/duvua/internal/player/errcodes"
 	"github.com/zanz1n/duvua/internal/player/platform"
 	"github.com/zanz1n/duvua/pkg/pb/player"
@@ -21,15 +24,22 @@ type PlayerManager struct {
 	s *discordgo.Session
 	f *platform.Fetcher
 	m *PlayerMessenger
+	l music.PlayLogRepository
 }
 
-func NewPlayerManager(s *discordgo.Session, f *platform.Fetcher) *PlayerManager {
+// if l == nil, the played tracks are not logged
+func NewPlayerManager(
+	s *discordgo.Session,
+	f *platform.Fetcher,
+	l music.PlayLogRepository,
+) *PlayerManager {
 	return &PlayerManager{
 		players: map[uint64]*GuildPlayer{},
 		mu:      sync.RWMutex{},
 		s:       s,
 		f:       f,
 		m:       &PlayerMessenger{s: s},
+		l:       l,
 	}
 }
 
@@ -64,9 +74,14 @@ func (m *PlayerManager) GetOrCreate(id, channelId uint64) *GuildPlayer {
 }
 
 func (m *PlayerManager) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
 	m.mu.Lock()
 	for id, p := range m.players {
-		p.Stop()
+		if err := p.Stop(ctx); err != nil {
+			slog.Error("Failed to stop guild player", "guild_id", id, "error", err)
+		}
 		delete(m.players, id)
 	}
 	defer m.mu.Unlock()
@@ -100,9 +115,7 @@ func (m *PlayerManager) guildJobLaunch(p *GuildPlayer, channelId uint64) {
 
 	defer func() {
 		m.Remove(p.GuildId)
-		if p.Interrupt != nil {
-			close(p.Interrupt)
-		}
+		p.close()
 	}()
 
 	err := m.guildJob(p, channelId)
@@ -116,8 +129,9 @@ func (m *PlayerManager) guildJobLaunch(p *GuildPlayer, channelId uint64) {
 }
 
 func (m *PlayerManager) guildJob(p *GuildPlayer, cId uint64) error {
-	const MaxPoolTries = 10
 	const PoolTryDelay = time.Second
+	const MaxResumeRetries = 3
+	const ResumeDelay = time.Second
 
 	start := time.Now()
 
@@ -137,7 +151,8 @@ func (m *PlayerManager) guildJob(p *GuildPlayer, cId uint64) error {
 
 	defer m.m.OnQueueEnd(p)
 
-	poolTries := 0
+	// When the queue became empty, zero while it has tracks
+	idleStart := time.Time{}
 	pausedTime := time.Duration(0)
 	track := (*player.Track)(nil)
 
@@ -145,20 +160,28 @@ LOOP:
 	for {
 		if !p.IsLooping() {
 			if track = p.Pool(); track == nil {
-				poolTries++
-				if poolTries >= MaxPoolTries {
+				if idleStart.IsZero() {
+					idleStart = time.Now()
+				}
+
+				// The player never leaves by itself in 24/7 mode
+				cfg := p.Config()
+				if !cfg.AlwaysOn && time.Since(idleStart) >= cfg.IdleTimeout.AsDuration() {
 					break
 				}
 				select {
 				case <-time.NewTimer(PoolTryDelay).C:
-				case evt := <-p.Interrupt:
-					if evt == InterruptStop {
+				case cmd := <-p.mailbox:
+					if p.handleCommand(cmd, nil) == InterruptStop {
 						break LOOP
 					}
 				}
+				if p.sleepDue() {
+					break
+				}
 				continue
 			} else {
-				poolTries = 0
+				idleStart = time.Time{}
 			}
 		} else if track == nil {
 			break
@@ -171,34 +194,85 @@ LOOP:
 			"queue_size", p.Size(),
 		)
 
-		m.m.OnTrackStart(p, track)
+		// The pause does not carry over to the next track
+		p.paused.Store(false)
 
-		stream, err := m.f.Fetch(track.Data.PlayQuery)
-		if err != nil {
-			slog.Error("Failed to fetch track", "error", err)
-			m.m.OnTrackFailed(p, track)
-			continue
+		if p.Config().AnnounceTracks {
+			m.m.OnTrackStart(p, track)
 		}
 
-		interrupt, pt, err := m.playTrack(vc, p, track, stream)
-		if err != nil {
+		trackStart := time.Now()
+		trackPaused := time.Duration(0)
+
+		position := track.Data.Start.AsDuration()
+		retries := 0
+		for {
+			p.setProgress(track.Id, position)
+
+			interrupt, pt, err := m.fetchAndPlay(vc, p, track, position)
+			pausedTime += pt
+			trackPaused += pt
+
 			if err == errcodes.ErrTooMuchTimePaused {
-				break
+				m.logPlay(p, track, trackStart, trackPaused, true)
+				break LOOP
 			} else if err == errcodes.ErrVoiceConnectionClosed {
 				slog.Info("Queue voice connection closed", "guild_id", guildId)
-				break
-			} else {
-				slog.Error(
-					"Error while playing track",
+				m.logPlay(p, track, trackStart, trackPaused, true)
+				break LOOP
+			} else if err != nil {
+				progress := p.getProgress(track)
+				// The retries are only counted while the track does not
+				// advance
+				if progress > position {
+					retries = 0
+				}
+
+				if platform.IsPermanentErr(err) || retries >= MaxResumeRetries {
+					slog.Error(
+						"Error while playing track",
+						"guild_id", guildId,
+						"track_id", track.Id,
+						"retries", retries,
+						"error", err,
+					)
+					m.m.OnTrackFailed(p, track, err)
+					break
+				}
+				retries++
+
+				slog.Warn(
+					"Resuming track after failure",
 					"guild_id", guildId,
+					"track_id", track.Id,
+					"attempt", retries,
+					"position", progress.Round(time.Second),
 					"error", err,
 				)
-				m.m.OnTrackFailed(p, track)
+
+				position = progress
+				interrupt = m.waitResume(p, track, ResumeDelay<<(retries-1))
+			}
+
+			if interrupt == InterruptStop {
+				m.logPlay(p, track, trackStart, trackPaused, true)
+				break LOOP
+			} else if interrupt == InterruptSeek {
+				position = time.Duration(p.seekPosition.Load())
+				slog.Info(
+					"Queue seeked track",
+					"track_id", track.Id,
+					"guild_id", guildId,
+					"position", position,
+				)
+			} else if err == nil || interrupt != InterruptNone {
+				m.logPlay(p, track, trackStart, trackPaused, interrupt != InterruptNone)
+				break
 			}
 		}
-		pausedTime += pt
 
-		if interrupt == InterruptStop {
+		if p.sleepTrackEnded() {
+			slog.Info("Queue sleep timer expired", "guild_id", guildId)
 			break
 		}
 	}
@@ -215,21 +289,189 @@ LOOP:
 	return nil
 }
 
+// logPlay records that the track was played, in background, since the
+// queue must not wait for the database.
+func (m *PlayerManager) logPlay(
+	p *GuildPlayer,
+	track *player.Track,
+	start time.Time,
+	paused time.Duration,
+	skipped bool,
+) {
+	if m.l == nil {
+		return
+	}
+
+	data := music.PlayLogCreateData{
+		GuildId:   strconv.FormatUint(p.GuildId, 10),
+		UserId:    strconv.FormatUint(track.UserId, 10),
+		StartedAt: start,
+		Name:      track.Data.Name,
+		Url:       track.Data.Url,
+		Duration:  track.Data.Duration.AsDuration(),
+		Listened:  max(time.Since(start)-paused, 0),
+		Skipped:   skipped,
+	}
+
+	go func() {
+		if err := m.l.Create(data); err != nil {
+			slog.Error(
+				"Failed to log played track",
+				"guild_id", data.GuildId,
+				"track_id", track.Id,
+				"error", err,
+			)
+		}
+	}()
+}
+
+type fetchResult struct {
+	stream platform.Streamer
+	err    error
+}
+
+// fetchAndPlay fetches the stream of the track, starting at position, and
+// plays it. The commands are still handled while the stream is fetched.
+func (m *PlayerManager) fetchAndPlay(
+	vc *discordgo.VoiceConnection,
+	p *GuildPlayer,
+	track *player.Track,
+	position time.Duration,
+) (InterruptType, time.Duration, error) {
+	fetched := make(chan fetchResult, 1)
+	go func() {
+		if err := m.checkBlocked(p, track); err != nil {
+			fetched <- fetchResult{err: err}
+			return
+		}
+
+		stream, err := m.f.Fetch(track.Data.PlayQuery, platform.FetchOptions{
+			Start: position,
+			// The volume of the config is in percent, while 256 is the
+			// original volume of the encoder
+			Volume: uint16(p.Config().Volume * 256 / 100),
+		})
+		fetched <- fetchResult{stream: stream, err: err}
+	}()
+
+	var res fetchResult
+WAIT:
+	for {
+		select {
+		case res = <-fetched:
+			break WAIT
+
+		case cmd := <-p.mailbox:
+			evt := p.handleCommand(cmd, track)
+			if evt == InterruptSkip || evt == InterruptStop || evt == InterruptSeek {
+				// The stream is discarded once fetched
+				go func() {
+					if res := <-fetched; res.stream != nil {
+						res.stream.Close()
+					}
+				}()
+				return evt, 0, nil
+			}
+		}
+	}
+
+	if res.err != nil {
+		slog.Error("Failed to fetch track", "error", res.err)
+		return InterruptNone, 0, res.err
+	}
+	stream := res.stream
+
+	if ls, ok := stream.(platform.LiveStreamer); ok {
+		id := track.Id
+		ls.OnTitle(func(title string) {
+			if t, ok := p.SetStreamTitle(id, title); ok {
+				m.m.OnStreamTitle(p, t)
+			}
+		})
+	}
+
+	return m.playTrack(vc, p, track, stream)
+}
+
+// checkBlocked checks the track against the blocklist of the guild again,
+// along with the track actually played if the platform resolves it, like the
+// youtube videos of spotify tracks.
+func (m *PlayerManager) checkBlocked(p *GuildPlayer, track *player.Track) error {
+	blocklist := p.Config().Blocklist
+	if len(blocklist) == 0 {
+		return nil
+	}
+
+	resolved, err := m.f.Resolve(track.Data.PlayQuery)
+	if err != nil {
+		return err
+	}
+
+	for _, data := range []*player.TrackData{track.Data, resolved} {
+		if data == nil {
+			continue
+		}
+
+		if entry := music.BlockedTrack(blocklist, data); entry != nil {
+			slog.Info(
+				"Rejected blocked track",
+				"guild_id", p.GuildId,
+				"user_id", track.UserId,
+				"url", data.Url,
+				"blocklist_id", entry.Id,
+			)
+			return errcodes.ErrTrackBlocked
+		}
+	}
+
+	return nil
+}
+
+// waitResume waits before resuming a failed track, returning the interrupt
+// received meanwhile, if any. A paused track is resumed paused.
+func (m *PlayerManager) waitResume(
+	p *GuildPlayer,
+	track *player.Track,
+	delay time.Duration,
+) InterruptType {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			return InterruptNone
+		case cmd := <-p.mailbox:
+			evt := p.handleCommand(cmd, track)
+			if evt == InterruptSkip || evt == InterruptStop || evt == InterruptSeek {
+				return evt
+			}
+		}
+	}
+}
+
 func (m *PlayerManager) playTrack(
 	vc *discordgo.VoiceConnection,
 	p *GuildPlayer,
 	track *player.Track,
 	stream platform.Streamer,
 ) (InterruptType, time.Duration, error) {
-	const MaxPausedTime = 5 * 60 * time.Second
-
 	defer stream.Close()
 
 	pausedTime := time.Duration(0)
 
-	var readStart time.Time
+	frameDuration := encoder.DefaultEncodeOptions.FrameDuration.Duration()
+
 	for {
-		readStart = time.Now()
+		// The track may be paused while it was fetched
+		if p.Paused() {
+			evt, pt, err := m.waitUnpause(p, track)
+			pausedTime += pt
+			if evt != InterruptNone || err != nil {
+				return evt, pausedTime, err
+			}
+		}
+
 		packet, err := stream.ReadOpus()
 		if err != nil {
 			if err == io.EOF {
@@ -240,30 +482,60 @@ func (m *PlayerManager) playTrack(
 			)
 		}
 
+		if p.sleepDue() {
+			slog.Info("Queue sleep timer expired", "guild_id", p.GuildId)
+			return InterruptStop, pausedTime, nil
+		}
+
 		select {
 		case vc.OpusSend <- packet:
-			atomicAddDuration(track.State, time.Since(readStart))
+			p.addProgress(track, frameDuration)
 
-		case evt := <-p.Interrupt:
-			if evt != InterruptPause {
+		case cmd := <-p.mailbox:
+			evt := p.handleCommand(cmd, track)
+			if evt == InterruptSkip || evt == InterruptStop || evt == InterruptSeek {
 				return evt, pausedTime, nil
 			}
+			// The packet is lost, but it is only 20ms of audio
 
-			pauseStart := time.Now()
-			select {
-			case e := <-p.Interrupt:
-				pausedTime += time.Since(pauseStart)
-				if e != InterruptUnpause {
-					return e, pausedTime, nil
-				}
+		case <-time.NewTimer(time.Second).C:
+			return InterruptNone, pausedTime, errcodes.ErrVoiceConnectionClosed
+		}
+	}
+}
+
+// waitUnpause waits until the track is unpaused, returning the interrupt
+// received meanwhile, if any.
+func (m *PlayerManager) waitUnpause(
+	p *GuildPlayer,
+	track *player.Track,
+) (InterruptType, time.Duration, error) {
+	// The player never leaves by itself in 24/7 mode, so the pause never
+	// times out
+	var pauseTimeout <-chan time.Time
+	if cfg := p.Config(); !cfg.AlwaysOn {
+		pauseTimeout = time.NewTimer(cfg.PauseTimeout.AsDuration()).C
+	}
+	sleepTimeout := p.sleepTimeout()
 
-			case <-time.NewTimer(MaxPausedTime).C:
-				pausedTime += time.Since(pauseStart)
-				return InterruptNone, pausedTime, errcodes.ErrTooMuchTimePaused
+	pauseStart := time.Now()
+	for p.Paused() {
+		select {
+		case cmd := <-p.mailbox:
+			evt := p.handleCommand(cmd, track)
+			if evt == InterruptSkip || evt == InterruptStop || evt == InterruptSeek {
+				return evt, time.Since(pauseStart), nil
 			}
 
-		case <-time.NewTimer(time.Second).C:
-			return InterruptNone, pausedTime, errcodes.ErrVoiceConnectionClosed
+		case <-pauseTimeout:
+			return InterruptNone, time.Since(pauseStart), errcodes.ErrTooMuchTimePaused
+
+		case <-sleepTimeout:
+			p.slept.Store(true)
+			slog.Info("Queue sleep timer expired", "guild_id", p.GuildId)
+			return InterruptStop, time.Since(pauseStart), nil
 		}
 	}
+
+	return InterruptNone, time.Since(pauseStart), nil
 }
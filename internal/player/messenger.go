@@ -4,25 +4,39 @@ import (
 	"fmt"
 	"log/slog"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/zanz1n/duvua/internal/errors"
-	"github.com/zanz1n/duvua/internal/utils"
+	"github.com/zanz1n/duvua/internal/music"
+	"github.com/zanz1n/duvua/internal/player/errcodes"
 	"github.com/zanz1n/duvua/pkg/pb/player"
 )
 
 type PlayerMessenger struct {
 	s *discordgo.Session
+
+	// The last on-track-start message sent on each guild, so it can be
+	// updated when the title of a livestream changes
+	nowPlaying sync.Map // map[uint64]nowPlayingMessage
+}
+
+type nowPlayingMessage struct {
+	trackId   string
+	channelId string
+	messageId string
 }
 
 func (m *PlayerMessenger) OnTrackStart(p *GuildPlayer, t *player.Track) {
 	cid := p.GetMessageChannel()
+	sleep := p.SleepTimer()
 
 	go func() {
 		start := time.Now()
 
-		if err := m.onTrackStart(cid, t); err != nil {
+		msg, err := m.onTrackStart(cid, t, sleep)
+		if err != nil {
 			slog.Error(
 				"Messenger: Failed to send on-track-start message",
 				"guild_id", p.GuildId,
@@ -30,6 +44,12 @@ func (m *PlayerMessenger) OnTrackStart(p *GuildPlayer, t *player.Track) {
 				"error", err,
 			)
 		} else {
+			m.nowPlaying.Store(p.GuildId, nowPlayingMessage{
+				trackId:   t.Id,
+				channelId: msg.ChannelID,
+				messageId: msg.ID,
+			})
+
 			slog.Info(
 				"Messenger: Sent on-track-start message",
 				"guild_id", p.GuildId,
@@ -39,31 +59,22 @@ func (m *PlayerMessenger) OnTrackStart(p *GuildPlayer, t *player.Track) {
 	}()
 }
 
-func (m *PlayerMessenger) onTrackStart(cid uint64, t *player.Track) error {
+func (m *PlayerMessenger) onTrackStart(
+	cid uint64,
+	t *player.Track,
+	sleep *player.SleepTimer,
+) (*discordgo.Message, error) {
 	if cid == 0 {
-		return errors.Unexpected("no text channel")
+		return nil, errors.Unexpected("no text channel")
 	}
 
-	desc := fmt.Sprintf(
-		"Tocando agora **[%s](%s)**\n\n**Duração: [%s]**",
-		t.Data.Name,
-		t.Data.Url,
-		utils.FmtDuration(t.Data.Duration.AsDuration()),
-	)
-
 	loopCustomId := "loop/on"
 	if t.State != nil && t.State.Looping {
-		desc = "**[Loop]** " + desc
 		loopCustomId = "loop/off"
 	}
 
 	message := discordgo.MessageSend{
-		Embeds: []*discordgo.MessageEmbed{{
-			Description: desc,
-			Thumbnail: &discordgo.MessageEmbedThumbnail{
-				URL: t.Data.Thumbnail,
-			},
-		}},
+		Embeds: []*discordgo.MessageEmbed{trackStartEmbed(t, sleep)},
 		Components: []discordgo.MessageComponent{discordgo.ActionsRow{
 			Components: []discordgo.MessageComponent{
 				discordgo.Button{
@@ -90,6 +101,11 @@ func (m *PlayerMessenger) onTrackStart(cid uint64, t *player.Track) error {
 					Style:    discordgo.SuccessButton,
 					CustomID: loopCustomId,
 				},
+				discordgo.Button{
+					Emoji:    emoji("❤️"),
+					Style:    discordgo.SecondaryButton,
+					CustomID: "favorites/" + t.Id,
+				},
 			},
 		}},
 	}
@@ -97,13 +113,76 @@ func (m *PlayerMessenger) onTrackStart(cid uint64, t *player.Track) error {
 	return m.sendMessage(cid, &message)
 }
 
+// OnStreamTitle updates the on-track-start message of the track with the
+// title announced by its livestream.
+func (m *PlayerMessenger) OnStreamTitle(p *GuildPlayer, t *player.Track) {
+	go func() {
+		start := time.Now()
+
+		v, ok := m.nowPlaying.Load(p.GuildId)
+		if !ok || v.(nowPlayingMessage).trackId != t.Id {
+			return
+		}
+		msg := v.(nowPlayingMessage)
+
+		_, err := m.s.ChannelMessageEditEmbed(
+			msg.channelId,
+			msg.messageId,
+			trackStartEmbed(t, p.SleepTimer()),
+		)
+		if err != nil {
+			slog.Error(
+				"Messenger: Failed to update on-track-start message",
+				"guild_id", p.GuildId,
+				"took", time.Since(start).Round(time.Millisecond),
+				"error", err,
+			)
+		} else {
+			slog.Info(
+				"Messenger: Updated on-track-start message",
+				"guild_id", p.GuildId,
+				"stream_title", t.State.StreamTitle,
+				"took", time.Since(start).Round(time.Millisecond),
+			)
+		}
+	}()
+}
+
+func trackStartEmbed(t *player.Track, sleep *player.SleepTimer) *discordgo.MessageEmbed {
+	desc := fmt.Sprintf(
+		"Tocando agora **[%s](%s)**\n\n**Duração: [%s]**",
+		t.Data.Name,
+		t.Data.Url,
+		music.FmtTrackDuration(t.Data),
+	)
+
+	if t.State != nil && t.State.Looping {
+		desc = "**[Loop]** " + desc
+	}
+	if t.State != nil && t.State.StreamTitle != "" {
+		desc += fmt.Sprintf("\n**No ar: %s**", t.State.StreamTitle)
+	}
+	if sleep != nil {
+		desc += "\n\n💤 " + music.FmtSleepTimer(sleep)
+	}
+
+	return &discordgo.MessageEmbed{
+		Description: desc,
+		Thumbnail: &discordgo.MessageEmbedThumbnail{
+			URL: t.Data.Thumbnail,
+		},
+	}
+}
+
 func (m *PlayerMessenger) OnQueueEnd(p *GuildPlayer) {
 	cid := p.GetMessageChannel()
+	slept := p.Slept()
+	m.nowPlaying.Delete(p.GuildId)
 
 	go func() {
 		start := time.Now()
 
-		if err := m.onQueueEnd(cid); err != nil {
+		if err := m.onQueueEnd(cid, slept); err != nil {
 			slog.Error(
 				"Messenger: Failed to send on-queue-end message",
 				"guild_id", p.GuildId,
@@ -120,23 +199,29 @@ func (m *PlayerMessenger) OnQueueEnd(p *GuildPlayer) {
 	}()
 }
 
-func (m *PlayerMessenger) onQueueEnd(cid uint64) error {
+func (m *PlayerMessenger) onQueueEnd(cid uint64, slept bool) error {
 	if cid == 0 {
 		return errors.Unexpected("no text channel")
 	}
 
-	return m.sendMessage(cid, &discordgo.MessageSend{
-		Content: "**A fila (playlist) terminou!**",
+	content := "**A fila (playlist) terminou!**"
+	if slept {
+		content = "💤 **O player foi desligado pelo timer!**"
+	}
+
+	_, err := m.sendMessage(cid, &discordgo.MessageSend{
+		Content: content,
 	})
+	return err
 }
 
-func (m *PlayerMessenger) OnTrackFailed(p *GuildPlayer, t *player.Track) {
+func (m *PlayerMessenger) OnTrackFailed(p *GuildPlayer, t *player.Track, err error) {
 	cid := p.GetMessageChannel()
 
 	go func() {
 		start := time.Now()
 
-		if err := m.onTrackFailed(cid, t); err != nil {
+		if err := m.onTrackFailed(cid, t, err); err != nil {
 			slog.Error(
 				"Messenger: Failed to send on-track-failed message",
 				"guild_id", p.GuildId,
@@ -153,31 +238,36 @@ func (m *PlayerMessenger) OnTrackFailed(p *GuildPlayer, t *player.Track) {
 	}()
 }
 
-func (m *PlayerMessenger) onTrackFailed(cid uint64, t *player.Track) error {
+func (m *PlayerMessenger) onTrackFailed(cid uint64, t *player.Track, err error) error {
 	if cid == 0 {
 		return errors.Unexpected("no text channel")
 	}
 
-	return m.sendMessage(cid, &discordgo.MessageSend{
-		Content: fmt.Sprintf(
-			"Não foi possível tocar a música **[%s](<%s>)**",
-			t.Data.Name,
-			t.Data.Url,
-		),
+	format := "Não foi possível tocar a música **[%s](<%s>)**"
+	if err == errcodes.ErrTrackBlocked {
+		format = "A música **[%s](<%s>)** está bloqueada no servidor"
+	}
+
+	_, err = m.sendMessage(cid, &discordgo.MessageSend{
+		Content: fmt.Sprintf(format, t.Data.Name, t.Data.Url),
 	})
+	return err
 }
 
-func (m *PlayerMessenger) sendMessage(cid uint64, data *discordgo.MessageSend) error {
-	_, err := m.s.ChannelMessageSendComplex(
+func (m *PlayerMessenger) sendMessage(
+	cid uint64,
+	data *discordgo.MessageSend,
+) (*discordgo.Message, error) {
+	msg, err := m.s.ChannelMessageSendComplex(
 		strconv.FormatUint(cid, 10),
 		data,
 	)
 	if err != nil {
-		return errors.Unexpected(
+		return nil, errors.Unexpected(
 			"failed to send message on text channel: " + err.Error(),
 		)
 	}
-	return nil
+	return msg, nil
 }
 
 func emoji(name string) *discordgo.ComponentEmoji {
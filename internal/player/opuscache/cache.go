@@ -0,0 +1,447 @@
+package opuscache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zanz1n/duvua/internal/errors"
+	"github.com/zanz1n/duvua/internal/player/encoder"
+)
+
+const (
+	dataExt  = ".opus"
+	indexExt = ".idx"
+	tempPref = "tmp-"
+)
+
+// Source is a stream of encoded opus packets, as produced by an
+// encoder.Session.
+type Source interface {
+	ReadOpus() ([]byte, error)
+	io.Closer
+}
+
+type entry struct {
+	key  string
+	size int64
+}
+
+// Cache is an on-disk LRU cache of encoded opus packet streams.
+//
+// Each entry is stored as two files inside the cache directory: a data file
+// with the length-prefixed packets and an index file with the offset of
+// every packet, used to start reading from any point of the track.
+type Cache struct {
+	dir     string
+	maxSize int64
+
+	size      int64
+	entries   map[string]*list.Element
+	lru       *list.List
+	recording map[string]struct{}
+
+	mu sync.Mutex
+}
+
+// New opens the cache located at dir, creating it if it does not exist.
+// Entries left by previous runs are loaded and the least recently used ones
+// are evicted if they exceed maxSize.
+func New(dir string, maxSize int64) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Unexpected("opus cache: create dir: " + err.Error())
+	}
+
+	c := &Cache{
+		dir:       dir,
+		maxSize:   maxSize,
+		entries:   map[string]*list.Element{},
+		lru:       list.New(),
+		recording: map[string]struct{}{},
+	}
+
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Key returns the cache key of a track encoded with the provided options.
+// The start time is not part of the key, since any entry can be read from
+// an arbitrary position.
+func Key(query string, opts *encoder.EncodeOptions) string {
+	if opts == nil {
+		opts = encoder.DefaultEncodeOptions
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%d:%d:%d:%d:%d:%s:%s:%d",
+		query,
+		opts.FrameRate,
+		opts.Volume,
+		opts.Bitrate,
+		opts.CompressionLevel,
+		opts.Channels,
+		opts.Mode,
+		opts.FrameDuration,
+		opts.PacketLoss,
+	)
+
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// Size returns the sum of the sizes of all the cached entries.
+func (c *Cache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// Len returns the number of cached entries.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Open returns a reader of the cached entry positioned at the packet that
+// starts at `start`. The returned boolean is false if the entry is not
+// cached.
+func (c *Cache) Open(query string, opts *encoder.EncodeOptions, start time.Duration) (*Reader, bool) {
+	if opts == nil {
+		opts = encoder.DefaultEncodeOptions
+	}
+	key := Key(query, opts)
+
+	c.mu.Lock()
+	elem, ok := c.entries[key]
+	if ok {
+		c.lru.MoveToFront(elem)
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	r, err := openReader(c.path(key, dataExt), c.path(key, indexExt), start)
+	if err != nil {
+		slog.Warn(
+			"OpusCache: Failed to open entry",
+			"key", key,
+			"query", query,
+			"error", err,
+		)
+		c.remove(key)
+		return nil, false
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(c.path(key, indexExt), now, now)
+
+	slog.Debug(
+		"OpusCache: Serving track from cache",
+		"key", key,
+		"query", query,
+		"start", start,
+		"frame_count", r.frameCount,
+	)
+
+	return r, true
+}
+
+// Record wraps src so that every packet read from it is also written to
+// the cache. The entry is only committed if src is read until io.EOF, so
+// tracks that were skipped or failed midway are not stored.
+//
+// If the track is already cached or being recorded, src is returned as is.
+func (c *Cache) Record(query string, opts *encoder.EncodeOptions, src Source) Source {
+	if opts == nil {
+		opts = encoder.DefaultEncodeOptions
+	}
+	key := Key(query, opts)
+
+	c.mu.Lock()
+	_, cached := c.entries[key]
+	_, recording := c.recording[key]
+	if cached || recording {
+		c.mu.Unlock()
+		return src
+	}
+	c.recording[key] = struct{}{}
+	c.mu.Unlock()
+
+	w, err := newWriter(c.dir, tempPref+key, opts.FrameDuration.Duration())
+	if err != nil {
+		slog.Warn(
+			"OpusCache: Failed to create entry writer",
+			"key", key,
+			"error", err,
+		)
+		c.doneRecording(key)
+		return src
+	}
+
+	return &recorder{
+		c:     c,
+		key:   key,
+		query: query,
+		src:   src,
+		w:     w,
+	}
+}
+
+func (c *Cache) commit(key string, w *writer) error {
+	size, err := w.finish()
+	if err != nil {
+		w.discard()
+		return err
+	}
+
+	if size > c.maxSize {
+		w.discard()
+		return errors.Unexpectedf(
+			"entry size %d exceeds the cache size %d",
+			size, c.maxSize,
+		)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err = os.Rename(w.dataPath(), c.path(key, dataExt)); err != nil {
+		w.discard()
+		return err
+	}
+	if err = os.Rename(w.indexPath(), c.path(key, indexExt)); err != nil {
+		w.discard()
+		os.Remove(c.path(key, dataExt))
+		return err
+	}
+
+	if elem, ok := c.entries[key]; ok {
+		c.lru.Remove(elem)
+		c.size -= elem.Value.(*entry).size
+	}
+
+	c.entries[key] = c.lru.PushFront(&entry{key: key, size: size})
+	c.size += size
+	c.evict()
+
+	return nil
+}
+
+func (c *Cache) doneRecording(key string) {
+	c.mu.Lock()
+	delete(c.recording, key)
+	c.mu.Unlock()
+}
+
+func (c *Cache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// evict must be called with the mutex locked.
+func (c *Cache) evict() {
+	for c.size > c.maxSize {
+		elem := c.lru.Back()
+		if elem == nil {
+			return
+		}
+
+		e := elem.Value.(*entry)
+		c.removeElement(elem)
+
+		slog.Debug(
+			"OpusCache: Evicted entry",
+			"key", e.key,
+			"size", e.size,
+			"cache_size", c.size,
+		)
+	}
+}
+
+// removeElement must be called with the mutex locked.
+func (c *Cache) removeElement(elem *list.Element) {
+	e := elem.Value.(*entry)
+
+	c.lru.Remove(elem)
+	delete(c.entries, e.key)
+	c.size -= e.size
+
+	// Readers that already opened the files keep working after removal.
+	os.Remove(c.path(e.key, dataExt))
+	os.Remove(c.path(e.key, indexExt))
+}
+
+func (c *Cache) load() error {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return errors.Unexpected("opus cache: read dir: " + err.Error())
+	}
+
+	type loaded struct {
+		entry
+		mtime time.Time
+	}
+	found := []loaded{}
+
+	for _, de := range dirEntries {
+		name := de.Name()
+		if de.IsDir() {
+			continue
+		}
+
+		// Leftovers of recordings interrupted by a crash or restart.
+		if strings.HasPrefix(name, tempPref) {
+			os.Remove(filepath.Join(c.dir, name))
+			continue
+		}
+
+		key, ok := strings.CutSuffix(name, indexExt)
+		if !ok {
+			continue
+		}
+
+		idxInfo, err := de.Info()
+		if err != nil {
+			continue
+		}
+		dataInfo, err := os.Stat(c.path(key, dataExt))
+		if err != nil {
+			os.Remove(c.path(key, indexExt))
+			continue
+		}
+
+		found = append(found, loaded{
+			entry: entry{key: key, size: idxInfo.Size() + dataInfo.Size()},
+			mtime: idxInfo.ModTime(),
+		})
+	}
+
+	// The index modification time is refreshed on every read, so the most
+	// recently used entries are pushed to the front.
+	slices.SortFunc(found, func(a, b loaded) int {
+		return a.mtime.Compare(b.mtime)
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, l := range found {
+		e := l.entry
+		c.entries[e.key] = c.lru.PushFront(&e)
+		c.size += e.size
+	}
+	c.evict()
+
+	slog.Info(
+		"OpusCache: Loaded cache",
+		"dir", c.dir,
+		"entries", c.lru.Len(),
+		"size", c.size,
+		"max_size", c.maxSize,
+	)
+
+	return nil
+}
+
+func (c *Cache) path(key, ext string) string {
+	return filepath.Join(c.dir, key+ext)
+}
+
+var _ Source = &recorder{}
+
+type recorder struct {
+	c     *Cache
+	key   string
+	query string
+	src   Source
+	w     *writer
+
+	done bool
+}
+
+// ReadOpus implements Source.
+func (r *recorder) ReadOpus() ([]byte, error) {
+	packet, err := r.src.ReadOpus()
+	if r.done {
+		return packet, err
+	}
+
+	if err != nil {
+		if err == io.EOF {
+			r.finish()
+		} else {
+			r.abort()
+		}
+		return packet, err
+	}
+
+	if err2 := r.w.writePacket(packet); err2 != nil {
+		slog.Warn(
+			"OpusCache: Failed to write packet",
+			"key", r.key,
+			"error", err2,
+		)
+		r.abort()
+	} else if r.w.size() > r.c.maxSize {
+		r.abort()
+	}
+
+	return packet, nil
+}
+
+// Close implements Source.
+func (r *recorder) Close() error {
+	r.abort()
+	return r.src.Close()
+}
+
+func (r *recorder) finish() {
+	r.done = true
+	defer r.c.doneRecording(r.key)
+
+	frameCount := r.w.frameCount()
+	if err := r.c.commit(r.key, r.w); err != nil {
+		slog.Warn(
+			"OpusCache: Failed to commit entry",
+			"key", r.key,
+			"query", r.query,
+			"error", err,
+		)
+		return
+	}
+
+	slog.Info(
+		"OpusCache: Stored track",
+		"key", r.key,
+		"query", r.query,
+		"frame_count", frameCount,
+	)
+}
+
+func (r *recorder) abort() {
+	if r.done {
+		return
+	}
+	r.done = true
+
+	r.w.discard()
+	r.c.doneRecording(r.key)
+}
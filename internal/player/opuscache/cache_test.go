@@ -0,0 +1,133 @@
+package opuscache_test
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zanz1n/duvua/internal/player/encoder"
+	"github.com/zanz1n/duvua/internal/player/opuscache"
+)
+
+type fakeSource struct {
+	packets [][]byte
+	pos     int
+}
+
+func newFakeSource(n int) *fakeSource {
+	packets := make([][]byte, n)
+	for i := range packets {
+		packets[i] = []byte(fmt.Sprintf("packet-%04d", i))
+	}
+	return &fakeSource{packets: packets}
+}
+
+func (s *fakeSource) ReadOpus() ([]byte, error) {
+	if s.pos >= len(s.packets) {
+		return nil, io.EOF
+	}
+	s.pos++
+	return s.packets[s.pos-1], nil
+}
+
+func (s *fakeSource) Close() error {
+	return nil
+}
+
+func readAll(t *testing.T, src opuscache.Source) [][]byte {
+	packets := [][]byte{}
+	for {
+		p, err := src.ReadOpus()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err, "Failed to read packet")
+		packets = append(packets, p)
+	}
+	return packets
+}
+
+func TestCacheRecordAndSeek(t *testing.T) {
+	c, err := opuscache.New(t.TempDir(), 1<<20)
+	assert.Nil(t, err, "Failed to create cache")
+
+	opts := encoder.DefaultEncodeOptions
+	src := newFakeSource(100)
+
+	_, ok := c.Open("youtube:abc", opts, 0)
+	assert.False(t, ok, "Entry must not be cached before recording")
+
+	rec := c.Record("youtube:abc", opts, src)
+	assert.Equal(t, src.packets, readAll(t, rec))
+	assert.Nil(t, rec.Close())
+
+	assert.Equal(t, 1, c.Len())
+
+	r, ok := c.Open("youtube:abc", opts, 0)
+	assert.True(t, ok, "Entry must be cached after a full read")
+	assert.Equal(t, src.packets, readAll(t, r))
+	r.Close()
+
+	start := 30 * opts.FrameDuration.Duration()
+	r, ok = c.Open("youtube:abc", opts, start)
+	assert.True(t, ok)
+	assert.Equal(t, src.packets[30:], readAll(t, r))
+	r.Close()
+}
+
+func TestCacheSkipsPartialReads(t *testing.T) {
+	c, err := opuscache.New(t.TempDir(), 1<<20)
+	assert.Nil(t, err, "Failed to create cache")
+
+	rec := c.Record("youtube:abc", nil, newFakeSource(100))
+	for range 10 {
+		_, err := rec.ReadOpus()
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, rec.Close())
+
+	_, ok := c.Open("youtube:abc", nil, 0)
+	assert.False(t, ok, "Partially read tracks must not be cached")
+	assert.Equal(t, int64(0), c.Size())
+}
+
+func TestCacheEviction(t *testing.T) {
+	dir := t.TempDir()
+
+	// Each entry takes 100 * (2 + 11) bytes of data and 12 + 100 * 4 of index.
+	const entrySize = 100*13 + 12 + 100*4
+
+	c, err := opuscache.New(dir, 2*entrySize)
+	assert.Nil(t, err, "Failed to create cache")
+
+	for _, q := range []string{"a", "b"} {
+		readAll(t, c.Record(q, nil, newFakeSource(100)))
+	}
+
+	// Marks "a" as recently used.
+	r, ok := c.Open("a", nil, 0)
+	assert.True(t, ok)
+	r.Close()
+	time.Sleep(10 * time.Millisecond)
+
+	readAll(t, c.Record("c", nil, newFakeSource(100)))
+
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, int64(2*entrySize), c.Size())
+
+	_, ok = c.Open("b", nil, 0)
+	assert.False(t, ok, "Least recently used entry must be evicted")
+
+	// The entries must survive a reopen of the cache.
+	c2, err := opuscache.New(dir, 2*entrySize)
+	assert.Nil(t, err, "Failed to reopen cache")
+	assert.Equal(t, 2, c2.Len())
+
+	for _, q := range []string{"a", "c"} {
+		r, ok := c2.Open(q, nil, 0)
+		assert.True(t, ok, "Entry %q must be loaded on reopen", q)
+		r.Close()
+	}
+}
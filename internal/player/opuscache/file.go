@@ -0,0 +1,208 @@
+package opuscache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zanz1n/duvua/internal/errors"
+)
+
+// The index file starts with a header followed by the offset of every
+// packet in the data file:
+//
+//	magic [4]byte | frame duration (µs) uint32 | frame count uint32 | offsets []uint32
+//
+// Each packet of the data file is prefixed by its length as an uint16.
+var indexMagic = [4]byte{'D', 'O', 'P', 'I'}
+
+const indexHeaderSize = 12
+
+var errCorruptedEntry = errors.Unexpected("opus cache: corrupted entry")
+
+type writer struct {
+	dir  string
+	name string
+
+	data    *os.File
+	buf     *bufio.Writer
+	offset  int64
+	offsets []uint32
+
+	frameDuration time.Duration
+}
+
+func newWriter(dir, name string, frameDuration time.Duration) (*writer, error) {
+	w := &writer{
+		dir:           dir,
+		name:          name,
+		offsets:       make([]uint32, 0, 1024),
+		frameDuration: frameDuration,
+	}
+
+	f, err := os.Create(w.dataPath())
+	if err != nil {
+		return nil, err
+	}
+	w.data = f
+	w.buf = bufio.NewWriter(f)
+
+	return w, nil
+}
+
+func (w *writer) dataPath() string {
+	return filepath.Join(w.dir, w.name+dataExt)
+}
+
+func (w *writer) indexPath() string {
+	return filepath.Join(w.dir, w.name+indexExt)
+}
+
+func (w *writer) frameCount() int {
+	return len(w.offsets)
+}
+
+func (w *writer) size() int64 {
+	return w.offset + indexHeaderSize + int64(len(w.offsets))*4
+}
+
+func (w *writer) writePacket(packet []byte) error {
+	if len(packet) > math.MaxUint16 {
+		return errors.Unexpected("opus cache: packet too large")
+	}
+	if w.offset > math.MaxUint32 {
+		return errors.Unexpected("opus cache: entry too large")
+	}
+
+	var lenBuf [2]byte
+	binary.LittleEndian.PutUint16(lenBuf[:], uint16(len(packet)))
+
+	if _, err := w.buf.Write(lenBuf[:]); err != nil {
+		return err
+	}
+	if _, err := w.buf.Write(packet); err != nil {
+		return err
+	}
+
+	w.offsets = append(w.offsets, uint32(w.offset))
+	w.offset += int64(len(packet)) + 2
+
+	return nil
+}
+
+// finish flushes the data file and writes the index, returning the total
+// size of the entry.
+func (w *writer) finish() (int64, error) {
+	if err := w.buf.Flush(); err != nil {
+		return 0, err
+	}
+	if err := w.data.Close(); err != nil {
+		return 0, err
+	}
+
+	idx := make([]byte, indexHeaderSize+4*len(w.offsets))
+	copy(idx, indexMagic[:])
+	binary.LittleEndian.PutUint32(idx[4:], uint32(w.frameDuration.Microseconds()))
+	binary.LittleEndian.PutUint32(idx[8:], uint32(len(w.offsets)))
+	for i, off := range w.offsets {
+		binary.LittleEndian.PutUint32(idx[indexHeaderSize+4*i:], off)
+	}
+
+	if err := os.WriteFile(w.indexPath(), idx, 0o644); err != nil {
+		return 0, err
+	}
+
+	return w.offset + int64(len(idx)), nil
+}
+
+func (w *writer) discard() {
+	w.data.Close()
+	os.Remove(w.dataPath())
+	os.Remove(w.indexPath())
+}
+
+var _ Source = &Reader{}
+
+// Reader reads the packets of a cached entry.
+type Reader struct {
+	f          *os.File
+	r          *bufio.Reader
+	frameCount int
+}
+
+func openReader(dataPath, indexPath string, start time.Duration) (*Reader, error) {
+	idx, err := os.ReadFile(indexPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(idx) < indexHeaderSize || [4]byte(idx[:4]) != indexMagic {
+		return nil, errCorruptedEntry
+	}
+
+	frameDuration := time.Duration(binary.LittleEndian.Uint32(idx[4:])) * time.Microsecond
+	frameCount := int(binary.LittleEndian.Uint32(idx[8:]))
+	if frameDuration <= 0 || len(idx) != indexHeaderSize+4*frameCount {
+		return nil, errCorruptedEntry
+	}
+
+	f, err := os.Open(dataPath)
+	if err != nil {
+		return nil, err
+	}
+
+	frame := 0
+	if start > 0 {
+		frame = int(start / frameDuration)
+	}
+
+	if frame >= frameCount {
+		// Seeking past the end yields an empty stream.
+		if _, err = f.Seek(0, io.SeekEnd); err != nil {
+			f.Close()
+			return nil, err
+		}
+	} else if frame > 0 {
+		off := binary.LittleEndian.Uint32(idx[indexHeaderSize+4*frame:])
+		if _, err = f.Seek(int64(off), io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	return &Reader{
+		f:          f,
+		r:          bufio.NewReader(f),
+		frameCount: frameCount,
+	}, nil
+}
+
+// ReadOpus implements Source.
+func (r *Reader) ReadOpus() ([]byte, error) {
+	var lenBuf [2]byte
+	if _, err := io.ReadFull(r.r, lenBuf[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errCorruptedEntry
+		}
+		return nil, err
+	}
+
+	packet := make([]byte, binary.LittleEndian.Uint16(lenBuf[:]))
+	if _, err := io.ReadFull(r.r, packet); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, errCorruptedEntry
+		}
+		return nil, err
+	}
+
+	return packet, nil
+}
+
+// Close implements Source.
+func (r *Reader) Close() error {
+	return r.f.Close()
+}
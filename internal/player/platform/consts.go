@@ -1,3 +1,26 @@
 package platform
 
+import (
+	"time"
+
+	"github.com/zanz1n/duvua/internal/player/errcodes"
+)
+
 const defaultThumbUrl = "https://encrypted-tbn0.gstatic.com/images?q=tbn:ANd9GcRd2NAjCcjjk7ac57mKCQvgWVTmP0ysxnzQnQ&s"
+
+// The max number of tracks loaded from a collection, like a playlist or an
+// album
+const maxCollectionTracks = 200
+
+// How long failed searches are cached
+const negativeCacheTTL = time.Minute
+
+// IsPermanentErr reports whether the search error will not change if the
+// search is done again, so it can be cached and fetching the track again is
+// pointless.
+func IsPermanentErr(err error) bool {
+	return err == errcodes.ErrTrackSearchFailed ||
+		err == errcodes.ErrTrackSearchInvalidUrl ||
+		err == errcodes.ErrTrackSearchUnsuported ||
+		err == errcodes.ErrTrackBlocked
+}
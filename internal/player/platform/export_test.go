@@ -0,0 +1,21 @@
+package platform
+
+var ParseHlsPlaylist = parseHlsPlaylist
+
+type YtSearchItem = ytSearchItem
+
+var YtMatchScore = ytMatchScore
+
+var (
+	NewIcyReader     = newIcyReader
+	NewHlsLiveReader = newHlsLiveReader
+)
+
+var (
+	YtStartTime = ytStartTime
+	YtChapters  = ytChapters
+)
+
+var NewProxyRotator = newProxyRotator
+
+var NewPublicTransport = newPublicTransport
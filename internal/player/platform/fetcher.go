@@ -2,70 +2,305 @@ package platform
 
 import (
 	"io"
+	"log/slog"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/zanz1n/duvua/internal/errors"
 	"github.com/zanz1n/duvua/internal/player/encoder"
 	"github.com/zanz1n/duvua/internal/player/errcodes"
+	"github.com/zanz1n/duvua/internal/player/opuscache"
+	"github.com/zanz1n/duvua/internal/utils/ttlcache"
 	"github.com/zanz1n/duvua/pkg/pb/player"
 )
 
 type Fetcher struct {
-	yt Platform
-	sp Platform
+	platforms []Platform
+	prefixes  map[string]Platform
+	hosts     map[string]Platform
+
+	// The platform used to search text queries
+	search Platform
+
+	cache *opuscache.Cache
+
+	// Search caches, nil if disabled
+	searches *ttlcache.Cache[searchResult]
+	results  *ttlcache.Cache[[]*player.SearchResult]
+}
+
+type searchResult struct {
+	tracks  []*player.TrackData
+	skipped int
+}
+
+// if cache == nil, the encoded tracks will not be cached
+func NewFetcher(cache *opuscache.Cache) *Fetcher {
+	return &Fetcher{
+		platforms: []Platform{},
+		prefixes:  map[string]Platform{},
+		hosts:     map[string]Platform{},
+		cache:     cache,
+	}
+}
+
+// Register adds the platform to the fetcher, so its urls and play queries
+// can be handled. The first registered platform is used to search text
+// queries, unless SetSearchPlatform is called.
+//
+// It panics if the name, the query prefix or any host of the platform was
+// already registered by another platform.
+func (f *Fetcher) Register(p Platform) {
+	info := p.Info()
+
+	for _, other := range f.platforms {
+		if other.Info().Name == info.Name {
+			panic("(*Fetcher).Register() platform `" + info.Name + "` already registered")
+		}
+	}
+	if _, ok := f.prefixes[info.QueryPrefix]; ok {
+		panic("(*Fetcher).Register() query prefix `" + info.QueryPrefix + "` already registered")
+	}
+	for _, host := range info.Hosts {
+		if _, ok := f.hosts[host]; ok {
+			panic("(*Fetcher).Register() host `" + host + "` already registered")
+		}
+	}
+
+	f.platforms = append(f.platforms, p)
+	f.prefixes[info.QueryPrefix] = p
+	for _, host := range info.Hosts {
+		f.hosts[host] = p
+	}
+
+	if f.search == nil {
+		f.search = p
+	}
+
+	slog.Info(
+		"Fetcher: Registered platform",
+		"name", info.Name,
+		"query_prefix", info.QueryPrefix,
+		"hosts", info.Hosts,
+	)
+}
+
+// EnableSearchCache caches the results of Search and SearchMany for ttl.
+// Failed searches that would fail again are cached for a shorter period.
+func (f *Fetcher) EnableSearchCache(size int, ttl time.Duration) {
+	cfg := ttlcache.Config{
+		Size:        size,
+		TTL:         ttl,
+		NegativeTTL: negativeCacheTTL,
+		CacheErr:    IsPermanentErr,
+	}
+
+	cfg.Name = "searches"
+	f.searches = ttlcache.New[searchResult](cfg)
+
+	cfg.Name = "search_results"
+	f.results = ttlcache.New[[]*player.SearchResult](cfg)
 }
 
-func NewFetcher(ytf *Youtube, spf *Spotify) *Fetcher {
-	if ytf == nil {
-		ytf = NewYoutube(nil, 1)
+// SetSearchPlatform sets the registered platform used to search text
+// queries.
+func (f *Fetcher) SetSearchPlatform(name string) error {
+	for _, p := range f.platforms {
+		if p.Info().Name == name {
+			f.search = p
+			return nil
+		}
 	}
-	return &Fetcher{yt: ytf, sp: spf}
+
+	return errors.Unexpectedf("search platform `%s` is not registered", name)
 }
 
-func (f *Fetcher) Search(query string) ([]*player.TrackData, error) {
-	if strings.HasPrefix(query, "https://") {
+// The returned skipped count is the number of tracks that could not be
+// resolved if the query is the url of a collection.
+//
+// The returned tracks may be shared with other callers and must not be
+// modified.
+func (f *Fetcher) Search(query string) ([]*player.TrackData, int, error) {
+	query = strings.TrimSpace(query)
+	if f.searches == nil {
+		return f.doSearch(query)
+	}
+
+	res, err := f.searches.Get(f.cacheKey(query), func() (searchResult, error) {
+		tracks, skipped, err := f.doSearch(query)
+		return searchResult{tracks: tracks, skipped: skipped}, err
+	})
+	return res.tracks, res.skipped, err
+}
+
+func (f *Fetcher) doSearch(query string) ([]*player.TrackData, int, error) {
+	if strings.HasPrefix(query, "https://") || strings.HasPrefix(query, "http://") {
 		u, err := url.Parse(query)
 		if err != nil {
-			return nil, errcodes.ErrTrackSearchInvalidUrl
+			return nil, 0, errcodes.ErrTrackSearchInvalidUrl
+		}
+
+		p, ok := f.matchUrl(u)
+		if !ok {
+			return nil, 0, errcodes.ErrTrackSearchUnsuported
+		}
+
+		return p.SearchUrl(query)
+	}
+
+	if f.search == nil {
+		return nil, 0, errcodes.ErrTrackSearchUnsuported
+	}
+
+	track, err := f.search.SearchString(query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return []*player.TrackData{track}, 0, nil
+}
+
+// SearchMany returns up to `limit` results of a text search, so the user can
+// pick the right one.
+//
+// The returned results may be shared with other callers and must not be
+// modified.
+func (f *Fetcher) SearchMany(query string, limit int) ([]*player.SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if f.results == nil {
+		return f.doSearchMany(query, limit)
+	}
+
+	key := strconv.Itoa(limit) + ":" + f.cacheKey(query)
+	return f.results.Get(key, func() ([]*player.SearchResult, error) {
+		return f.doSearchMany(query, limit)
+	})
+}
+
+func (f *Fetcher) doSearchMany(query string, limit int) ([]*player.SearchResult, error) {
+	if f.search == nil {
+		return nil, errcodes.ErrTrackSearchUnsuported
+	}
+
+	var results []*player.SearchResult
+	if ms, ok := f.search.(MultiSearcher); ok {
+		var err error
+		if results, err = ms.SearchMany(query, limit); err != nil {
+			return nil, err
+		}
+	} else {
+		track, err := f.search.SearchString(query)
+		if err != nil {
+			return nil, err
 		}
 
-		switch {
-		case strings.Contains(u.Host, "youtu"):
-			return f.yt.SearchUrl(query)
+		results = []*player.SearchResult{{
+			Kind:      player.SearchResultKind_SearchResultVideo,
+			Name:      track.Name,
+			Url:       track.Url,
+			Thumbnail: track.Thumbnail,
+			Duration:  track.Duration,
+		}}
+	}
 
-		case strings.Contains(u.Host, "spotify"):
-			return f.sp.SearchUrl(query)
+	if len(results) == 0 {
+		return nil, errcodes.ErrTrackSearchFailed
+	}
 
-		// case strings.Contains(u.Host, "soundcloud"):
-		default:
-			return nil, errcodes.ErrTrackSearchUnsuported
+	return results, nil
+}
+
+// Fetch creates the streamer of the play query with the options.
+func (f *Fetcher) Fetch(query string, opts FetchOptions) (Streamer, error) {
+	encodeOpts := opts.encodeOptions()
+
+	if f.cache != nil {
+		if r, ok := f.cache.Open(query, encodeOpts, opts.Start); ok {
+			return &cachedStreamer{r}, nil
 		}
 	}
 
-	track, err := f.yt.SearchString(query)
+	stream, err := f.fetch(query, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	return []*player.TrackData{track}, nil
+	// Live streams have no end and streams that do not start at the
+	// beginning are not complete, so they can not be cached
+	if _, live := stream.(LiveStreamer); f.cache != nil && !live && opts.Start == 0 {
+		stream = &recordStreamer{
+			Source:   f.cache.Record(query, encodeOpts, stream),
+			Streamer: stream,
+		}
+	}
+
+	return stream, nil
 }
 
-func (f *Fetcher) Fetch(query string) (Streamer, error) {
-	platform, id, ok := strings.Cut(query, ":")
+// Resolve returns the data of the track actually played for the play query,
+// or nil if it is the track itself.
+func (f *Fetcher) Resolve(query string) (*player.TrackData, error) {
+	prefix, id, ok := strings.Cut(query, ":")
 	if !ok {
 		return nil, errors.New("invalid music format")
 	}
 
-	switch platform {
-	case "youtube":
-		return f.yt.Fetch(id)
+	r, ok := f.prefixes[prefix].(Resolver)
+	if !ok {
+		return nil, nil
+	}
+	return r.Resolve(id)
+}
+
+func (f *Fetcher) fetch(query string, opts FetchOptions) (Streamer, error) {
+	prefix, id, ok := strings.Cut(query, ":")
+	if !ok {
+		return nil, errors.New("invalid music format")
+	}
 
-	// case "spotify":
-	// case "soundcloud":
-	default:
+	p, ok := f.prefixes[prefix]
+	if !ok {
 		return nil, errors.New("invalid format")
 	}
+
+	return p.Fetch(id, opts)
+}
+
+// cacheKey returns the key of the query in the search caches. Text queries
+// are case insensitive and depend on the search platform.
+func (f *Fetcher) cacheKey(query string) string {
+	if strings.HasPrefix(query, "https://") || strings.HasPrefix(query, "http://") {
+		return query
+	}
+
+	name := ""
+	if f.search != nil {
+		name = f.search.Info().Name
+	}
+	return name + ":" + strings.ToLower(query)
+}
+
+// matchUrl returns the platform that handles the url, looking for the host
+// and its parent domains before trying the MatchUrl of the platforms.
+func (f *Fetcher) matchUrl(u *url.URL) (Platform, bool) {
+	host := strings.ToLower(u.Hostname())
+	for host != "" {
+		if p, ok := f.hosts[host]; ok {
+			return p, true
+		}
+
+		_, host, _ = strings.Cut(host, ".")
+	}
+
+	for _, p := range f.platforms {
+		if match := p.Info().MatchUrl; match != nil && match(u) {
+			return p, true
+		}
+	}
+
+	return nil, false
 }
 
 var _ Streamer = &readerStreamer{}
@@ -74,8 +309,10 @@ type readerStreamer struct {
 	*encoder.Session
 }
 
-func newReaderStreamer(r io.ReadCloser) (*readerStreamer, error) {
-	return &readerStreamer{encoder.NewSession(r, nil)}, nil
+// The stream is decoded from the start of the options, discarding the audio
+// before it.
+func newReaderStreamer(r io.ReadCloser, opts FetchOptions) (*readerStreamer, error) {
+	return &readerStreamer{encoder.NewSession(r, opts.encodeOptions())}, nil
 }
 
 // SetSpeed implements Streamer.
@@ -89,3 +326,41 @@ func (s *readerStreamer) SetVolume(volume uint8) error {
 	// TODO: implement volume
 	return nil
 }
+
+var _ Streamer = &cachedStreamer{}
+
+// cachedStreamer streams a track stored in the opus cache.
+type cachedStreamer struct {
+	*opuscache.Reader
+}
+
+// SetSpeed implements Streamer.
+func (s *cachedStreamer) SetSpeed(speed TrackSpeed) error {
+	// TODO: implement speed
+	return nil
+}
+
+// SetVolume implements Streamer.
+func (s *cachedStreamer) SetVolume(volume uint8) error {
+	// TODO: implement volume
+	return nil
+}
+
+var _ Streamer = &recordStreamer{}
+
+// recordStreamer stores the packets of the underlying Streamer in the opus
+// cache while they are read.
+type recordStreamer struct {
+	opuscache.Source
+	Streamer
+}
+
+// ReadOpus implements Streamer.
+func (s *recordStreamer) ReadOpus() ([]byte, error) {
+	return s.Source.ReadOpus()
+}
+
+// Close implements Streamer.
+func (s *recordStreamer) Close() error {
+	return s.Source.Close()
+}
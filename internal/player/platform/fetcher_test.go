@@ -0,0 +1,83 @@
+package platform_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zanz1n/duvua/internal/player/errcodes"
+	"github.com/zanz1n/duvua/internal/player/platform"
+	"github.com/zanz1n/duvua/pkg/pb/player"
+)
+
+type fakePlatform struct {
+	info    platform.PlatformInfo
+	fetched []string
+}
+
+func (p *fakePlatform) Info() platform.PlatformInfo {
+	return p.info
+}
+
+func (p *fakePlatform) SearchString(s string) (*player.TrackData, error) {
+	return &player.TrackData{Name: s, PlayQuery: p.info.QueryPrefix + ":" + s}, nil
+}
+
+func (p *fakePlatform) SearchUrl(url string) ([]*player.TrackData, int, error) {
+	return []*player.TrackData{{Url: url, PlayQuery: p.info.QueryPrefix + ":1"}}, 0, nil
+}
+
+func (p *fakePlatform) Fetch(id string, opts platform.FetchOptions) (platform.Streamer, error) {
+	p.fetched = append(p.fetched, id)
+	return nil, nil
+}
+
+func TestFetcherRouting(t *testing.T) {
+	video := &fakePlatform{info: platform.PlatformInfo{
+		Name:        "video",
+		QueryPrefix: "video",
+		Hosts:       []string{"video.com"},
+	}}
+	audio := &fakePlatform{info: platform.PlatformInfo{
+		Name:        "audio",
+		QueryPrefix: "url",
+		MatchUrl: func(u *url.URL) bool {
+			return u.Path == "/file.mp3"
+		},
+	}}
+
+	f := platform.NewFetcher(nil)
+	f.Register(video)
+	f.Register(audio)
+
+	assert.Panics(t, func() { f.Register(video) }, "Duplicate names must panic")
+
+	cases := map[string]string{
+		"https://video.com/watch":          "video:1",
+		"https://m.video.com/watch":        "video:1",
+		"https://video.com/file.mp3":       "video:1",
+		"https://cdn.example.com/file.mp3": "url:1",
+		"some text":                        "video:some text",
+	}
+	for query, expected := range cases {
+		tracks, _, err := f.Search(query)
+		assert.Nil(t, err, "Search(%q) failed", query)
+		assert.Equal(t, expected, tracks[0].PlayQuery, "Search(%q)", query)
+	}
+
+	_, _, err := f.Search("https://notvideo.com/watch")
+	assert.Equal(t, errcodes.ErrTrackSearchUnsuported, err)
+
+	assert.Nil(t, f.SetSearchPlatform("audio"))
+	tracks, _, err := f.Search("some text")
+	assert.Nil(t, err)
+	assert.Equal(t, "url:some text", tracks[0].PlayQuery)
+	assert.NotNil(t, f.SetSearchPlatform("unknown"))
+
+	_, err = f.Fetch("url:https://cdn.example.com/file.mp3", platform.FetchOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"https://cdn.example.com/file.mp3"}, audio.fetched)
+
+	_, err = f.Fetch("unknown:1", platform.FetchOptions{})
+	assert.NotNil(t, err)
+}
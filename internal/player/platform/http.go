@@ -0,0 +1,476 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/zanz1n/duvua/internal/errors"
+	"github.com/zanz1n/duvua/internal/player/errcodes"
+	"github.com/zanz1n/duvua/pkg/pb/player"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var httpAudioExts = []string{".mp3", ".ogg", ".opus", ".flac", ".m4a", ".wav"}
+
+const (
+	// The metadata of the audio files is expected at the start of the file
+	httpMaxProbeSize = 16 << 20
+	// The max time taken by a server to respond, the streams are read for as
+	// long as the track plays, so the requests have no overall timeout
+	httpHeaderTimeout = 15 * time.Second
+	// The max time taken to find out what a url serves
+	httpSearchTimeout = 20 * time.Second
+)
+
+var _ Platform = &Http{}
+
+// Http plays audio files served through direct http links, like the
+// attachments uploaded to discord.
+type Http struct {
+	hc          *http.Client
+	ffprobePath string
+}
+
+// if client == nil, it will be defaulted to a client that only connects to
+// public addresses and times out servers that do not respond, see
+// newPublicTransport.
+func NewHttp(client *http.Client, ffprobeExec string) *Http {
+	if client == nil {
+		client = &http.Client{Transport: newPublicTransport()}
+	}
+	if ffprobeExec == "" {
+		ffprobeExec = "ffprobe"
+	}
+
+	return &Http{hc: client, ffprobePath: ffprobeExec}
+}
+
+type httpStreamKind uint8
+
+const (
+	httpStreamFile httpStreamKind = iota
+	httpStreamRadio
+	httpStreamHls
+)
+
+// Info implements Platform.
+// Http handles the urls that are not handled by any other platform.
+func (h *Http) Info() PlatformInfo {
+	return PlatformInfo{
+		Name:        "http",
+		QueryPrefix: "url",
+		MatchUrl: func(u *url.URL) bool {
+			return u.Scheme == "http" || u.Scheme == "https"
+		},
+	}
+}
+
+// IsAudioUrl reports whether the url points to an audio file with one of the
+// supported extensions.
+func (h *Http) IsAudioUrl(u *url.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+
+	ext := strings.ToLower(path.Ext(u.Path))
+	for _, e := range httpAudioExts {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchString implements Platform.
+func (h *Http) SearchString(s string) (*player.TrackData, error) {
+	return nil, errcodes.ErrTrackSearchUnsuported
+}
+
+// SearchUrl implements Platform.
+// Besides audio files, icecast/shoutcast radios and live hls streams are
+// supported as well.
+func (h *Http) SearchUrl(uri string) ([]*player.TrackData, int, error) {
+	u, err := url.Parse(uri)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, 0, errcodes.ErrTrackSearchInvalidUrl
+	}
+
+	if err = checkPublicHost(u.Hostname()); err != nil {
+		return nil, 0, errcodes.ErrTrackSearchInvalidUrl
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), httpSearchTimeout)
+	defer cancel()
+
+	// Radios are usually shared as playlist files pointing to the stream
+	ext := strings.ToLower(path.Ext(u.Path))
+	if ext == ".pls" || ext == ".m3u" {
+		if uri, err = h.resolveRadioPlaylist(ctx, uri); err != nil {
+			return nil, 0, err
+		}
+		if u, err = url.Parse(uri); err != nil {
+			return nil, 0, errcodes.ErrTrackSearchInvalidUrl
+		}
+		if err = checkPublicHost(u.Hostname()); err != nil {
+			return nil, 0, errcodes.ErrTrackSearchInvalidUrl
+		}
+	}
+
+	res, kind, err := h.open(ctx, uri)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer res.Body.Close()
+
+	switch kind {
+	case httpStreamRadio:
+		name := strings.TrimSpace(res.Header.Get("Icy-Name"))
+		if name == "" {
+			name = u.Host
+		}
+		return []*player.TrackData{httpLiveTrackData(name, uri)}, 0, nil
+
+	case httpStreamHls:
+		body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+		if err != nil {
+			return nil, 0, errcodes.ErrTrackSearchFailed
+		}
+
+		p, err := parseHlsMediaPlaylist(uri, string(body))
+		if err != nil {
+			return nil, 0, errcodes.ErrTrackSearchFailed
+		}
+		// Only live hls streams are supported
+		if p.ended {
+			return nil, 0, errcodes.ErrTrackSearchUnsuported
+		}
+		return []*player.TrackData{httpLiveTrackData(u.Host, uri)}, 0, nil
+	}
+
+	// ffprobe reads the response the player already requested, so it never
+	// connects to an address by itself
+	start := time.Now()
+	probe, err := h.probe(res.Body)
+	if err != nil {
+		slog.Warn(
+			"Http: Failed to probe audio file",
+			"url", uri,
+			"took", time.Since(start).Round(time.Millisecond),
+			"error", err,
+		)
+		return nil, 0, errcodes.ErrTrackSearchFailed
+	}
+
+	duration, _ := strconv.ParseFloat(probe.Format.Duration, 64)
+	// The length of some formats is only known by the file size
+	if bitRate := probe.bitRate(); duration <= 0 && bitRate > 0 && res.ContentLength > 0 {
+		duration = float64(res.ContentLength*8) / bitRate
+	}
+	if duration <= 0 {
+		return nil, 0, errcodes.ErrTrackSearchFailed
+	}
+
+	name := probe.Format.Tags.title()
+	if name == "" {
+		name, _ = url.PathUnescape(path.Base(u.Path))
+	}
+
+	return []*player.TrackData{{
+		Name:      name,
+		Url:       uri,
+		PlayQuery: "url:" + uri,
+		Thumbnail: defaultThumbUrl,
+		Duration:  durationpb.New(time.Duration(duration * float64(time.Second))),
+	}}, 0, nil
+}
+
+// Fetch implements Platform.
+func (h *Http) Fetch(uri string, opts FetchOptions) (Streamer, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, errcodes.ErrTrackSearchInvalidUrl
+	}
+
+	if err = checkPublicHost(u.Hostname()); err != nil {
+		return nil, err
+	}
+
+	// The response is streamed until the track ends, it is only interrupted
+	// by closing the body
+	res, kind, err := h.open(context.Background(), uri)
+	if err != nil {
+		return nil, errors.Unexpected("fetch http audio: " + err.Error())
+	}
+
+	slog.Debug(
+		"Http: Created audio streamer",
+		"content_type", res.Header.Get("Content-Type"),
+		"content_length", res.ContentLength,
+		"kind", kind,
+	)
+
+	switch kind {
+	case httpStreamRadio:
+		return newLiveStreamer(opts, func(setTitle func(string)) (io.ReadCloser, error) {
+			return newReconnectReader(
+				radioBody(res, setTitle),
+				func() (io.ReadCloser, error) {
+					return openRadio(h.hc, uri, setTitle)
+				},
+			), nil
+		})
+
+	case httpStreamHls:
+		res.Body.Close()
+		return newLiveStreamer(opts, func(func(string)) (io.ReadCloser, error) {
+			return newHlsLiveReader(h.hc, uri)
+		})
+
+	default:
+		return newReaderStreamer(res.Body, opts)
+	}
+}
+
+// open requests the url, finding out which kind of stream it serves by the
+// response headers.
+func (h *Http) open(
+	ctx context.Context,
+	uri string,
+) (*http.Response, httpStreamKind, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, 0, errcodes.ErrTrackSearchInvalidUrl
+	}
+	// Asks radios to send the stream titles
+	req.Header.Set("Icy-MetaData", "1")
+
+	res, err := h.hc.Do(req)
+	if err != nil {
+		return nil, 0, errors.Unexpected("http request: " + err.Error())
+	}
+
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, 0, errcodes.ErrTrackSearchFailed
+	}
+
+	ct := strings.ToLower(res.Header.Get("Content-Type"))
+	isAudio := strings.HasPrefix(ct, "audio/") || strings.HasPrefix(ct, "application/ogg")
+
+	switch {
+	case strings.Contains(ct, "mpegurl") ||
+		strings.ToLower(path.Ext(res.Request.URL.Path)) == ".m3u8":
+		return res, httpStreamHls, nil
+
+	case res.Header.Get("Icy-Metaint") != "" || res.Header.Get("Icy-Name") != "" ||
+		res.Header.Get("Icy-Br") != "":
+		return res, httpStreamRadio, nil
+
+	// Audio of unknown length is probably a radio without icecast headers
+	case isAudio && res.ContentLength < 0:
+		return res, httpStreamRadio, nil
+
+	case isAudio || h.IsAudioUrl(res.Request.URL):
+		return res, httpStreamFile, nil
+
+	default:
+		res.Body.Close()
+		return nil, 0, errcodes.ErrTrackSearchUnsuported
+	}
+}
+
+// resolveRadioPlaylist returns the first stream url of a .pls or .m3u
+// playlist file.
+func (h *Http) resolveRadioPlaylist(ctx context.Context, uri string) (string, error) {
+	res, err := httpGet(ctx, h.hc, uri)
+	if err != nil {
+		return "", errors.Unexpected("radio playlist: " + err.Error())
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", errcodes.ErrTrackSearchFailed
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 64<<10))
+	if err != nil {
+		return "", errcodes.ErrTrackSearchFailed
+	}
+
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSpace(line)
+		// .pls entries are like `File1=http://...`
+		if _, v, ok := strings.Cut(line, "="); ok && strings.HasPrefix(strings.ToLower(line), "file") {
+			line = v
+		}
+
+		if strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://") {
+			return line, nil
+		}
+	}
+
+	return "", errcodes.ErrTrackSearchFailed
+}
+
+// httpGet requests the url, the request is interrupted when ctx is done.
+func httpGet(ctx context.Context, hc *http.Client, uri string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	return hc.Do(req)
+}
+
+func httpLiveTrackData(name, uri string) *player.TrackData {
+	return &player.TrackData{
+		Name:      name,
+		Url:       uri,
+		PlayQuery: "url:" + uri,
+		Thumbnail: defaultThumbUrl,
+		Duration:  durationpb.New(0),
+		Live:      true,
+	}
+}
+
+type ffprobeTags struct {
+	Title  string `json:"title"`
+	Artist string `json:"artist"`
+	// Some containers, like ogg, use upper case tag names
+	TitleUpper  string `json:"TITLE"`
+	ArtistUpper string `json:"ARTIST"`
+}
+
+func (t *ffprobeTags) title() string {
+	title, artist := t.Title, t.Artist
+	if title == "" {
+		title = t.TitleUpper
+	}
+	if artist == "" {
+		artist = t.ArtistUpper
+	}
+
+	if title != "" && artist != "" {
+		return artist + " - " + title
+	}
+	return title
+}
+
+type ffprobeResult struct {
+	Format struct {
+		Duration string      `json:"duration"`
+		BitRate  string      `json:"bit_rate"`
+		Tags     ffprobeTags `json:"tags"`
+	} `json:"format"`
+	Streams []struct {
+		BitRate string `json:"bit_rate"`
+	} `json:"streams"`
+}
+
+// bitRate returns the bit rate of the file in bits per second, 0 if unknown.
+func (r *ffprobeResult) bitRate() float64 {
+	if v, _ := strconv.ParseFloat(r.Format.BitRate, 64); v > 0 {
+		return v
+	}
+	for _, stream := range r.Streams {
+		if v, _ := strconv.ParseFloat(stream.BitRate, 64); v > 0 {
+			return v
+		}
+	}
+	return 0
+}
+
+// probe reads the metadata of the audio file through the stdin of ffprobe.
+func (h *Http) probe(r io.Reader) (*ffprobeResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, h.ffprobePath,
+		"-v", "error",
+		"-protocol_whitelist", "pipe",
+		"-show_entries", "format=duration,bit_rate:format_tags:stream=bit_rate",
+		"-of", "json",
+		"pipe:0",
+	)
+	cmd.Stdin = io.LimitReader(r, httpMaxProbeSize)
+	// Does not wait for the copy of a stalled response after ffprobe exits
+	cmd.WaitDelay = time.Second
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Unexpected("ffprobe: " + err.Error())
+	}
+
+	var res ffprobeResult
+	if err = json.Unmarshal(out, &res); err != nil {
+		return nil, errors.Unexpected("ffprobe: parse output: " + err.Error())
+	}
+
+	return &res, nil
+}
+
+// checkPublicHost prevents the player from being used to reach services of
+// the internal network. It only rejects the urls early, the addresses are
+// checked again when connecting, see newPublicTransport.
+func checkPublicHost(host string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return errors.Unexpected("resolve host: " + err.Error())
+	}
+
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return errors.New("the url host is not allowed")
+		}
+	}
+
+	return nil
+}
+
+// newPublicTransport creates a transport that refuses to connect to non
+// public addresses. The check happens on every connection, so it also covers
+// redirects and hosts that resolve to another address after checkPublicHost.
+func newPublicTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   checkPublicAddr,
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = dialer.DialContext
+	t.ResponseHeaderTimeout = httpHeaderTimeout
+	// A proxy would be the only address checked
+	t.Proxy = nil
+	return t
+}
+
+func checkPublicAddr(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return errors.Newf("the address %s is not allowed", address)
+	}
+	return nil
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast()
+}
@@ -0,0 +1,22 @@
+package platform_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zanz1n/duvua/internal/player/platform"
+)
+
+func TestPublicTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: platform.NewPublicTransport()}
+
+	_, err := client.Get(server.URL)
+	assert.Error(t, err, "Expected the loopback address to be refused")
+}
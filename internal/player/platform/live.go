@@ -0,0 +1,689 @@
+package platform
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zanz1n/duvua/internal/errors"
+)
+
+const (
+	// The max number of consecutive failed attempts to reconnect to a live
+	// stream before giving up
+	liveMaxReconnects = 5
+	// The number of segments behind the live edge the hls streams start at
+	hlsLiveEdgeSegments = 3
+	hlsMinRefreshDelay  = 100 * time.Millisecond
+)
+
+var _ LiveStreamer = &liveStreamer{}
+
+// liveStreamer streams livestreams and radios, that have no end.
+type liveStreamer struct {
+	*readerStreamer
+
+	onTitle func(title string)
+	title   string
+	mu      sync.Mutex
+}
+
+// newLiveStreamer calls open to create the stream reader, with a function
+// that must be called when the stream title changes. The start of the
+// options is ignored.
+func newLiveStreamer(
+	opts FetchOptions,
+	open func(setTitle func(title string)) (io.ReadCloser, error),
+) (*liveStreamer, error) {
+	s := &liveStreamer{}
+
+	r, err := open(s.setTitle)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Start = 0
+	if s.readerStreamer, err = newReaderStreamer(r, opts); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// OnTitle implements LiveStreamer.
+func (s *liveStreamer) OnTitle(fn func(title string)) {
+	s.mu.Lock()
+	s.onTitle = fn
+	title := s.title
+	s.mu.Unlock()
+
+	if fn != nil && title != "" {
+		fn(title)
+	}
+}
+
+func (s *liveStreamer) setTitle(title string) {
+	s.mu.Lock()
+	if title == s.title {
+		s.mu.Unlock()
+		return
+	}
+	s.title = title
+	fn := s.onTitle
+	s.mu.Unlock()
+
+	if fn != nil {
+		fn(title)
+	}
+}
+
+var _ io.ReadCloser = &reconnectReader{}
+
+// reconnectReader reads a stream that has no end, opening it again if the
+// connection is lost.
+type reconnectReader struct {
+	open func() (io.ReadCloser, error)
+
+	current  io.ReadCloser
+	failures int
+	closed   bool
+	mu       sync.Mutex
+
+	done chan struct{}
+}
+
+// if first is not nil, it is read before the stream is opened again.
+func newReconnectReader(
+	first io.ReadCloser,
+	open func() (io.ReadCloser, error),
+) *reconnectReader {
+	return &reconnectReader{
+		open:    open,
+		current: first,
+		done:    make(chan struct{}),
+	}
+}
+
+// Read implements io.ReadCloser.
+func (r *reconnectReader) Read(p []byte) (int, error) {
+	for {
+		r.mu.Lock()
+		if r.closed {
+			r.mu.Unlock()
+			return 0, io.ErrClosedPipe
+		}
+		current := r.current
+		r.mu.Unlock()
+
+		if current == nil {
+			var err error
+			if current, err = r.reconnect(); err != nil {
+				return 0, err
+			}
+		}
+
+		n, err := current.Read(p)
+		if n > 0 {
+			r.failures = 0
+			return n, nil
+		}
+		if err == nil {
+			continue
+		}
+
+		// Live streams have no end, so io.EOF is a hiccup as well
+		current.Close()
+		r.mu.Lock()
+		r.current = nil
+		r.mu.Unlock()
+
+		slog.Warn("Live: Lost connection to stream", "error", err)
+	}
+}
+
+func (r *reconnectReader) reconnect() (io.ReadCloser, error) {
+	for {
+		if r.failures > 0 {
+			if r.failures >= liveMaxReconnects {
+				return nil, errors.Unexpectedf(
+					"live stream: gave up after %d reconnects",
+					r.failures,
+				)
+			}
+
+			delay := min(500*time.Millisecond<<r.failures, 8*time.Second)
+			select {
+			case <-time.After(delay):
+			case <-r.done:
+				return nil, io.ErrClosedPipe
+			}
+		}
+		r.failures++
+
+		start := time.Now()
+		rc, err := r.open()
+		if err != nil {
+			slog.Warn(
+				"Live: Failed to reconnect to stream",
+				"attempt", r.failures,
+				"took", time.Since(start).Round(time.Millisecond),
+				"error", err,
+			)
+			continue
+		}
+
+		r.mu.Lock()
+		if r.closed {
+			r.mu.Unlock()
+			rc.Close()
+			return nil, io.ErrClosedPipe
+		}
+		r.current = rc
+		r.mu.Unlock()
+
+		slog.Info(
+			"Live: Reconnected to stream",
+			"attempt", r.failures,
+			"took", time.Since(start).Round(time.Millisecond),
+		)
+		return rc, nil
+	}
+}
+
+// Close implements io.ReadCloser.
+func (r *reconnectReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	close(r.done)
+
+	if r.current != nil {
+		err := r.current.Close()
+		r.current = nil
+		return err
+	}
+	return nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// icyReader removes the icecast metadata blocks sent between the audio data
+// of a radio stream, reporting the stream titles they contain.
+type icyReader struct {
+	r        *bufio.Reader
+	metaint  int
+	left     int
+	setTitle func(title string)
+}
+
+func newIcyReader(r io.Reader, metaint int, setTitle func(string)) *icyReader {
+	return &icyReader{
+		r:        bufio.NewReader(r),
+		metaint:  metaint,
+		left:     metaint,
+		setTitle: setTitle,
+	}
+}
+
+// Read implements io.Reader.
+func (r *icyReader) Read(p []byte) (int, error) {
+	if r.left == 0 {
+		if err := r.readMetadata(); err != nil {
+			return 0, err
+		}
+		r.left = r.metaint
+	}
+
+	if len(p) > r.left {
+		p = p[:r.left]
+	}
+
+	n, err := r.r.Read(p)
+	r.left -= n
+	return n, err
+}
+
+func (r *icyReader) readMetadata() error {
+	size, err := r.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if size == 0 {
+		return nil
+	}
+
+	buf := make([]byte, int(size)*16)
+	if _, err = io.ReadFull(r.r, buf); err != nil {
+		return err
+	}
+
+	if title, ok := parseIcyTitle(string(buf)); ok && r.setTitle != nil {
+		r.setTitle(title)
+	}
+	return nil
+}
+
+// parseIcyTitle extracts the StreamTitle of an icecast metadata block, like
+// `StreamTitle='Artist - Song';StreamUrl=”;`.
+func parseIcyTitle(meta string) (string, bool) {
+	_, title, ok := strings.Cut(meta, "StreamTitle='")
+	if !ok {
+		return "", false
+	}
+
+	if end := strings.Index(title, "';"); end != -1 {
+		title = title[:end]
+	} else {
+		title = strings.TrimRight(title, "\x00")
+		title = strings.TrimSuffix(title, "'")
+	}
+
+	title = strings.TrimSpace(title)
+	return title, title != ""
+}
+
+// openRadio opens a radio stream requesting the icecast metadata.
+func openRadio(hc *http.Client, uri string, setTitle func(string)) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Icy-MetaData", "1")
+
+	res, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, errors.Unexpected("radio: response status: " + res.Status)
+	}
+
+	return radioBody(res, setTitle), nil
+}
+
+func radioBody(res *http.Response, setTitle func(string)) io.ReadCloser {
+	metaint, _ := strconv.Atoi(res.Header.Get("Icy-Metaint"))
+	if metaint <= 0 {
+		return res.Body
+	}
+
+	return readCloser{
+		Reader: newIcyReader(res.Body, metaint, setTitle),
+		Closer: res.Body,
+	}
+}
+
+type hlsVariant struct {
+	url       string
+	bandwidth int
+}
+
+type hlsMediaPlaylist struct {
+	variants []hlsVariant
+
+	sequence       int64
+	targetDuration time.Duration
+	init           string
+	segments       []string
+	ended          bool
+}
+
+// parseHlsMediaPlaylist parses both master and media playlists. Master
+// playlists only have the variants set.
+func parseHlsMediaPlaylist(playlistUrl, body string) (*hlsMediaPlaylist, error) {
+	base, err := url.Parse(playlistUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	resolve := func(ref string) (string, error) {
+		u, err := base.Parse(ref)
+		if err != nil {
+			return "", err
+		}
+		return u.String(), nil
+	}
+
+	p := &hlsMediaPlaylist{targetDuration: 5 * time.Second}
+	nextIsVariant, bandwidth := false, 0
+
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			nextIsVariant, bandwidth = true, 0
+			for _, attr := range strings.Split(line[len("#EXT-X-STREAM-INF:"):], ",") {
+				if v, ok := strings.CutPrefix(attr, "BANDWIDTH="); ok {
+					bandwidth, _ = strconv.Atoi(v)
+				}
+			}
+
+		case strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"):
+			p.sequence, _ = strconv.ParseInt(line[len("#EXT-X-MEDIA-SEQUENCE:"):], 10, 64)
+
+		case strings.HasPrefix(line, "#EXT-X-TARGETDURATION:"):
+			secs, err := strconv.Atoi(line[len("#EXT-X-TARGETDURATION:"):])
+			if err == nil && secs >= 0 {
+				p.targetDuration = time.Duration(secs) * time.Second
+			}
+
+		case strings.HasPrefix(line, `#EXT-X-MAP:URI="`):
+			ref, _, _ := strings.Cut(line[len(`#EXT-X-MAP:URI="`):], `"`)
+			if p.init, err = resolve(ref); err != nil {
+				return nil, err
+			}
+
+		case line == "#EXT-X-ENDLIST":
+			p.ended = true
+
+		case strings.HasPrefix(line, "#"):
+
+		default:
+			u, err := resolve(line)
+			if err != nil {
+				return nil, err
+			}
+
+			if nextIsVariant {
+				p.variants = append(p.variants, hlsVariant{url: u, bandwidth: bandwidth})
+				nextIsVariant = false
+			} else {
+				p.segments = append(p.segments, u)
+			}
+		}
+	}
+
+	if len(p.variants) == 0 && len(p.segments) == 0 {
+		return nil, errors.Unexpected("hls playlist: no segments")
+	}
+
+	return p, nil
+}
+
+func fetchHlsPlaylist(
+	ctx context.Context,
+	hc *http.Client,
+	uri string,
+) (*hlsMediaPlaylist, error) {
+	res, err := httpGet(ctx, hc, uri)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Unexpected("hls playlist: response status: " + res.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	return parseHlsMediaPlaylist(uri, string(body))
+}
+
+// resolveHlsMedia returns the media playlist with the lowest bandwidth if
+// the playlist is a master playlist. The audio quality of the variants of
+// livestreams is usually the same.
+func resolveHlsMedia(
+	ctx context.Context,
+	hc *http.Client,
+	uri string,
+) (string, *hlsMediaPlaylist, error) {
+	p, err := fetchHlsPlaylist(ctx, hc, uri)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(p.variants) == 0 {
+		return uri, p, nil
+	}
+
+	variant := slices.MinFunc(p.variants, func(a, b hlsVariant) int {
+		return a.bandwidth - b.bandwidth
+	})
+
+	p, err = fetchHlsPlaylist(ctx, hc, variant.url)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(p.variants) > 0 {
+		return "", nil, errors.Unexpected("hls playlist: nested master playlists")
+	}
+
+	return variant.url, p, nil
+}
+
+var _ io.ReadCloser = &hlsLiveReader{}
+
+// hlsLiveReader concatenates the segments of a live hls media playlist,
+// refreshing the playlist as new segments are published.
+type hlsLiveReader struct {
+	hc          *http.Client
+	playlistUrl string
+
+	lastSeq        int64
+	targetDuration time.Duration
+	pending        []string
+	ended          bool
+	failures       int
+	// The number of consecutive segments that failed to be fetched
+	segFailures int
+
+	current io.ReadCloser
+	closed  bool
+	mu      sync.Mutex
+
+	// Canceled on Close, interrupting the pending requests
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newHlsLiveReader(hc *http.Client, uri string) (*hlsLiveReader, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	mediaUrl, p, err := resolveHlsMedia(ctx, hc, uri)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	r := &hlsLiveReader{
+		hc:          hc,
+		playlistUrl: mediaUrl,
+		lastSeq:     -1,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+
+	// Starts near the live edge, not at the oldest available segment
+	skip := max(len(p.segments)-hlsLiveEdgeSegments, 0)
+	if p.ended {
+		skip = 0
+	}
+	if p.init != "" {
+		r.pending = append(r.pending, p.init)
+	}
+	r.push(p, skip)
+
+	return r, nil
+}
+
+func (r *hlsLiveReader) push(p *hlsMediaPlaylist, skip int) {
+	for i, seg := range p.segments {
+		seq := p.sequence + int64(i)
+		if i < skip || seq <= r.lastSeq {
+			continue
+		}
+		r.pending = append(r.pending, seg)
+		r.lastSeq = seq
+	}
+
+	if r.lastSeq < p.sequence+int64(len(p.segments))-1 {
+		r.lastSeq = p.sequence + int64(len(p.segments)) - 1
+	}
+	r.targetDuration = p.targetDuration
+	r.ended = p.ended
+}
+
+// refresh waits for new segments to be published.
+func (r *hlsLiveReader) refresh() error {
+	for len(r.pending) == 0 {
+		if r.ended {
+			return io.EOF
+		}
+		if r.failures >= liveMaxReconnects {
+			return errors.Unexpectedf(
+				"hls live stream: gave up after %d failed refreshes",
+				r.failures,
+			)
+		}
+
+		select {
+		case <-time.After(max(r.targetDuration/2, hlsMinRefreshDelay)):
+		case <-r.ctx.Done():
+			return io.ErrClosedPipe
+		}
+
+		p, err := fetchHlsPlaylist(r.ctx, r.hc, r.playlistUrl)
+		if err != nil {
+			r.failures++
+			slog.Warn(
+				"Live: Failed to refresh hls playlist",
+				"attempt", r.failures,
+				"error", err,
+			)
+			continue
+		}
+		r.failures = 0
+		r.push(p, 0)
+	}
+	return nil
+}
+
+// backoff waits before the next segment is fetched, longer as more
+// consecutive segments fail, so a failing server is not flooded with
+// requests.
+func (r *hlsLiveReader) backoff() error {
+	delay := min(
+		hlsMinRefreshDelay<<min(r.segFailures, liveMaxReconnects),
+		max(r.targetDuration, hlsMinRefreshDelay),
+	)
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-r.ctx.Done():
+		return io.ErrClosedPipe
+	}
+}
+
+// Read implements io.ReadCloser.
+func (r *hlsLiveReader) Read(p []byte) (int, error) {
+	for {
+		r.mu.Lock()
+		if r.closed {
+			r.mu.Unlock()
+			return 0, io.ErrClosedPipe
+		}
+		current := r.current
+		r.mu.Unlock()
+
+		if current == nil {
+			if err := r.refresh(); err != nil {
+				return 0, err
+			}
+
+			seg := r.pending[0]
+			r.pending = r.pending[1:]
+
+			res, err := httpGet(r.ctx, r.hc, seg)
+			if err == nil && res.StatusCode != http.StatusOK {
+				res.Body.Close()
+				err = errors.Unexpected("hls segment: response status: " + res.Status)
+			}
+			if err != nil {
+				// Missing a segment is better than stopping the stream
+				r.segFailures++
+				slog.Warn(
+					"Live: Failed to fetch hls segment",
+					"attempt", r.segFailures,
+					"error", err,
+				)
+				if err = r.backoff(); err != nil {
+					return 0, err
+				}
+				continue
+			}
+			r.segFailures = 0
+
+			r.mu.Lock()
+			if r.closed {
+				r.mu.Unlock()
+				res.Body.Close()
+				return 0, io.ErrClosedPipe
+			}
+			r.current = res.Body
+			r.mu.Unlock()
+			current = res.Body
+		}
+
+		n, err := current.Read(p)
+		if err != nil {
+			current.Close()
+			r.mu.Lock()
+			r.current = nil
+			r.mu.Unlock()
+
+			if err != io.EOF {
+				slog.Warn("Live: Failed to read hls segment", "error", err)
+			}
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+
+		return n, nil
+	}
+}
+
+// Close implements io.ReadCloser.
+func (r *hlsLiveReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	r.cancel()
+
+	if r.current != nil {
+		err := r.current.Close()
+		r.current = nil
+		return err
+	}
+	return nil
+}
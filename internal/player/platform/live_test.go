@@ -0,0 +1,95 @@
+package platform_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zanz1n/duvua/internal/player/platform"
+)
+
+func icyMetadataBlock(meta string) []byte {
+	size := (len(meta) + 15) / 16
+	block := make([]byte, 1+size*16)
+	block[0] = byte(size)
+	copy(block[1:], meta)
+	return block
+}
+
+func TestIcyReader(t *testing.T) {
+	const metaint = 8
+
+	stream := bytes.Buffer{}
+	stream.WriteString("aaaaaaaa")
+	stream.Write(icyMetadataBlock("StreamTitle='Artist - Song';StreamUrl='';"))
+	stream.WriteString("bbbbbbbb")
+	stream.Write([]byte{0})
+	stream.WriteString("cccccccc")
+	stream.Write(icyMetadataBlock("StreamTitle='Other Artist - It''s Live';"))
+	stream.WriteString("dddd")
+
+	titles := []string{}
+	r := platform.NewIcyReader(&stream, metaint, func(title string) {
+		titles = append(titles, title)
+	})
+
+	audio, err := io.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "aaaaaaaabbbbbbbbccccccccdddd", string(audio))
+	assert.Equal(t, []string{"Artist - Song", "Other Artist - It''s Live"}, titles)
+}
+
+func TestHlsLiveReader(t *testing.T) {
+	// Every playlist request publishes a new segment
+	var requests atomic.Int64
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /master.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, strings.Join([]string{
+			"#EXTM3U",
+			"#EXT-X-STREAM-INF:BANDWIDTH=2000000",
+			"high.m3u8",
+			"#EXT-X-STREAM-INF:BANDWIDTH=100000",
+			"low.m3u8",
+		}, "\n"))
+	})
+	mux.HandleFunc("GET /low.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		// The playlist keeps the last 4 segments
+		first := n
+
+		lines := []string{
+			"#EXTM3U",
+			"#EXT-X-TARGETDURATION:0",
+			fmt.Sprintf("#EXT-X-MEDIA-SEQUENCE:%d", first),
+		}
+		for seq := first; seq < n+4; seq++ {
+			lines = append(lines, "#EXTINF:1,", fmt.Sprintf("seg/%d.ts", seq))
+		}
+		if n >= 3 {
+			lines = append(lines, "#EXT-X-ENDLIST")
+		}
+		fmt.Fprint(w, strings.Join(lines, "\n"))
+	})
+	mux.HandleFunc("GET /seg/{seq}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "[%s]", r.PathValue("seq"))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	r, err := platform.NewHlsLiveReader(srv.Client(), srv.URL+"/master.m3u8")
+	assert.Nil(t, err)
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	assert.Nil(t, err)
+	// Starts 3 segments behind the live edge, then follows the new ones
+	assert.Equal(t, "[2.ts][3.ts][4.ts][5.ts][6.ts]", string(data))
+}
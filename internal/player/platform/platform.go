@@ -2,7 +2,10 @@ package platform
 
 import (
 	"io"
+	"net/url"
+	"time"
 
+	"github.com/zanz1n/duvua/internal/player/encoder"
 	"github.com/zanz1n/duvua/pkg/pb/player"
 )
 
@@ -28,8 +31,72 @@ type Streamer interface {
 	io.Closer
 }
 
+// LiveStreamer is implemented by the streamers of livestreams and radios,
+// which have no end.
+type LiveStreamer interface {
+	Streamer
+	// OnTitle sets the function called when the title of the stream changes,
+	// like when a radio starts playing another song. It is called right away
+	// if the title is already known.
+	OnTitle(fn func(title string))
+}
+
+// PlatformInfo describes the urls and play queries handled by a Platform.
+type PlatformInfo struct {
+	// The name used to enable or disable the platform in the configuration
+	Name string
+	// The prefix of the PlayQuery of the tracks, without the ':' separator
+	QueryPrefix string
+	// The hosts of the urls handled by the platform. Their subdomains are
+	// handled as well.
+	Hosts []string
+	// If not nil, it is used to match urls of any host, after the urls that
+	// match the Hosts of all the platforms
+	MatchUrl func(u *url.URL) bool
+}
+
 type Platform interface {
+	Info() PlatformInfo
 	SearchString(s string) (*player.TrackData, error)
-	SearchUrl(url string) ([]*player.TrackData, error)
-	Fetch(url string) (Streamer, error)
+	// The skipped count is the number of tracks of a collection (playlist,
+	// album, etc...) that could not be resolved.
+	SearchUrl(url string) (tracks []*player.TrackData, skipped int, err error)
+	// The start of the options is ignored by livestreams.
+	Fetch(url string, opts FetchOptions) (Streamer, error)
+}
+
+// FetchOptions are the options of the streams created by the platforms.
+type FetchOptions struct {
+	// Where the playback starts
+	Start time.Duration
+	// The volume of the stream, where 256 is the original volume. If zero,
+	// the default volume is used.
+	Volume uint16
+}
+
+// encodeOptions returns the options used to encode the stream.
+func (o FetchOptions) encodeOptions() *encoder.EncodeOptions {
+	opts := encoder.DefaultEncodeOptions
+	if o.Start > 0 || (o.Volume != 0 && o.Volume != opts.Volume) {
+		v := *opts
+		v.StartTime = int(o.Start / time.Second)
+		if o.Volume != 0 {
+			v.Volume = o.Volume
+		}
+		opts = &v
+	}
+	return opts
+}
+
+// Resolver is implemented by platforms whose tracks are played from another
+// platform, like spotify tracks played as youtube videos.
+type Resolver interface {
+	// Resolve returns the data of the track that is actually played.
+	Resolve(id string) (*player.TrackData, error)
+}
+
+// MultiSearcher is implemented by platforms that can return more than one
+// result of a text search.
+type MultiSearcher interface {
+	SearchMany(s string, limit int) ([]*player.SearchResult, error)
 }
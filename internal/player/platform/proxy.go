@@ -0,0 +1,209 @@
+package platform
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zanz1n/duvua/internal/errors"
+)
+
+// The proxies blocked by youtube are not used for a while, the backoff
+// doubles every time the proxy is blocked again
+const (
+	proxyMinBackoff = time.Minute
+	proxyMaxBackoff = 30 * time.Minute
+	// The max size of the player responses checked for bot checks, the rest
+	// is not checked
+	proxyMaxSniffSize = 4 << 20
+)
+
+var errYtBlocked = errors.Unexpected("youtube: all the proxies are blocked")
+
+type proxyEntry struct {
+	// The url of the proxy without the credentials, used in logs
+	name      string
+	transport http.RoundTripper
+
+	failures     int
+	blockedUntil time.Time
+}
+
+var _ http.RoundTripper = &proxyRotator{}
+
+// proxyRotator sends each request through the next proxy that is not
+// blocked, retrying the blocked requests with the other proxies.
+type proxyRotator struct {
+	entries []*proxyEntry
+	next    int
+	mu      sync.Mutex
+}
+
+// if proxies is empty, the requests are sent directly, but are still backed
+// off when blocked.
+func newProxyRotator(base *http.Transport, proxies []*url.URL) *proxyRotator {
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+
+	r := &proxyRotator{}
+	if len(proxies) == 0 {
+		r.entries = []*proxyEntry{{name: "direct", transport: base}}
+		return r
+	}
+
+	r.entries = make([]*proxyEntry, len(proxies))
+	for i, u := range proxies {
+		t := base.Clone()
+		t.Proxy = http.ProxyURL(u)
+
+		r.entries[i] = &proxyEntry{name: u.Redacted(), transport: t}
+	}
+	return r
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *proxyRotator) RoundTrip(req *http.Request) (*http.Response, error) {
+	for tries := 1; ; tries++ {
+		e, ok := r.pick()
+		if !ok {
+			return nil, errYtBlocked
+		}
+
+		start := time.Now()
+		res, err := e.transport.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		blocked := false
+		if res, blocked, err = ytBlocked(req, res); err != nil {
+			return nil, err
+		}
+
+		if !blocked {
+			r.unblock(e)
+			slog.Debug(
+				"Youtube: Request served",
+				"proxy", e.name,
+				"host", req.URL.Host,
+				"path", req.URL.Path,
+				"status", res.StatusCode,
+				"took", time.Since(start).Round(time.Millisecond),
+			)
+			return res, nil
+		}
+		res.Body.Close()
+
+		backoff := r.block(e)
+		slog.Warn(
+			"Youtube: Proxy blocked",
+			"proxy", e.name,
+			"host", req.URL.Host,
+			"path", req.URL.Path,
+			"status", res.StatusCode,
+			"backoff", backoff,
+		)
+
+		if tries >= len(r.entries) {
+			return nil, errYtBlocked
+		}
+		if req, err = rewindRequest(req); err != nil {
+			return nil, errYtBlocked
+		}
+	}
+}
+
+func (r *proxyRotator) pick() (*proxyEntry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for range r.entries {
+		e := r.entries[r.next]
+		r.next = (r.next + 1) % len(r.entries)
+
+		if now.After(e.blockedUntil) {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+func (r *proxyRotator) block(e *proxyEntry) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	backoff := min(proxyMinBackoff<<e.failures, proxyMaxBackoff)
+	e.failures++
+	e.blockedUntil = time.Now().Add(backoff)
+	return backoff
+}
+
+func (r *proxyRotator) unblock(e *proxyEntry) {
+	r.mu.Lock()
+	e.failures = 0
+	r.mu.Unlock()
+}
+
+// rewindRequest returns a copy of the request that can be sent again.
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.Unexpected("request body can not be rewound")
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Body = body
+	return req, nil
+}
+
+// ytBlocked reports whether youtube refused to answer the request, asking
+// for a consent or a captcha, rate limiting or checking for bots. The
+// returned response must be used instead of res, since its body may have
+// been read.
+func ytBlocked(req *http.Request, res *http.Response) (*http.Response, bool, error) {
+	if res.StatusCode == http.StatusTooManyRequests {
+		return res, true, nil
+	}
+
+	if loc := res.Header.Get("Location"); loc != "" {
+		if strings.Contains(loc, "consent.youtube.com") ||
+			strings.Contains(loc, "google.com/sorry") {
+			return res, true, nil
+		}
+	}
+
+	// The bot checks are sent as unplayable videos in the player responses
+	if res.StatusCode != http.StatusOK ||
+		!strings.HasSuffix(req.URL.Path, "/youtubei/v1/player") {
+		return res, false, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, proxyMaxSniffSize))
+	if err != nil {
+		res.Body.Close()
+		return nil, false, err
+	}
+	res.Body = readCloser{
+		Reader: io.MultiReader(bytes.NewReader(body), res.Body),
+		Closer: res.Body,
+	}
+
+	blocked := bytes.Contains(body, []byte(`"LOGIN_REQUIRED"`)) &&
+		bytes.Contains(body, []byte("not a bot"))
+
+	return res, blocked, nil
+}
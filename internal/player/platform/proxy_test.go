@@ -0,0 +1,66 @@
+package platform_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zanz1n/duvua/internal/player/platform"
+)
+
+// newProxyStandIn creates a proxy that answers all the requests itself with
+// the status.
+func newProxyStandIn(t *testing.T, status *atomic.Int32, hits *atomic.Int32) *url.URL {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(int(status.Load()))
+		io.WriteString(w, r.URL.String())
+	}))
+	t.Cleanup(srv.Close)
+
+	u, _ := url.Parse(srv.URL)
+	return u
+}
+
+func TestProxyRotator(t *testing.T) {
+	var blockedStatus, okStatus atomic.Int32
+	var blockedHits, okHits atomic.Int32
+
+	blockedStatus.Store(http.StatusTooManyRequests)
+	okStatus.Store(http.StatusOK)
+
+	client := &http.Client{Transport: platform.NewProxyRotator(nil, []*url.URL{
+		newProxyStandIn(t, &blockedStatus, &blockedHits),
+		newProxyStandIn(t, &okStatus, &okHits),
+	})}
+
+	for range 3 {
+		res, err := client.Get("http://www.youtube.test/watch?v=id")
+		if assert.Nil(t, err) {
+			body, _ := io.ReadAll(res.Body)
+			res.Body.Close()
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, "http://www.youtube.test/watch?v=id", string(body),
+				"The request must be sent through the proxy")
+		}
+	}
+
+	assert.Equal(t, int32(1), blockedHits.Load(), "Blocked proxies must not be used")
+	assert.Equal(t, int32(3), okHits.Load())
+
+	okStatus.Store(http.StatusTooManyRequests)
+
+	_, err := client.Get("http://www.youtube.test/watch?v=id")
+	assert.NotNil(t, err, "Requests must fail when all the proxies are blocked")
+
+	_, err = client.Get("http://www.youtube.test/watch?v=id")
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(4), okHits.Load(),
+		"Requests must fail fast while all the proxies are backed off")
+	assert.Equal(t, int32(1), blockedHits.Load())
+}
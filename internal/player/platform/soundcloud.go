@@ -0,0 +1,687 @@
+package platform
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zanz1n/duvua/internal/errors"
+	"github.com/zanz1n/duvua/internal/player/errcodes"
+	"github.com/zanz1n/duvua/pkg/pb/player"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	soundCloudSiteUrl = "https://soundcloud.com"
+	soundCloudApiUrl  = "https://api-v2.soundcloud.com"
+)
+
+var (
+	scScriptRe   = regexp.MustCompile(`<script[^>]+src="([^"]+\.js)"`)
+	scClientIdRe = regexp.MustCompile(`client_id\s*[:=]\s*"?([0-9a-zA-Z]{32})`)
+)
+
+var errSoundCloudUnauthorized = errors.Unexpected("soundcloud: unauthorized")
+
+type SoundCloudConfig struct {
+	// if nil, it will be defaulted to http.DefaultClient
+	Client *http.Client
+	// if empty, it will be discovered from the soundcloud website
+	ClientId string
+
+	// The urls of the website and the api, only changed to point to
+	// a local stand-in in tests
+	SiteUrl string
+	ApiUrl  string
+}
+
+var _ Platform = &SoundCloud{}
+
+type SoundCloud struct {
+	hc      *http.Client
+	siteUrl string
+	apiUrl  string
+
+	clientId string
+	mu       sync.Mutex
+}
+
+func NewSoundCloud(cfg SoundCloudConfig) *SoundCloud {
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	if cfg.SiteUrl == "" {
+		cfg.SiteUrl = soundCloudSiteUrl
+	}
+	if cfg.ApiUrl == "" {
+		cfg.ApiUrl = soundCloudApiUrl
+	}
+
+	return &SoundCloud{
+		hc:       cfg.Client,
+		siteUrl:  strings.TrimSuffix(cfg.SiteUrl, "/"),
+		apiUrl:   strings.TrimSuffix(cfg.ApiUrl, "/"),
+		clientId: cfg.ClientId,
+	}
+}
+
+// Info implements Platform.
+func (s *SoundCloud) Info() PlatformInfo {
+	return PlatformInfo{
+		Name:        "soundcloud",
+		QueryPrefix: "soundcloud",
+		Hosts:       []string{"soundcloud.com"},
+	}
+}
+
+// SearchString implements Platform.
+func (s *SoundCloud) SearchString(query string) (*player.TrackData, error) {
+	var res scCollection[scTrack]
+
+	err := s.apiGet("/search/tracks", url.Values{
+		"q":     {query},
+		"limit": {"5"},
+	}, &res)
+	if err != nil {
+		return nil, errors.Unexpected("soundcloud search string: " + err.Error())
+	}
+
+	for _, track := range res.Collection {
+		if data, ok := track.Into(); ok {
+			return data, nil
+		}
+	}
+
+	return nil, errcodes.ErrTrackSearchFailed
+}
+
+// SearchUrl implements Platform.
+func (s *SoundCloud) SearchUrl(uri string) ([]*player.TrackData, int, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, 0, errcodes.ErrTrackSearchInvalidUrl
+	}
+
+	// Shortened urls redirect to the full ones
+	if u.Host == "on.soundcloud.com" {
+		res, err := s.hc.Get(u.String())
+		if err != nil {
+			return nil, 0, errors.Unexpected("soundcloud short url: " + err.Error())
+		}
+		res.Body.Close()
+		u = res.Request.URL
+	}
+
+	u.Host = "soundcloud.com"
+	u.RawQuery = ""
+	u.Fragment = ""
+
+	pathS := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(pathS) == 2 && pathS[1] == "likes" {
+		return s.searchLikes(u)
+	}
+
+	var res scResource
+	err = s.apiGet("/resolve", url.Values{"url": {u.String()}}, &res)
+	if err != nil {
+		if err == errcodes.ErrTrackSearchFailed {
+			return nil, 0, err
+		}
+		return nil, 0, errors.Unexpected("soundcloud resolve: " + err.Error())
+	}
+
+	var tracks []scTrack
+	switch res.Kind {
+	case "track":
+		tracks = []scTrack{res.scTrack}
+
+	case "playlist", "system-playlist":
+		tracks, err = s.hydrate(res.Tracks)
+		if err != nil {
+			return nil, 0, err
+		}
+
+	default:
+		return nil, 0, errcodes.ErrTrackSearchUnsuported
+	}
+
+	return intoTrackData(tracks)
+}
+
+// Fetch implements Platform.
+func (s *SoundCloud) Fetch(id string, opts FetchOptions) (Streamer, error) {
+	var track scTrack
+	if err := s.apiGet("/tracks/"+id, nil, &track); err != nil {
+		return nil, errors.Unexpected("fetch soundcloud track: " + err.Error())
+	}
+
+	transcoding := track.Media.filter()
+	if transcoding == nil {
+		return nil, errcodes.ErrTrackSearchFailed
+	}
+
+	var stream struct {
+		Url string `json:"url"`
+	}
+	if err := s.apiGet(transcoding.Url, nil, &stream); err != nil {
+		return nil, errors.Unexpected(
+			"fetch soundcloud stream url: " + err.Error(),
+		)
+	}
+
+	var (
+		r   io.ReadCloser
+		err error
+	)
+	if transcoding.Format.Protocol == "hls" {
+		r, err = newHlsReader(s.hc, stream.Url)
+	} else {
+		r, err = s.get(stream.Url)
+	}
+	if err != nil {
+		slog.Warn("SoundCloud: Failed to get audio stream", "error", err)
+		return nil, errors.Unexpected(
+			"fetch soundcloud audio stream: " + err.Error(),
+		)
+	}
+
+	slog.Debug(
+		"SoundCloud: Created audio streamer",
+		"protocol", transcoding.Format.Protocol,
+		"input_codec", transcoding.Format.MimeType,
+	)
+
+	return newReaderStreamer(r, opts)
+}
+
+func (s *SoundCloud) searchLikes(u *url.URL) ([]*player.TrackData, int, error) {
+	u.Path = strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/likes")
+
+	var user scResource
+	if err := s.apiGet("/resolve", url.Values{"url": {u.String()}}, &user); err != nil {
+		if err == errcodes.ErrTrackSearchFailed {
+			return nil, 0, err
+		}
+		return nil, 0, errors.Unexpected("soundcloud resolve user: " + err.Error())
+	}
+	if user.Kind != "user" {
+		return nil, 0, errcodes.ErrTrackSearchFailed
+	}
+
+	tracks := []scTrack{}
+	next := "/users/" + strconv.FormatInt(user.Id, 10) + "/likes"
+	query := url.Values{"limit": {"50"}}
+
+	for next != "" && len(tracks) < maxCollectionTracks {
+		var res scCollection[struct {
+			Track *scTrack `json:"track"`
+		}]
+		if err := s.apiGet(next, query, &res); err != nil {
+			return nil, 0, errors.Unexpected(
+				"soundcloud search likes: " + err.Error(),
+			)
+		}
+
+		for _, like := range res.Collection {
+			// Liked playlists come without the track field
+			if like.Track != nil {
+				tracks = append(tracks, *like.Track)
+			}
+		}
+
+		next, query = res.NextHref, nil
+	}
+
+	if len(tracks) > maxCollectionTracks {
+		tracks = tracks[:maxCollectionTracks]
+	}
+
+	return intoTrackData(tracks)
+}
+
+// Playlists only return the full data of the first tracks, while the others
+// only come with the id.
+func (s *SoundCloud) hydrate(tracks []scTrack) ([]scTrack, error) {
+	const BatchSize = 50
+
+	if len(tracks) > maxCollectionTracks {
+		tracks = tracks[:maxCollectionTracks]
+	}
+
+	missing := []string{}
+	for _, track := range tracks {
+		if track.Title == "" {
+			missing = append(missing, strconv.FormatInt(track.Id, 10))
+		}
+	}
+
+	full := make(map[int64]scTrack, len(missing))
+	for len(missing) > 0 {
+		batch := missing[:min(BatchSize, len(missing))]
+		missing = missing[len(batch):]
+
+		var res []scTrack
+		err := s.apiGet("/tracks", url.Values{
+			"ids": {strings.Join(batch, ",")},
+		}, &res)
+		if err != nil {
+			return nil, errors.Unexpected(
+				"soundcloud fetch playlist tracks: " + err.Error(),
+			)
+		}
+
+		for _, track := range res {
+			full[track.Id] = track
+		}
+	}
+
+	hydrated := make([]scTrack, 0, len(tracks))
+	for _, track := range tracks {
+		if track.Title == "" {
+			var ok bool
+			if track, ok = full[track.Id]; !ok {
+				continue
+			}
+		}
+		hydrated = append(hydrated, track)
+	}
+
+	return hydrated, nil
+}
+
+// apiGet requests the soundcloud api and decodes the json response into v.
+// The path may also be a full url, as returned by the api in pagination
+// and transcoding urls.
+func (s *SoundCloud) apiGet(path string, query url.Values, v any) error {
+	clientId, err := s.getClientId(false)
+	if err != nil {
+		return err
+	}
+
+	err = s.apiGetWithId(path, query, clientId, v)
+	if err == errSoundCloudUnauthorized {
+		if clientId, err = s.getClientId(true); err != nil {
+			return err
+		}
+		err = s.apiGetWithId(path, query, clientId, v)
+	}
+
+	return err
+}
+
+func (s *SoundCloud) apiGetWithId(path string, query url.Values, clientId string, v any) error {
+	uri := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		uri = s.apiUrl + path
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return err
+	}
+
+	q := u.Query()
+	for k, vs := range query {
+		q[k] = vs
+	}
+	q.Set("client_id", clientId)
+	u.RawQuery = q.Encode()
+
+	res, err := s.hc.Get(u.String())
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return errSoundCloudUnauthorized
+	case http.StatusNotFound:
+		return errcodes.ErrTrackSearchFailed
+	default:
+		return errors.Unexpected("response status: " + res.Status)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+func (s *SoundCloud) get(uri string) (io.ReadCloser, error) {
+	res, err := s.hc.Get(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, errors.Unexpected("response status: " + res.Status)
+	}
+
+	return res.Body, nil
+}
+
+func (s *SoundCloud) getClientId(refresh bool) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.clientId != "" && !refresh {
+		return s.clientId, nil
+	}
+
+	start := time.Now()
+	clientId, err := s.discoverClientId()
+	if err != nil {
+		slog.Error(
+			"SoundCloud: Failed to discover client id",
+			"took", time.Since(start).Round(time.Millisecond),
+			"error", err,
+		)
+		return "", errors.Unexpected(
+			"soundcloud discover client id: " + err.Error(),
+		)
+	}
+
+	s.clientId = clientId
+	slog.Info(
+		"SoundCloud: Discovered client id",
+		"took", time.Since(start).Round(time.Millisecond),
+	)
+
+	return clientId, nil
+}
+
+// The client id used by the soundcloud website is embedded in one of the
+// javascript bundles loaded by the home page, usually the last one.
+func (s *SoundCloud) discoverClientId() (string, error) {
+	body, err := s.get(s.siteUrl)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	page, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+
+	scripts := scScriptRe.FindAllSubmatch(page, -1)
+	for i := len(scripts) - 1; i >= 0; i-- {
+		src := string(scripts[i][1])
+		if strings.HasPrefix(src, "/") {
+			src = s.siteUrl + src
+		}
+
+		r, err := s.get(src)
+		if err != nil {
+			continue
+		}
+		script, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			continue
+		}
+
+		if m := scClientIdRe.FindSubmatch(script); m != nil {
+			return string(m[1]), nil
+		}
+	}
+
+	return "", errors.Unexpected("client id not found")
+}
+
+func intoTrackData(tracks []scTrack) ([]*player.TrackData, int, error) {
+	data := make([]*player.TrackData, 0, len(tracks))
+	for _, track := range tracks {
+		if d, ok := track.Into(); ok {
+			data = append(data, d)
+		}
+	}
+
+	if len(data) == 0 {
+		return nil, 0, errcodes.ErrTrackSearchFailed
+	}
+
+	return data, len(tracks) - len(data), nil
+}
+
+type scCollection[T any] struct {
+	Collection []T    `json:"collection"`
+	NextHref   string `json:"next_href"`
+}
+
+type scResource struct {
+	Kind string `json:"kind"`
+	scTrack
+	Tracks []scTrack `json:"tracks"`
+}
+
+type scTrack struct {
+	Id           int64  `json:"id"`
+	Title        string `json:"title"`
+	PermalinkUrl string `json:"permalink_url"`
+	ArtworkUrl   string `json:"artwork_url"`
+	// Duration in milliseconds
+	Duration int64 `json:"duration"`
+	// BLOCK: not streamable, SNIP: only a preview is available
+	Policy string `json:"policy"`
+	User   struct {
+		AvatarUrl string `json:"avatar_url"`
+	} `json:"user"`
+	Media scMedia `json:"media"`
+}
+
+func (t *scTrack) Into() (*player.TrackData, bool) {
+	if t.Id == 0 || t.Title == "" || t.Duration == 0 {
+		return nil, false
+	}
+	if t.Policy == "BLOCK" || t.Policy == "SNIP" {
+		return nil, false
+	}
+
+	thumbnail := t.ArtworkUrl
+	if thumbnail == "" {
+		thumbnail = t.User.AvatarUrl
+	}
+	if thumbnail == "" {
+		thumbnail = defaultThumbUrl
+	} else {
+		thumbnail = strings.Replace(thumbnail, "-large.", "-t500x500.", 1)
+	}
+
+	return &player.TrackData{
+		Name:      t.Title,
+		Url:       t.PermalinkUrl,
+		PlayQuery: "soundcloud:" + strconv.FormatInt(t.Id, 10),
+		Thumbnail: thumbnail,
+		Duration:  durationpb.New(time.Duration(t.Duration) * time.Millisecond),
+	}, true
+}
+
+type scTranscoding struct {
+	Url     string `json:"url"`
+	Snipped bool   `json:"snipped"`
+	Format  struct {
+		// progressive or hls
+		Protocol string `json:"protocol"`
+		MimeType string `json:"mime_type"`
+	} `json:"format"`
+}
+
+type scMedia struct {
+	Transcodings []scTranscoding `json:"transcodings"`
+}
+
+// filter prefers progressive streams, since they are a single request, and
+// mpeg over other codecs, since its hls segments can simply be concatenated.
+func (m *scMedia) filter() *scTranscoding {
+	find, findScore := -1, 0
+	for i, t := range m.Transcodings {
+		if t.Snipped || t.Url == "" {
+			continue
+		}
+
+		score := 1
+		if t.Format.Protocol == "progressive" {
+			score += 2
+		} else if t.Format.Protocol != "hls" {
+			continue
+		}
+		if strings.Contains(t.Format.MimeType, "mpeg") {
+			score++
+		}
+
+		if score > findScore {
+			find, findScore = i, score
+		}
+	}
+
+	if find == -1 {
+		return nil
+	}
+	return &m.Transcodings[find]
+}
+
+var _ io.ReadCloser = &hlsReader{}
+
+// hlsReader concatenates all the segments of a hls media playlist.
+type hlsReader struct {
+	hc       *http.Client
+	segments []string
+	current  io.ReadCloser
+	closed   bool
+	mu       sync.Mutex
+}
+
+func newHlsReader(hc *http.Client, playlistUrl string) (*hlsReader, error) {
+	res, err := hc.Get(playlistUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Unexpected("hls playlist: response status: " + res.Status)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	segments, err := parseHlsPlaylist(playlistUrl, string(body))
+	if err != nil {
+		return nil, err
+	}
+
+	return &hlsReader{hc: hc, segments: segments}, nil
+}
+
+func parseHlsPlaylist(playlistUrl, body string) ([]string, error) {
+	base, err := url.Parse(playlistUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := []string{}
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+
+		// The initialization section must be read before the segments
+		if mapUri, ok := strings.CutPrefix(line, `#EXT-X-MAP:URI="`); ok {
+			line, _, _ = strings.Cut(mapUri, `"`)
+		} else if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		u, err := base.Parse(line)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, u.String())
+	}
+
+	if len(segments) == 0 {
+		return nil, errors.Unexpected("hls playlist: no segments")
+	}
+
+	return segments, nil
+}
+
+// Read implements io.ReadCloser.
+func (r *hlsReader) Read(p []byte) (int, error) {
+	for {
+		r.mu.Lock()
+		if r.closed {
+			r.mu.Unlock()
+			return 0, io.ErrClosedPipe
+		}
+
+		if r.current == nil {
+			if len(r.segments) == 0 {
+				r.mu.Unlock()
+				return 0, io.EOF
+			}
+
+			seg := r.segments[0]
+			r.segments = r.segments[1:]
+			r.mu.Unlock()
+
+			res, err := r.hc.Get(seg)
+			if err != nil {
+				return 0, err
+			}
+			if res.StatusCode != http.StatusOK {
+				res.Body.Close()
+				return 0, errors.Unexpected(
+					"hls segment: response status: " + res.Status,
+				)
+			}
+
+			r.mu.Lock()
+			if r.closed {
+				r.mu.Unlock()
+				res.Body.Close()
+				return 0, io.ErrClosedPipe
+			}
+			r.current = res.Body
+		}
+		current := r.current
+		r.mu.Unlock()
+
+		n, err := current.Read(p)
+		if err == io.EOF {
+			current.Close()
+			r.mu.Lock()
+			r.current = nil
+			r.mu.Unlock()
+
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+
+		return n, err
+	}
+}
+
+// Close implements io.ReadCloser.
+func (r *hlsReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	if r.current != nil {
+		err := r.current.Close()
+		r.current = nil
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package platform_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zanz1n/duvua/internal/player/errcodes"
+	"github.com/zanz1n/duvua/internal/player/platform"
+)
+
+const scTestClientId = "0123456789abcdefABCDEF0123456789"
+
+func scTestTrack(id int) map[string]any {
+	return map[string]any{
+		"id":            id,
+		"title":         fmt.Sprintf("Track %d", id),
+		"permalink_url": fmt.Sprintf("https://soundcloud.com/artist/track-%d", id),
+		"artwork_url":   fmt.Sprintf("https://i1.sndcdn.com/artworks-%d-large.jpg", id),
+		"duration":      180000,
+		"policy":        "ALLOW",
+	}
+}
+
+func newSoundCloudStandIn(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+
+	writeJson := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w,
+			`<html><script crossorigin src="%s/assets/0.js"></script>`+
+				`<script crossorigin src="%s/assets/1.js"></script></html>`,
+			srv.URL, srv.URL,
+		)
+	})
+	mux.HandleFunc("GET /assets/0.js", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `var a = 1;`)
+	})
+	mux.HandleFunc("GET /assets/1.js", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `({client_id:"%s",env:"production"})`, scTestClientId)
+	})
+
+	api := http.NewServeMux()
+	mux.Handle("/api/", http.StripPrefix("/api", http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("client_id") != scTestClientId {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			api.ServeHTTP(w, r)
+		},
+	)))
+
+	api.HandleFunc("GET /resolve", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("url") {
+		case "https://soundcloud.com/artist/track-1":
+			res := scTestTrack(1)
+			res["kind"] = "track"
+			writeJson(w, res)
+
+		case "https://soundcloud.com/artist/sets/album":
+			writeJson(w, map[string]any{
+				"kind": "playlist",
+				"id":   99,
+				"tracks": []any{
+					scTestTrack(1),
+					scTestTrack(2),
+					map[string]any{"id": 3},
+					map[string]any{"id": 4},
+				},
+			})
+
+		case "https://soundcloud.com/artist":
+			writeJson(w, map[string]any{"kind": "user", "id": 7})
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	api.HandleFunc("GET /tracks", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "3,4", r.URL.Query().Get("ids"))
+		writeJson(w, []any{scTestTrack(3), scTestTrack(4)})
+	})
+
+	api.HandleFunc("GET /users/7/likes", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("offset") == "" {
+			writeJson(w, map[string]any{
+				"collection": []any{
+					map[string]any{"track": scTestTrack(5)},
+					map[string]any{"playlist": map[string]any{"id": 1}},
+				},
+				"next_href": srv.URL + "/api/users/7/likes?offset=1",
+			})
+		} else {
+			writeJson(w, map[string]any{
+				"collection": []any{
+					map[string]any{"track": scTestTrack(6)},
+				},
+			})
+		}
+	})
+
+	api.HandleFunc("GET /search/tracks", func(w http.ResponseWriter, r *http.Request) {
+		snip := scTestTrack(8)
+		snip["policy"] = "SNIP"
+
+		writeJson(w, map[string]any{
+			"collection": []any{snip, scTestTrack(9)},
+		})
+	})
+
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestSoundCloud(srv *httptest.Server, clientId string) *platform.SoundCloud {
+	return platform.NewSoundCloud(platform.SoundCloudConfig{
+		Client:   srv.Client(),
+		ClientId: clientId,
+		SiteUrl:  srv.URL,
+		ApiUrl:   srv.URL + "/api",
+	})
+}
+
+func TestSoundCloudSearchTrackUrl(t *testing.T) {
+	srv := newSoundCloudStandIn(t)
+	// An expired client id must be discovered again
+	sc := newTestSoundCloud(srv, "expired")
+
+	tracks, skipped, err := sc.SearchUrl("https://m.soundcloud.com/artist/track-1?si=abc")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, skipped)
+	assert.Len(t, tracks, 1)
+
+	track := tracks[0]
+	assert.Equal(t, "Track 1", track.Name)
+	assert.Equal(t, "soundcloud:1", track.PlayQuery)
+	assert.Equal(t, "https://i1.sndcdn.com/artworks-1-t500x500.jpg", track.Thumbnail)
+	assert.Equal(t, 3*time.Minute, track.Duration.AsDuration())
+}
+
+func TestSoundCloudSearchPlaylistUrl(t *testing.T) {
+	sc := newTestSoundCloud(newSoundCloudStandIn(t), "")
+
+	tracks, skipped, err := sc.SearchUrl("https://soundcloud.com/artist/sets/album")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, skipped)
+
+	queries := []string{}
+	for _, track := range tracks {
+		queries = append(queries, track.PlayQuery)
+	}
+	assert.Equal(t, []string{
+		"soundcloud:1",
+		"soundcloud:2",
+		"soundcloud:3",
+		"soundcloud:4",
+	}, queries)
+}
+
+func TestSoundCloudSearchLikesUrl(t *testing.T) {
+	sc := newTestSoundCloud(newSoundCloudStandIn(t), "")
+
+	tracks, _, err := sc.SearchUrl("https://soundcloud.com/artist/likes")
+	assert.Nil(t, err)
+	assert.Len(t, tracks, 2)
+	assert.Equal(t, "soundcloud:5", tracks[0].PlayQuery)
+	assert.Equal(t, "soundcloud:6", tracks[1].PlayQuery)
+}
+
+func TestSoundCloudSearchString(t *testing.T) {
+	sc := newTestSoundCloud(newSoundCloudStandIn(t), "")
+
+	track, err := sc.SearchString("some track")
+	assert.Nil(t, err)
+	assert.Equal(t, "soundcloud:9", track.PlayQuery, "Previews must be skipped")
+}
+
+func TestSoundCloudSearchNotFound(t *testing.T) {
+	sc := newTestSoundCloud(newSoundCloudStandIn(t), "")
+
+	_, _, err := sc.SearchUrl("https://soundcloud.com/artist/unknown")
+	assert.Equal(t, errcodes.ErrTrackSearchFailed, err)
+}
+
+func TestSoundCloudHlsPlaylist(t *testing.T) {
+	segments, err := platform.ParseHlsPlaylist(
+		"https://cf-hls-media.sndcdn.com/playlist/abc.128.mp3/playlist.m3u8",
+		strings.Join([]string{
+			"#EXTM3U",
+			"#EXT-X-VERSION:6",
+			`#EXT-X-MAP:URI="init.mp4"`,
+			"#EXTINF:1.985,",
+			"https://cf-hls-media.sndcdn.com/media/0/1/abc.128.mp3",
+			"#EXTINF:9.952,",
+			"segment-2.mp3",
+			"#EXT-X-ENDLIST",
+		}, "\n"),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"https://cf-hls-media.sndcdn.com/playlist/abc.128.mp3/init.mp4",
+		"https://cf-hls-media.sndcdn.com/media/0/1/abc.128.mp3",
+		"https://cf-hls-media.sndcdn.com/playlist/abc.128.mp3/segment-2.mp3",
+	}, segments)
+}
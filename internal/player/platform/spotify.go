@@ -5,7 +5,6 @@ import (
 	"log/slog"
 	"net/url"
 	"strings"
-
 	"sync/atomic"
 	"time"
 
@@ -15,6 +14,7 @@ import (
 	"github.com/zmb3/spotify/v2"
 	spotifyauth "github.com/zmb3/spotify/v2/auth"
 	"golang.org/x/oauth2/clientcredentials"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 var _ Platform = &Spotify{}
@@ -23,12 +23,19 @@ type Spotify struct {
 	c  atomic.Pointer[spotify.Client]
 	yt *Youtube
 
+	// The market used to check if the tracks are playable
+	market string
+
+	// Youtube videos matched to spotify tracks, by spotify track id
+	matches ytMatchCache
+
 	cfg clientcredentials.Config
 }
 
-func NewSpotify(clientId, clientSecret string, yt *Youtube) (*Spotify, error) {
+func NewSpotify(clientId, clientSecret, market string, yt *Youtube) (*Spotify, error) {
 	s := &Spotify{
-		yt: yt,
+		yt:     yt,
+		market: market,
 		cfg: clientcredentials.Config{
 			ClientID:     clientId,
 			ClientSecret: clientSecret,
@@ -43,6 +50,15 @@ func NewSpotify(clientId, clientSecret string, yt *Youtube) (*Spotify, error) {
 	return s, nil
 }
 
+// Info implements Platform.
+func (s *Spotify) Info() PlatformInfo {
+	return PlatformInfo{
+		Name:        "spotify",
+		QueryPrefix: "spotify",
+		Hosts:       []string{"spotify.com"},
+	}
+}
+
 // SearchString implements Platform.
 func (s *Spotify) SearchString(query string) (*player.TrackData, error) {
 	res, err := authRetry(s, func(c *spotify.Client) (*spotify.SearchResult, error) {
@@ -73,37 +89,45 @@ func (s *Spotify) SearchString(query string) (*player.TrackData, error) {
 }
 
 // SearchUrl implements Platform.
-func (s *Spotify) SearchUrl(uri string) ([]*player.TrackData, error) {
-
+//
+// Tracks of playlists, albums and artists are not converted to youtube
+// videos when searched, since it takes a long time for large collections.
+// They are matched by Resolve right before being played.
+func (s *Spotify) SearchUrl(uri string) ([]*player.TrackData, int, error) {
 	u, err := url.Parse(uri)
 	if err != nil {
-		return nil, errcodes.ErrTrackSearchFailed
+		return nil, 0, errcodes.ErrTrackSearchFailed
 	}
 
 	pathS := strings.Split(u.Path, "/")
 	if 3 > len(pathS) {
-		return nil, errcodes.ErrTrackSearchFailed
+		return nil, 0, errcodes.ErrTrackSearchFailed
 	}
 
-	id := pathS[len(pathS)-1]
+	id := spotify.ID(pathS[len(pathS)-1])
 	pathS = pathS[:len(pathS)-1]
 
-	switch pathS[1] {
+	var (
+		collection []spotify.FullTrack
+		skipped    int
+	)
+
+	start := time.Now()
+	switch pathS[len(pathS)-1] {
 	case "track":
 		track, err := authRetry(s, func(c *spotify.Client) (*spotify.FullTrack, error) {
 			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 			defer cancel()
 
-			return c.GetTrack(ctx, spotify.ID(id))
+			return c.GetTrack(ctx, id)
 		})
 
 		if err != nil {
-			return nil, errors.Unexpected(
+			return nil, 0, errors.Unexpected(
 				"spotify search track: " + err.Error(),
 			)
 		}
 
-		start := time.Now()
 		data, err := s.ytConvert(track)
 		if err != nil {
 			slog.Warn(
@@ -111,22 +135,199 @@ func (s *Spotify) SearchUrl(uri string) ([]*player.TrackData, error) {
 				"took", time.Since(start),
 				"error", err,
 			)
-			return nil, err
+			return nil, 0, err
 		}
 
-		return []*player.TrackData{data}, nil
+		return []*player.TrackData{data}, 0, nil
 
-	case "playlist", "album":
-		return nil, errcodes.ErrSpotifyPlaylistsNotSupported
+	case "playlist":
+		collection, skipped, err = s.searchPlaylist(id)
+
+	case "album":
+		collection, skipped, err = s.searchAlbum(id)
+
+	case "artist":
+		collection, skipped, err = s.searchArtist(id)
 
 	default:
-		return nil, errcodes.ErrTrackSearchFailed
+		return nil, 0, errcodes.ErrTrackSearchFailed
+	}
+
+	if err != nil {
+		return nil, 0, errors.Unexpected(
+			"spotify search collection: " + err.Error(),
+		)
+	}
+
+	tracks := make([]*player.TrackData, len(collection))
+	for idx := range collection {
+		tracks[idx] = spotifyTrackData(&collection[idx])
+	}
+
+	slog.Info(
+		"Spotify: Loaded collection",
+		"id", id,
+		"kind", pathS[len(pathS)-1],
+		"tracks", len(tracks),
+		"skipped", skipped,
+		"took", time.Since(start).Round(time.Millisecond),
+	)
+
+	if len(tracks) == 0 {
+		return nil, 0, errcodes.ErrTrackSearchFailed
 	}
+
+	return tracks, skipped, nil
 }
 
 // Fetch implements Platform.
-func (s *Spotify) Fetch(query string) (Streamer, error) {
-	panic("must not be used")
+// The spotify track is converted to a youtube video, which is then fetched.
+func (s *Spotify) Fetch(id string, opts FetchOptions) (Streamer, error) {
+	data, err := s.Resolve(id)
+	if err != nil {
+		return nil, err
+	}
+
+	_, videoId, _ := strings.Cut(data.PlayQuery, ":")
+	return s.yt.Fetch(videoId, opts)
+}
+
+// Resolve implements Resolver.
+// Returns the youtube video matched to the spotify track.
+func (s *Spotify) Resolve(id string) (*player.TrackData, error) {
+	// The cached matches do not need the spotify track
+	if data, ok := s.matches.get(spotify.ID(id)); ok {
+		return data, nil
+	}
+
+	track, err := authRetry(s, func(c *spotify.Client) (*spotify.FullTrack, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		return c.GetTrack(ctx, spotify.ID(id))
+	})
+	if err != nil {
+		return nil, errors.Unexpected("fetch spotify track: " + err.Error())
+	}
+
+	return s.ytConvert(track)
+}
+
+func (s *Spotify) searchPlaylist(id spotify.ID) ([]spotify.FullTrack, int, error) {
+	page, err := authRetry(s, func(c *spotify.Client) (*spotify.PlaylistItemPage, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		return c.GetPlaylistItems(ctx, id, spotify.Limit(100), spotify.Market(s.market))
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tracks, skipped := []spotify.FullTrack{}, 0
+	for {
+		for _, item := range page.Items {
+			// Podcast episodes and local files can not be played
+			track := item.Track.Track
+			if item.IsLocal || track == nil || (track.IsPlayable != nil && !*track.IsPlayable) {
+				skipped++
+				continue
+			}
+
+			if validSpotifyTrack(&track.SimpleTrack) {
+				tracks = append(tracks, *track)
+			} else {
+				skipped++
+			}
+		}
+
+		if len(tracks) >= maxCollectionTracks || page.Next == "" {
+			break
+		}
+
+		err = s.nextPage(func(ctx context.Context, c *spotify.Client) error {
+			return c.NextPage(ctx, page)
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return capCollection(tracks), skipped, nil
+}
+
+func (s *Spotify) searchAlbum(id spotify.ID) ([]spotify.FullTrack, int, error) {
+	album, err := authRetry(s, func(c *spotify.Client) (*spotify.FullAlbum, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		return c.GetAlbum(ctx, id, spotify.Market(s.market))
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	page := &album.Tracks
+	tracks, skipped := []spotify.FullTrack{}, 0
+	for {
+		for _, track := range page.Tracks {
+			if validSpotifyTrack(&track) {
+				tracks = append(tracks, spotify.FullTrack{
+					SimpleTrack: track,
+					Album:       album.SimpleAlbum,
+				})
+			} else {
+				skipped++
+			}
+		}
+
+		if len(tracks) >= maxCollectionTracks || page.Next == "" {
+			break
+		}
+
+		err = s.nextPage(func(ctx context.Context, c *spotify.Client) error {
+			return c.NextPage(ctx, page)
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return capCollection(tracks), skipped, nil
+}
+
+func (s *Spotify) searchArtist(id spotify.ID) ([]spotify.FullTrack, int, error) {
+	top, err := authRetry(s, func(c *spotify.Client) (*[]spotify.FullTrack, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		tracks, err := c.GetArtistsTopTracks(ctx, id, s.market)
+		return &tracks, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tracks, skipped := []spotify.FullTrack{}, 0
+	for _, track := range *top {
+		if validSpotifyTrack(&track.SimpleTrack) {
+			tracks = append(tracks, track)
+		} else {
+			skipped++
+		}
+	}
+
+	return capCollection(tracks), skipped, nil
+}
+
+func (s *Spotify) nextPage(f func(ctx context.Context, c *spotify.Client) error) error {
+	_, err := authRetry(s, func(c *spotify.Client) (*struct{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		return nil, f(ctx, c)
+	})
+	return err
 }
 
 func (s *Spotify) rollAuth() error {
@@ -174,16 +375,39 @@ func authRetry[T any](s *Spotify, f func(c *spotify.Client) (*T, error)) (*T, er
 	return t, err
 }
 
-func (s *Spotify) ytConvert(track *spotify.FullTrack) (*player.TrackData, error) {
-	ytSearchQuery := ""
+func isExpiredTokenErr(err error) bool {
+	return strings.Contains(err.Error(), "token expired")
+}
+
+func validSpotifyTrack(track *spotify.SimpleTrack) bool {
+	return track.ID != "" && track.Name != "" && track.Duration != 0
+}
+
+func spotifyTrackData(track *spotify.FullTrack) *player.TrackData {
+	name := track.Name
 	if len(track.Artists) > 0 {
-		ytSearchQuery += track.Artists[0].Name + " "
+		name = track.Artists[0].Name + " - " + name
 	}
-	ytSearchQuery += track.Name
 
-	return s.yt.SearchString(ytSearchQuery)
+	thumbnail := defaultThumbUrl
+	if len(track.Album.Images) > 0 {
+		thumbnail = track.Album.Images[0].URL
+	}
+
+	return &player.TrackData{
+		Name:      name,
+		Url:       "https://open.spotify.com/track/" + track.ID.String(),
+		PlayQuery: "spotify:" + track.ID.String(),
+		Thumbnail: thumbnail,
+		Duration: durationpb.New(
+			time.Duration(track.Duration) * time.Millisecond,
+		),
+	}
 }
 
-func isExpiredTokenErr(err error) bool {
-	return strings.Contains(err.Error(), "token expired")
+func capCollection(tracks []spotify.FullTrack) []spotify.FullTrack {
+	if len(tracks) > maxCollectionTracks {
+		return tracks[:maxCollectionTracks]
+	}
+	return tracks
 }
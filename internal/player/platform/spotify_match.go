@@ -0,0 +1,204 @@
+package platform
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/zanz1n/duvua/internal/player/errcodes"
+	"github.com/zanz1n/duvua/pkg/pb/player"
+	"github.com/zmb3/spotify/v2"
+)
+
+// The number of youtube search results scored when converting a spotify track
+const ytMatchCandidates = 8
+
+// The max number of spotify to youtube mappings kept in memory
+const ytMatchCacheSize = 4096
+
+// Words that usually mean that the video is not the original recording of
+// the track. They are penalized unless present in the spotify track or album
+// name.
+var ytMatchPenalizedWords = []string{
+	"live", "cover", "remix", "karaoke", "instrumental", "acoustic",
+	"nightcore", "slowed", "sped up", "reverb", "8d", "bass boosted",
+	"hour", "hours", "loop", "extended", "reaction", "tutorial",
+}
+
+type ytMatchCache struct {
+	m  map[spotify.ID]*player.TrackData
+	mu sync.Mutex
+}
+
+func (c *ytMatchCache) get(id spotify.ID) (*player.TrackData, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, ok := c.m[id]
+	return data, ok
+}
+
+func (c *ytMatchCache) set(id spotify.ID, data *player.TrackData) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.m == nil {
+		c.m = make(map[spotify.ID]*player.TrackData)
+	}
+
+	// Drops arbitrary entries, no need for anything fancier, since mappings
+	// are cheap to be found again.
+	for id := range c.m {
+		if len(c.m) < ytMatchCacheSize {
+			break
+		}
+		delete(c.m, id)
+	}
+
+	c.m[id] = data
+}
+
+// ytConvert finds the youtube video that best matches the spotify track.
+// The chosen video is cached by the spotify track id.
+func (s *Spotify) ytConvert(track *spotify.FullTrack) (*player.TrackData, error) {
+	if data, ok := s.matches.get(track.ID); ok {
+		return data, nil
+	}
+
+	query := track.Name
+	if len(track.Artists) > 0 {
+		query = track.Artists[0].Name + " " + query
+	}
+
+	start := time.Now()
+	items, err := s.yt.search(query, ytMatchCandidates, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, errcodes.ErrTrackSearchFailed
+	}
+
+	best, bestScore := 0, ytMatchScore(track, &items[0])
+	for i := 1; i < len(items); i++ {
+		if score := ytMatchScore(track, &items[i]); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+
+	data := items[best].Data
+	if track.ID != "" {
+		s.matches.set(track.ID, data)
+	}
+
+	slog.Debug(
+		"Spotify: Matched track on youtube",
+		"spotify_id", track.ID,
+		"youtube_query", data.PlayQuery,
+		"position", best,
+		"score", bestScore,
+		"candidates", len(items),
+		"took", time.Since(start).Round(time.Millisecond),
+	)
+
+	return data, nil
+}
+
+// ytMatchScore rates how likely the youtube video is the same recording of
+// the spotify track. Higher is better.
+func ytMatchScore(track *spotify.FullTrack, item *ytSearchItem) float64 {
+	title := ytMatchNormalize(item.Data.Name)
+	channel := ytMatchNormalize(item.Channel)
+	source := ytMatchNormalize(track.Name + " " + track.Album.Name)
+
+	score := 0.0
+
+	// Duration
+	target := time.Duration(track.Duration) * time.Millisecond
+	diff := item.Data.Duration.AsDuration() - target
+	if diff < 0 {
+		diff = -diff
+	}
+
+	switch {
+	case target == 0:
+	case diff <= 2*time.Second:
+		score += 40
+	case diff <= 5*time.Second:
+		score += 30
+	case diff <= 15*time.Second:
+		score += 10
+	case diff > target/2:
+		score -= 60
+	default:
+		score -= min(diff.Seconds()/2, 50)
+	}
+
+	// Artists
+	for i, artist := range track.Artists {
+		name := ytMatchNormalize(artist.Name)
+		if name == "" {
+			continue
+		}
+		if ytMatchContains(title, name) || ytMatchContains(channel, name) {
+			if i == 0 {
+				score += 20
+			} else {
+				score += 5
+			}
+		} else if i == 0 {
+			score -= 10
+		}
+	}
+
+	// Title keywords
+	words := strings.Fields(ytMatchNormalize(track.Name))
+	if len(words) > 0 {
+		found := 0
+		for _, word := range words {
+			if ytMatchContains(title, word) {
+				found++
+			}
+		}
+		score += 30 * float64(found) / float64(len(words))
+	}
+
+	// Official uploads
+	if strings.HasSuffix(channel, " topic") {
+		score += 15
+	}
+	if strings.Contains(title, "official audio") {
+		score += 10
+	} else if strings.Contains(title, "official") {
+		score += 5
+	}
+
+	for _, word := range ytMatchPenalizedWords {
+		if ytMatchContains(title, word) && !ytMatchContains(source, word) {
+			score -= 25
+		}
+	}
+
+	return score
+}
+
+// ytMatchNormalize lowercases s and replaces every punctuation by spaces.
+func ytMatchNormalize(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, s)
+
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// ytMatchContains reports whether the normalized text contains the
+// normalized words as a whole.
+func ytMatchContains(text, words string) bool {
+	return strings.Contains(" "+text+" ", " "+words+" ")
+}
@@ -0,0 +1,62 @@
+package platform_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zanz1n/duvua/internal/player/platform"
+	"github.com/zanz1n/duvua/pkg/pb/player"
+	"github.com/zmb3/spotify/v2"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func ytTestItem(name, channel string, duration time.Duration) *platform.YtSearchItem {
+	return &platform.YtSearchItem{
+		Data: &player.TrackData{
+			Name:     name,
+			Duration: durationpb.New(duration),
+		},
+		Channel: channel,
+	}
+}
+
+func TestYtMatchScore(t *testing.T) {
+	track := &spotify.FullTrack{
+		SimpleTrack: spotify.SimpleTrack{
+			Name: "Bohemian Rhapsody",
+			Artists: []spotify.SimpleArtist{
+				{Name: "Queen"},
+			},
+			Duration: 355000,
+		},
+	}
+
+	official := ytTestItem("Bohemian Rhapsody (Remastered 2011)", "Queen - Topic", 5*time.Minute+55*time.Second)
+	video := ytTestItem("Queen – Bohemian Rhapsody (Official Video Remastered)", "Queen Official", 5*time.Minute+59*time.Second)
+	live := ytTestItem("Queen - Bohemian Rhapsody (Live at Wembley)", "Queen Official", 6*time.Minute)
+	cover := ytTestItem("Bohemian Rhapsody - Queen (cover)", "Some Band", 5*time.Minute+50*time.Second)
+	loop := ytTestItem("Queen - Bohemian Rhapsody 10 hours", "Loops", 10*time.Hour)
+
+	ranked := [][]*platform.YtSearchItem{{official}, {video}, {live, cover}, {loop}}
+	for i := 1; i < len(ranked); i++ {
+		for _, better := range ranked[i-1] {
+			for _, worse := range ranked[i] {
+				assert.Greater(t,
+					platform.YtMatchScore(track, better),
+					platform.YtMatchScore(track, worse),
+					"%q must score higher than %q",
+					better.Data.Name, worse.Data.Name,
+				)
+			}
+		}
+	}
+
+	// Live versions are not penalized if the spotify track is live as well
+	track.Name = "Bohemian Rhapsody - Live at Wembley"
+	track.Duration = 360000
+	assert.Greater(t,
+		platform.YtMatchScore(track, live),
+		platform.YtMatchScore(track, official),
+	)
+}
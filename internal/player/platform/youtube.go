@@ -14,24 +14,46 @@ import (
 	"github.com/kkdai/youtube/v2"
 	"github.com/zanz1n/duvua/internal/errors"
 	"github.com/zanz1n/duvua/internal/player/errcodes"
+	"github.com/zanz1n/duvua/internal/utils/ttlcache"
 	"github.com/zanz1n/duvua/pkg/pb/player"
 	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 var _ Platform = &Youtube{}
 
+// The video metadata contains stream urls that expire after some hours
+const (
+	ytVideoCacheSize = 512
+	ytVideoCacheTTL  = 30 * time.Minute
+)
+
 type Youtube struct {
 	c        *youtube.Client
 	hc       *http.Client
 	validate *validator.Validate
+
+	videos *ttlcache.Cache[*youtube.Video]
 }
 
 // if client == nil, it will be defaulted to http.DefaultClient
-func NewYoutube(client *http.Client, maxDlRoutines int) *Youtube {
+//
+// The requests are sent through the proxies in turns. The proxies, or the
+// direct connection if there are none, are not used for a while when youtube
+// blocks them.
+func NewYoutube(client *http.Client, maxDlRoutines int, proxies ...*url.URL) *Youtube {
 	if client == nil {
 		client = http.DefaultClient
 	}
 
+	base, _ := client.Transport.(*http.Transport)
+	if client.Transport == nil || base != nil {
+		c := *client
+		c.Transport = newProxyRotator(base, proxies)
+		client = &c
+	} else if len(proxies) > 0 {
+		slog.Warn("Youtube: Proxies are not supported by the custom http transport")
+	}
+
 	return &Youtube{
 		c: &youtube.Client{
 			HTTPClient:  client,
@@ -39,6 +61,22 @@ func NewYoutube(client *http.Client, maxDlRoutines int) *Youtube {
 		},
 		hc:       client,
 		validate: validator.New(),
+		videos: ttlcache.New[*youtube.Video](ttlcache.Config{
+			Name:        "youtube_videos",
+			Size:        ytVideoCacheSize,
+			TTL:         ytVideoCacheTTL,
+			NegativeTTL: negativeCacheTTL,
+			CacheErr:    IsPermanentErr,
+		}),
+	}
+}
+
+// Info implements Platform.
+func (y *Youtube) Info() PlatformInfo {
+	return PlatformInfo{
+		Name:        "youtube",
+		QueryPrefix: "youtube",
+		Hosts:       []string{"youtube.com", "youtu.be"},
 	}
 }
 
@@ -47,54 +85,24 @@ func NewYoutube(client *http.Client, maxDlRoutines int) *Youtube {
 //
 // The data is extracted from html youtube responses.
 func (y *Youtube) SearchString(s string) (*player.TrackData, error) {
-	start := time.Now()
-	defer func() {
-		slog.Debug(
-			"Youtube string search: finished search",
-			"took", time.Since(start).Round(time.Microsecond),
-		)
-	}()
-
-	searchUrl := "https://www.youtube.com/results?search_query=" +
-		url.QueryEscape(s)
-
-	req, err := http.NewRequest(http.MethodGet, searchUrl, nil)
-	if err != nil {
-		return nil, errors.Unexpected("youtube search: request: " + err.Error())
-	}
-
-	req.Header.Add("Accept-Language", "en")
-
-	res, err := y.c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, errors.Unexpected("youtube search: request: " + err.Error())
-	}
-
-	if res.StatusCode != 200 {
-		return nil, errors.Unexpected(
-			"youtube search: response status: " + res.Status,
-		)
-	}
-	defer res.Body.Close()
-
-	data, err := ytParseSearchBody(res.Body, 1)
+	items, err := y.search(s, 1, false)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(data) == 0 {
+	if len(items) == 0 {
 		return nil, errcodes.ErrTrackSearchFailed
 	}
 
-	return data[0], nil
+	return items[0].Data, nil
 }
 
 // SearchUrl implements Platform.
-func (y *Youtube) SearchUrl(url string) ([]*player.TrackData, error) {
+func (y *Youtube) SearchUrl(url string) ([]*player.TrackData, int, error) {
 	if !strings.Contains(url, "&list") && !strings.Contains(url, "?list") {
-		video, err := y.c.GetVideo(url)
+		video, err := y.getVideo(url)
 		if err != nil {
-			return nil, errcodes.ErrTrackSearchFailed
+			return nil, 0, errcodes.ErrTrackSearchFailed
 		}
 
 		thumbnailUrl := defaultThumbUrl
@@ -102,17 +110,29 @@ func (y *Youtube) SearchUrl(url string) ([]*player.TrackData, error) {
 			thumbnailUrl = thumbnail.URL
 		}
 
-		return []*player.TrackData{{
+		data := &player.TrackData{
 			Name:      video.Title,
 			Url:       "https://youtu.be/" + video.ID,
 			PlayQuery: "youtube:" + video.ID,
 			Thumbnail: thumbnailUrl,
 			Duration:  durationpb.New(video.Duration),
-		}}, nil
+			Live:      ytIsLive(video),
+			Author:    video.Author,
+		}
+
+		// Livestreams always start at the live edge
+		if !data.Live {
+			if start := ytStartTime(url); start > 0 && start < video.Duration {
+				data.Start = durationpb.New(start)
+			}
+			data.Chapters = ytChapters(video.Description, video.Duration)
+		}
+
+		return []*player.TrackData{data}, 0, nil
 	} else {
 		playlist, err := y.c.GetPlaylist(url)
 		if err != nil {
-			return nil, errcodes.ErrTrackSearchFailed
+			return nil, 0, errcodes.ErrTrackSearchFailed
 		}
 
 		tracks := make([]*player.TrackData, len(playlist.Videos))
@@ -128,26 +148,38 @@ func (y *Youtube) SearchUrl(url string) ([]*player.TrackData, error) {
 				PlayQuery: "youtube:" + video.ID,
 				Thumbnail: thumbnailUrl,
 				Duration:  durationpb.New(video.Duration),
+				Author:    video.Author,
 			}
 		}
 
 		if len(tracks) == 0 {
-			return nil, errcodes.ErrTrackSearchFailed
+			return nil, 0, errcodes.ErrTrackSearchFailed
 		}
 
-		return tracks, nil
+		return tracks, 0, nil
 	}
 }
 
 // Fetch implements Platform.
-func (y *Youtube) Fetch(id string) (Streamer, error) {
-	v, err := y.c.GetVideo("https://www.youtube.com/watch?v=" + id)
+func (y *Youtube) Fetch(id string, opts FetchOptions) (Streamer, error) {
+	v, err := y.getVideo(id)
 	if err != nil {
+		if IsPermanentErr(err) {
+			return nil, err
+		}
 		return nil, errors.Unexpected(
 			"fetch youtube video: " + err.Error(),
 		)
 	}
 
+	if ytIsLive(v) {
+		slog.Debug("Youtube: Created livestream streamer", "video_id", v.ID)
+
+		return newLiveStreamer(opts, func(func(string)) (io.ReadCloser, error) {
+			return newHlsLiveReader(y.hc, v.HLSManifestURL)
+		})
+	}
+
 	format := filterYtVideos(v.Formats)
 	if format == nil {
 		return nil, errcodes.ErrTrackSearchFailed
@@ -155,6 +187,9 @@ func (y *Youtube) Fetch(id string) (Streamer, error) {
 
 	r, _, err := y.c.GetStream(v, format)
 	if err != nil {
+		// The stream urls of the cached video may have expired
+		y.videos.Delete(v.ID)
+
 		slog.Warn("Youtube: Failed to get audio stream", "error", err)
 		return nil, errors.Unexpected(
 			"fetch youtube audio stream: " + err.Error(),
@@ -167,7 +202,52 @@ func (y *Youtube) Fetch(id string) (Streamer, error) {
 		"input_bitrate", format.Bitrate,
 	)
 
-	return newReaderStreamer(r)
+	r = &ytStreamReader{
+		ReadCloser: r,
+		onErr:      func() { y.videos.Delete(v.ID) },
+	}
+
+	return newReaderStreamer(r, opts)
+}
+
+// ytStreamReader calls onErr when the stream fails, so the cached video,
+// whose stream urls may have expired, is fetched again when the track is
+// resumed.
+type ytStreamReader struct {
+	io.ReadCloser
+	onErr func()
+}
+
+// Read implements io.Reader.
+func (r *ytStreamReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		r.onErr()
+	}
+	return n, err
+}
+
+// getVideo returns the metadata of the video, cached by the video id. The
+// returned video is shared and must not be modified.
+func (y *Youtube) getVideo(url string) (*youtube.Video, error) {
+	id, err := youtube.ExtractVideoID(url)
+	if err != nil {
+		return nil, errcodes.ErrTrackSearchInvalidUrl
+	}
+
+	return y.videos.Get(id, func() (*youtube.Video, error) {
+		v, err := y.c.GetVideo(id)
+		if err == youtube.ErrVideoPrivate || err == youtube.ErrNotPlayableInEmbed ||
+			err == youtube.ErrLoginRequired {
+			return nil, errcodes.ErrTrackSearchFailed
+		}
+		return v, err
+	})
+}
+
+// Livestreams have no duration and are only available through hls
+func ytIsLive(v *youtube.Video) bool {
+	return v.Duration == 0 && v.HLSManifestURL != ""
 }
 
 func filterYtVideos(formats []youtube.Format) *youtube.Format {
@@ -203,7 +283,72 @@ func filterYtThumbnails(thumbnails []youtube.Thumbnail) *youtube.Thumbnail {
 	return nil
 }
 
-func ytParseSearchBody(r io.Reader, limit int) ([]*player.TrackData, error) {
+// SearchMany returns up to `limit` videos and playlists found in the youtube
+// search.
+func (y *Youtube) SearchMany(s string, limit int) ([]*player.SearchResult, error) {
+	items, err := y.search(s, limit, true)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*player.SearchResult, len(items))
+	for i, item := range items {
+		result := &player.SearchResult{
+			Kind:      player.SearchResultKind_SearchResultVideo,
+			Name:      item.Data.Name,
+			Url:       item.Data.Url,
+			Channel:   item.Channel,
+			Thumbnail: item.Data.Thumbnail,
+			Duration:  item.Data.Duration,
+		}
+		if item.Playlist {
+			result.Kind = player.SearchResultKind_SearchResultPlaylist
+			result.VideoCount = int32(item.VideoCount)
+		}
+
+		results[i] = result
+	}
+
+	return results, nil
+}
+
+// search returns up to `limit` videos found in the youtube search. If
+// playlists is true, the found playlists are returned as well.
+func (y *Youtube) search(s string, limit int, playlists bool) ([]ytSearchItem, error) {
+	start := time.Now()
+	defer func() {
+		slog.Debug(
+			"Youtube string search: finished search",
+			"took", time.Since(start).Round(time.Microsecond),
+		)
+	}()
+
+	searchUrl := "https://www.youtube.com/results?search_query=" +
+		url.QueryEscape(s)
+
+	req, err := http.NewRequest(http.MethodGet, searchUrl, nil)
+	if err != nil {
+		return nil, errors.Unexpected("youtube search: request: " + err.Error())
+	}
+
+	req.Header.Add("Accept-Language", "en")
+
+	res, err := y.c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, errors.Unexpected("youtube search: request: " + err.Error())
+	}
+
+	if res.StatusCode != 200 {
+		return nil, errors.Unexpected(
+			"youtube search: response status: " + res.Status,
+		)
+	}
+	defer res.Body.Close()
+
+	return ytParseSearchBody(res.Body, limit, playlists)
+}
+
+func ytParseSearchBody(r io.Reader, limit int, playlists bool) ([]ytSearchItem, error) {
 	start := time.Now()
 	defer func() {
 		slog.Debug(
@@ -242,7 +387,7 @@ func ytParseSearchBody(r io.Reader, limit int) ([]*player.TrackData, error) {
 		)
 	}
 
-	parsed, err := data.Into(limit)
+	parsed, err := data.Into(limit, playlists)
 	if err != nil {
 		return nil, err
 	}
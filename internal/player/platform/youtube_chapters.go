@@ -0,0 +1,127 @@
+package platform
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zanz1n/duvua/pkg/pb/player"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Youtube only shows the chapters of a description with at least 3
+// timestamps, the first one at 0:00, each one lasting at least 10 seconds
+const (
+	ytMinChapters        = 3
+	ytMinChapterDuration = 10 * time.Second
+)
+
+var (
+	ytChapterRegex   = regexp.MustCompile(`(?:^|\s)\(?((?:\d{1,2}:)?\d{1,2}:\d{2})\)?(?:\s|$)`)
+	ytStartUnitRegex = regexp.MustCompile(`^(?:(\d+)h)?(?:(\d+)m)?(?:(\d+)s?)?$`)
+)
+
+// ytStartTime returns the start offset of youtube urls like
+// `youtu.be/id?t=95` or `youtube.com/watch?v=id&t=1m35s`.
+func ytStartTime(uri string) time.Duration {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return 0
+	}
+
+	q := u.Query()
+	v := q.Get("t")
+	if v == "" {
+		v = q.Get("start")
+	}
+	if v == "" {
+		// Some links are shared as `youtube.com/watch?v=id#t=95`
+		fragment, _ := url.ParseQuery(u.Fragment)
+		v = fragment.Get("t")
+	}
+
+	return parseYtTimestamp(v)
+}
+
+// parseYtTimestamp parses the `t` parameter of youtube urls, that can be both
+// `95` or `1m35s`.
+func parseYtTimestamp(v string) time.Duration {
+	m := ytStartUnitRegex.FindStringSubmatch(strings.ToLower(v))
+	if m == nil {
+		return 0
+	}
+
+	d := time.Duration(0)
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		if m[i+1] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+1])
+		if err != nil {
+			return 0
+		}
+		d += time.Duration(n) * unit
+	}
+	return d
+}
+
+// parseClock parses timestamps like `1:02:03` and `2:03`.
+func parseClock(v string) (time.Duration, bool) {
+	d := time.Duration(0)
+	for _, part := range strings.Split(v, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, false
+		}
+		d = d*60 + time.Duration(n)
+	}
+	return d * time.Second, true
+}
+
+// ytChapters extracts the chapters from the timestamps in the description of
+// a video, the same way youtube does. Returns nil if the description has no
+// valid chapters.
+func ytChapters(description string, duration time.Duration) []*player.Chapter {
+	chapters := []*player.Chapter{}
+
+	for _, line := range strings.Split(description, "\n") {
+		loc := ytChapterRegex.FindStringSubmatchIndex(line)
+		if loc == nil {
+			continue
+		}
+
+		start, ok := parseClock(line[loc[2]:loc[3]])
+		if !ok {
+			continue
+		}
+
+		title := strings.TrimSpace(line[:loc[0]] + " " + line[loc[1]:])
+		title = strings.TrimLeft(title, "-–—:|• ")
+		title = strings.TrimSpace(title)
+
+		if len(chapters) == 0 && start != 0 {
+			return nil
+		}
+		if len(chapters) > 0 {
+			last := chapters[len(chapters)-1].Start.AsDuration()
+			if start-last < ytMinChapterDuration {
+				return nil
+			}
+		}
+		if duration > 0 && start >= duration {
+			break
+		}
+
+		chapters = append(chapters, &player.Chapter{
+			Title: title,
+			Start: durationpb.New(start),
+		})
+	}
+
+	if len(chapters) < ytMinChapters {
+		return nil
+	}
+	return chapters
+}
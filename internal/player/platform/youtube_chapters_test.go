@@ -0,0 +1,66 @@
+package platform_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zanz1n/duvua/internal/player/platform"
+)
+
+func TestYtStartTime(t *testing.T) {
+	tests := map[string]time.Duration{
+		"https://youtu.be/dQw4w9WgXcQ?t=95":                         95 * time.Second,
+		"https://youtu.be/dQw4w9WgXcQ?t=95s":                        95 * time.Second,
+		"https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=1m35s":       95 * time.Second,
+		"https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=1h2m3s":      time.Hour + 2*time.Minute + 3*time.Second,
+		"https://www.youtube.com/embed/dQw4w9WgXcQ?start=30":        30 * time.Second,
+		"https://www.youtube.com/watch?v=dQw4w9WgXcQ#t=42":          42 * time.Second,
+		"https://www.youtube.com/watch?v=dQw4w9WgXcQ":               0,
+		"https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=invalid":     0,
+		"https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=1m35s&foo=1": 95 * time.Second,
+	}
+
+	for uri, expected := range tests {
+		assert.Equal(t, expected, platform.YtStartTime(uri), uri)
+	}
+}
+
+func TestYtChapters(t *testing.T) {
+	description := "Full album\n\n" +
+		"Tracklist:\n" +
+		"0:00 Intro\n" +
+		"1:30 - Second Song\n" +
+		"(4:05) Third Song\n" +
+		"Last Song 1:02:03\n" +
+		"\nFollow me on social media"
+
+	chapters := platform.YtChapters(description, 2*time.Hour)
+	if assert.Len(t, chapters, 4) {
+		titles := []string{"Intro", "Second Song", "Third Song", "Last Song"}
+		starts := []time.Duration{
+			0,
+			90 * time.Second,
+			245 * time.Second,
+			time.Hour + 2*time.Minute + 3*time.Second,
+		}
+
+		for i, chapter := range chapters {
+			assert.Equal(t, titles[i], chapter.Title)
+			assert.Equal(t, starts[i], chapter.Start.AsDuration())
+		}
+	}
+
+	assert.Nil(t,
+		platform.YtChapters("1:00 First\n2:00 Second\n3:00 Third", time.Hour),
+		"Chapters must start at 0:00",
+	)
+	assert.Nil(t,
+		platform.YtChapters("0:00 First\n0:05 Second\n3:00 Third", time.Hour),
+		"Chapters must last at least 10 seconds",
+	)
+	assert.Nil(t,
+		platform.YtChapters("0:00 First\n2:00 Second", time.Hour),
+		"At least 3 chapters are required",
+	)
+}
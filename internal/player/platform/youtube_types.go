@@ -36,7 +36,7 @@ type ytVideo struct {
 	// } `json:"viewCountText"`
 
 	// The name of the channel that published the video
-	// OwnerText ytRichText `json:"ownerText"`
+	OwnerText ytRichText `json:"ownerText"`
 
 	// // The inner text contains the shorted youtube description
 	// DetailedMetadataSnippets [1]struct {
@@ -88,6 +88,7 @@ func (v *ytVideo) Into() (*player.TrackData, bool) {
 		PlayQuery: "youtube:" + v.VideoId,
 		Thumbnail: thumbnail,
 		Duration:  durationpb.New(duration),
+		Author:    v.OwnerText.String(),
 	}, ok
 }
 
@@ -119,8 +120,44 @@ func (t ytRichText) String() (s string) {
 	return
 }
 
+type ytPlaylist struct {
+	PlaylistId string `json:"playlistId"`
+
+	// The thumbnails of the first videos of the playlist
+	Thumbnails []struct {
+		Thumbnails []ytThumbnail `json:"thumbnails"`
+	} `json:"thumbnails"`
+
+	Title struct {
+		SimpleText string `json:"simpleText"`
+	} `json:"title"`
+
+	// The number of videos of the playlist as a string
+	VideoCount string `json:"videoCount"`
+
+	// The name of the channel that created the playlist
+	ShortBylineText ytRichText `json:"shortBylineText"`
+}
+
+func (p *ytPlaylist) Into() (*player.TrackData, int, bool) {
+	thumbnail := defaultThumbUrl
+	if len(p.Thumbnails) > 0 && len(p.Thumbnails[0].Thumbnails) > 0 {
+		thumbnail = p.Thumbnails[0].Thumbnails[0].URL
+	}
+
+	count, _ := strconv.Atoi(strings.ReplaceAll(p.VideoCount, ",", ""))
+	ok := p.PlaylistId != "" && p.Title.SimpleText != "" && count > 0
+
+	return &player.TrackData{
+		Name:      p.Title.SimpleText,
+		Url:       "https://www.youtube.com/playlist?list=" + p.PlaylistId,
+		Thumbnail: thumbnail,
+	}, count, ok
+}
+
 type ytSearchResultContent struct {
-	VideoRenderer *ytVideo `json:"videoRenderer"`
+	VideoRenderer    *ytVideo    `json:"videoRenderer"`
+	PlaylistRenderer *ytPlaylist `json:"playlistRenderer"`
 }
 
 type ytJsonSearchResult struct {
@@ -140,14 +177,25 @@ type ytJsonSearchResult struct {
 	} `json:"contents"`
 }
 
-func (r *ytJsonSearchResult) Into(limit int) ([]*player.TrackData, error) {
+// ytSearchItem is a video or playlist found in the youtube search, along
+// with the name of the channel that published it.
+type ytSearchItem struct {
+	Data    *player.TrackData
+	Channel string
+
+	// Playlists have no play query nor duration
+	Playlist   bool
+	VideoCount int
+}
+
+func (r *ytJsonSearchResult) Into(limit int, playlists bool) ([]ytSearchItem, error) {
 	videosRaw := r.Contents.TwoColumnSearchResultsRenderer.PrimaryContents.
 		SectionListRenderer.Contents[0].ItemSectionRenderer.Contents
 
-	videos := []*player.TrackData{}
+	videos := []ytSearchItem{}
 
 	for i, content := range videosRaw {
-		if len(videos) > limit {
+		if len(videos) >= limit {
 			break
 		}
 
@@ -160,9 +208,22 @@ func (r *ytJsonSearchResult) Into(limit int) ([]*player.TrackData, error) {
 		}
 
 		if data.VideoRenderer != nil {
-			data, ok := data.VideoRenderer.Into()
-			if ok {
-				videos = append(videos, data)
+			video := data.VideoRenderer
+			if data, ok := video.Into(); ok {
+				videos = append(videos, ytSearchItem{
+					Data:    data,
+					Channel: video.OwnerText.String(),
+				})
+			}
+		} else if playlists && data.PlaylistRenderer != nil {
+			playlist := data.PlaylistRenderer
+			if data, count, ok := playlist.Into(); ok {
+				videos = append(videos, ytSearchItem{
+					Data:       data,
+					Channel:    playlist.ShortBylineText.String(),
+					Playlist:   true,
+					VideoCount: count,
+				})
 			}
 		}
 	}
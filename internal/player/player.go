@@ -1,12 +1,15 @@
 package player
 
 import (
+	"context"
 	"fmt"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/zanz1n/duvua/internal/player/errcodes"
 	"github.com/zanz1n/duvua/pkg/pb/player"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/durationpb"
@@ -21,6 +24,7 @@ const (
 	InterruptStop
 	InterruptPause
 	InterruptUnpause
+	InterruptSeek
 	// InterruptSetVolume
 	// InterruptSetSpeed
 )
@@ -38,6 +42,8 @@ func (i InterruptType) String() string {
 		return "pause"
 	case InterruptUnpause:
 		return "unpause"
+	case InterruptSeek:
+		return "seek"
 	// case InterruptSetVolume:
 	// 	return "set-volume"
 	// case InterruptSetSpeed:
@@ -47,18 +53,42 @@ func (i InterruptType) String() string {
 	}
 }
 
+// The settings used if the guild did not send its own
+var defaultPlayerConfig = &player.PlayerConfig{
+	Volume:         100,
+	IdleTimeout:    durationpb.New(10 * time.Second),
+	PauseTimeout:   durationpb.New(5 * time.Minute),
+	AnnounceTracks: true,
+	AlwaysOn:       false,
+	MaxQueueSize:   0,
+}
+
 type GuildPlayer struct {
 	GuildId     uint64
 	loop        atomic.Bool
 	textChannel atomic.Uint64
+	config      atomic.Pointer[player.PlayerConfig]
 
 	queue   []*player.Track
 	current *player.Track
 	paused  atomic.Bool
+	// The position requested by the last InterruptSeek
+	seekPosition atomic.Int64
+
+	// Guarded by mu, nil if not set
+	sleep *player.SleepTimer
+	// The deadline of the sleep timer in unix nanoseconds, zero if it is not
+	// in the time mode. Checked by the queue on every packet.
+	sleepDeadline atomic.Int64
+	// Whether the player was stopped by the sleep timer
+	slept atomic.Bool
 
 	mu sync.Mutex
 
-	Interrupt chan InterruptType
+	// The commands sent to the queue of the guild, it is never closed
+	mailbox chan command
+	// Closed when the queue of the guild ends
+	done chan struct{}
 }
 
 func newGuildPlayer(guildId uint64) *GuildPlayer {
@@ -70,7 +100,8 @@ func newGuildPlayer(guildId uint64) *GuildPlayer {
 		current:     nil,
 		paused:      atomic.Bool{},
 		mu:          sync.Mutex{},
-		Interrupt:   make(chan InterruptType),
+		mailbox:     make(chan command),
+		done:        make(chan struct{}),
 	}
 }
 
@@ -104,7 +135,7 @@ func (p *GuildPlayer) GetCurrent() (*player.Track, bool) {
 	if p.current == nil {
 		return nil, false
 	}
-	return p.current, true
+	return proto.Clone(p.current).(*player.Track), true
 }
 
 func (p *GuildPlayer) Size() int {
@@ -123,6 +154,39 @@ func (p *GuildPlayer) AddTrack(track *player.Track) {
 	p.mu.Unlock()
 }
 
+// AddTracks adds all the tracks to the queue, or none of them if the queue
+// would exceed its max size.
+func (p *GuildPlayer) AddTracks(tracks []*player.Track) error {
+	maxSize := int(p.Config().MaxQueueSize)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.fits(len(tracks), maxSize) {
+		return errcodes.ErrQueueFull
+	}
+	p.queue = append(p.queue, tracks...)
+
+	return nil
+}
+
+// CheckQueueSize returns ErrQueueFull if n more tracks do not fit in a queue
+// of maxSize, zero means unlimited.
+func (p *GuildPlayer) CheckQueueSize(n int, maxSize int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.fits(n, maxSize) {
+		return errcodes.ErrQueueFull
+	}
+	return nil
+}
+
+// Must be called with the lock held.
+func (p *GuildPlayer) fits(n int, maxSize int) bool {
+	return maxSize <= 0 || len(p.queue)+n <= maxSize
+}
+
 func (p *GuildPlayer) Pool() *player.Track {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -135,7 +199,7 @@ func (p *GuildPlayer) Pool() *player.Track {
 		p.current = track
 
 		p.current.State = &player.TrackState{
-			Progress:     durationpb.New(0),
+			Progress:     durationpb.New(track.Data.Start.AsDuration()),
 			PlayingStart: timestamppb.Now(),
 		}
 	}
@@ -143,42 +207,75 @@ func (p *GuildPlayer) Pool() *player.Track {
 	return p.current
 }
 
-func (p *GuildPlayer) RemoveById(uid uuid.UUID) (*player.Track, bool) {
+// RemoveById removes the track from the queue, the current track is skipped
+// instead.
+func (p *GuildPlayer) RemoveById(ctx context.Context, uid uuid.UUID) (*player.Track, error) {
 	id := uid.String()
 
 	p.mu.Lock()
-
-	if p.current != nil {
-		if p.current.Id == id {
-			c := proto.Clone(p.current).(*player.Track)
-			p.mu.Unlock()
-
-			p.Skip()
-			return c, true
-		}
+	if p.current != nil && p.current.Id == id {
+		p.mu.Unlock()
+		return p.skip(ctx, id)
 	}
 	defer p.mu.Unlock()
 
-	index := -1
-	track := (*player.Track)(nil)
-	for i, t := range p.queue {
-		if t.Id == id {
-			track, index = t, i
-		}
+	index := slices.IndexFunc(p.queue, func(t *player.Track) bool {
+		return t.Id == id
+	})
+	if 0 > index {
+		return nil, errcodes.ErrTrackNotFoundInQueue
 	}
 
+	track := p.queue[index]
+	p.queue = slices.Delete(p.queue, index, index+1)
+
+	return track, nil
+}
+
+// SkipTo removes the tracks queued before the track and skips the current
+// one, so it is played next.
+func (p *GuildPlayer) SkipTo(ctx context.Context, uid uuid.UUID) (*player.Track, error) {
+	id := uid.String()
+
+	p.mu.Lock()
+	index := slices.IndexFunc(p.queue, func(t *player.Track) bool {
+		return t.Id == id
+	})
 	if 0 > index {
-		return nil, false
+		p.mu.Unlock()
+		return nil, errcodes.ErrTrackNotFoundInQueue
 	}
 
-	p.queue = append(p.queue[:index], p.queue[index+1:]...)
-	return track, true
+	track := proto.Clone(p.queue[index]).(*player.Track)
+	p.queue = p.queue[index:]
+
+	currentId := ""
+	if p.current != nil {
+		currentId = p.current.Id
+	}
+	p.mu.Unlock()
+
+	// The idle queue already plays the track next
+	if currentId == "" {
+		return track, nil
+	}
+
+	// Only the track playing when the queue was cut is skipped, if it already
+	// ended the track may be playing by now
+	_, err := p.skip(ctx, currentId)
+	if err != nil && err != errcodes.ErrNoActivePlayer &&
+		err != errcodes.ErrTrackNotFoundInQueue {
+		return nil, err
+	}
+
+	return track, nil
 }
 
-func (p *GuildPlayer) RemoveByPosition(pos int) (*player.Track, bool) {
+// RemoveByPosition removes the track at the position of the queue, where 0
+// is the current track, which is skipped instead.
+func (p *GuildPlayer) RemoveByPosition(ctx context.Context, pos int) (*player.Track, error) {
 	if pos == 0 {
-		v := p.Skip()
-		return v, v != nil
+		return p.Skip(ctx)
 	}
 
 	pos--
@@ -186,11 +283,99 @@ func (p *GuildPlayer) RemoveByPosition(pos int) (*player.Track, bool) {
 	defer p.mu.Unlock()
 
 	if pos >= len(p.queue) || 0 > pos {
-		return nil, false
+		return nil, errcodes.ErrTrackNotFoundInQueue
 	}
 
 	track := p.queue[pos]
-	p.queue = append(p.queue[:pos], p.queue[pos+1:]...)
+	p.queue = slices.Delete(p.queue, pos, pos+1)
+
+	return track, nil
+}
+
+// Clear removes all the queued tracks, returning how many were removed.
+func (p *GuildPlayer) Clear() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := len(p.queue)
+	p.queue = []*player.Track{}
+
+	return n
+}
+
+// RemoveByUser removes the queued tracks added by the user, returning how
+// many were removed.
+func (p *GuildPlayer) RemoveByUser(userId uint64) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := len(p.queue)
+	p.queue = slices.DeleteFunc(p.queue, func(t *player.Track) bool {
+		return t.UserId == userId
+	})
+
+	return n - len(p.queue)
+}
+
+// RemoveRange removes the queued tracks between the positions, where 1 is
+// the next track, both inclusive. Returns how many were removed.
+func (p *GuildPlayer) RemoveRange(start, end int) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if 1 > start || start > end || start > len(p.queue) {
+		return 0, errcodes.ErrTrackNotFoundInQueue
+	}
+	end = min(end, len(p.queue))
+
+	p.queue = slices.Delete(p.queue, start-1, end)
+
+	return end - start + 1, nil
+}
+
+// Dedupe removes the queued tracks with the same play query of a previous
+// track or of the current one, returning how many were removed.
+func (p *GuildPlayer) Dedupe() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	seen := map[string]struct{}{}
+	if p.current != nil {
+		seen[p.current.Data.PlayQuery] = struct{}{}
+	}
+
+	n := len(p.queue)
+	p.queue = slices.DeleteFunc(p.queue, func(t *player.Track) bool {
+		if _, ok := seen[t.Data.PlayQuery]; ok {
+			return true
+		}
+		seen[t.Data.PlayQuery] = struct{}{}
+		return false
+	})
+
+	return n - len(p.queue)
+}
+
+// Move changes the position of a queued track, where 1 is the next track to
+// be played. The current track can not be moved.
+func (p *GuildPlayer) Move(uid uuid.UUID, pos int) (*player.Track, bool) {
+	id := uid.String()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	index := slices.IndexFunc(p.queue, func(t *player.Track) bool {
+		return t.Id == id
+	})
+	if 0 > index {
+		return nil, false
+	}
+
+	track := p.queue[index]
+	p.queue = slices.Delete(p.queue, index, index+1)
+
+	pos = min(max(pos-1, 0), len(p.queue))
+	p.queue = slices.Insert(p.queue, pos, track)
 
 	return track, true
 }
@@ -201,14 +386,17 @@ func (p *GuildPlayer) QueueDuration() time.Duration {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	// Livestreams have no end, so they are not counted
 	for _, track := range p.queue {
-		d += track.Data.Duration.AsDuration()
+		if !track.Data.Live {
+			d += track.Data.Duration.AsDuration()
+		}
 	}
 
-	if p.current != nil {
+	if p.current != nil && !p.current.Data.Live {
 		d += p.current.Data.Duration.AsDuration()
 		if p.current.State != nil {
-			d -= atomicLoadDuration(p.current.State)
+			d -= p.current.State.Progress.AsDuration()
 		}
 	}
 
@@ -235,14 +423,58 @@ func (p *GuildPlayer) GetQueue(
 		finish = offset + limit
 	}
 
+	// Cloned, so the tracks are only changed by the queue
 	tracks = make([]*player.Track, finish-offset)
 	for i := range finish - offset {
-		tracks[i] = p.queue[offset+i]
+		tracks[i] = proto.Clone(p.queue[offset+i]).(*player.Track)
 	}
 
 	return
 }
 
+// SetStreamTitle sets the title announced by the livestream of the track, if
+// it is still the current one.
+func (p *GuildPlayer) SetStreamTitle(id string, title string) (*player.Track, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.current == nil || p.current.Id != id || p.current.State == nil {
+		return nil, false
+	}
+	p.current.State.StreamTitle = title
+
+	return proto.Clone(p.current).(*player.Track), true
+}
+
+// Config returns the settings of the guild, never nil.
+func (p *GuildPlayer) Config() *player.PlayerConfig {
+	if cfg := p.config.Load(); cfg != nil {
+		return cfg
+	}
+	return defaultPlayerConfig
+}
+
+// SetConfig replaces the settings of the guild, the unset durations and
+// volume are defaulted. Nil configs are ignored.
+func (p *GuildPlayer) SetConfig(cfg *player.PlayerConfig) {
+	if cfg == nil {
+		return
+	}
+	cfg = proto.Clone(cfg).(*player.PlayerConfig)
+
+	if cfg.Volume == 0 {
+		cfg.Volume = defaultPlayerConfig.Volume
+	}
+	if cfg.IdleTimeout == nil {
+		cfg.IdleTimeout = defaultPlayerConfig.IdleTimeout
+	}
+	if cfg.PauseTimeout == nil {
+		cfg.PauseTimeout = defaultPlayerConfig.PauseTimeout
+	}
+
+	p.config.Store(cfg)
+}
+
 func (p *GuildPlayer) GetMessageChannel() uint64 {
 	return p.textChannel.Load()
 }
@@ -265,43 +497,135 @@ func (p *GuildPlayer) SetLoop(v bool) {
 	}
 }
 
-func (p *GuildPlayer) Skip() *player.Track {
+// setProgress sets the progress of the track, if it is still the current one.
+func (p *GuildPlayer) setProgress(id string, progress time.Duration) {
 	p.mu.Lock()
+	defer p.mu.Unlock()
 
-	if p.current == nil {
-		p.mu.Unlock()
-		return nil
+	if p.current != nil && p.current.Id == id && p.current.State != nil {
+		p.current.State.Progress = durationpb.New(progress)
 	}
-	c := proto.Clone(p.current).(*player.Track)
-	p.mu.Unlock()
+}
 
-	p.Interrupt <- InterruptSkip
+// addProgress adds the duration of the played packets to the progress of the
+// track.
+func (p *GuildPlayer) addProgress(track *player.Track, inc time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
-	return c
+	if track.State != nil {
+		track.State.Progress = durationpb.New(track.State.Progress.AsDuration() + inc)
+	}
 }
 
-func (p *GuildPlayer) Stop() {
-	p.Interrupt <- InterruptStop
+// getProgress returns the progress of the track.
+func (p *GuildPlayer) getProgress(track *player.Track) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if track.State == nil {
+		return 0
+	}
+	return track.State.Progress.AsDuration()
 }
 
 func (p *GuildPlayer) Paused() bool {
 	return p.paused.Load()
 }
 
-func (p *GuildPlayer) Pause() bool {
-	if !p.paused.Load() {
-		p.paused.Store(true)
-		p.Interrupt <- InterruptPause
-		return true
+// SetSleepTimer sets the timer that stops the player, replacing the previous
+// one. In the tracks mode, the player stops after the current track and the
+// provided number of tracks.
+func (p *GuildPlayer) SetSleepTimer(
+	mode player.SleepMode,
+	d time.Duration,
+	tracks int,
+) *player.SleepTimer {
+	timer := &player.SleepTimer{Mode: mode}
+	if mode == player.SleepMode_SleepModeTime {
+		deadline := time.Now().Add(d)
+		timer.Deadline = timestamppb.New(deadline)
+		p.sleepDeadline.Store(deadline.UnixNano())
+	} else {
+		timer.Tracks = int32(tracks)
+		p.sleepDeadline.Store(0)
+	}
+
+	p.mu.Lock()
+	p.sleep = timer
+	p.mu.Unlock()
+
+	return proto.Clone(timer).(*player.SleepTimer)
+}
+
+// CancelSleepTimer removes the sleep timer, returning false if it was not
+// set.
+func (p *GuildPlayer) CancelSleepTimer() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sleep == nil {
+		return false
+	}
+	p.sleep = nil
+	p.sleepDeadline.Store(0)
+
+	return true
+}
+
+// SleepTimer returns the sleep timer of the player, nil if not set.
+func (p *GuildPlayer) SleepTimer() *player.SleepTimer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sleep == nil {
+		return nil
+	}
+	return proto.Clone(p.sleep).(*player.SleepTimer)
+}
+
+// Slept returns whether the player was stopped by the sleep timer.
+func (p *GuildPlayer) Slept() bool {
+	return p.slept.Load()
+}
+
+// sleepDue returns whether the deadline of the sleep timer has passed,
+// marking the player as slept.
+func (p *GuildPlayer) sleepDue() bool {
+	deadline := p.sleepDeadline.Load()
+	if deadline == 0 || time.Now().UnixNano() < deadline {
+		return false
+	}
+
+	p.slept.Store(true)
+	return true
+}
+
+// sleepTimeout returns a channel fired when the deadline of the sleep timer
+// passes, nil if it is not in the time mode.
+func (p *GuildPlayer) sleepTimeout() <-chan time.Time {
+	deadline := p.sleepDeadline.Load()
+	if deadline == 0 {
+		return nil
 	}
-	return false
+	return time.NewTimer(time.Until(time.Unix(0, deadline))).C
 }
 
-func (p *GuildPlayer) Unpause() bool {
-	if p.paused.Load() {
-		p.paused.Store(false)
-		p.Interrupt <- InterruptUnpause
-		return true
+// sleepTrackEnded counts a finished track on the sleep timer, returning
+// whether the player must stop. Skipped tracks are counted as well.
+func (p *GuildPlayer) sleepTrackEnded() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sleep == nil || p.sleep.Mode != player.SleepMode_SleepModeTracks {
+		return false
 	}
-	return false
+
+	if p.sleep.Tracks > 0 {
+		p.sleep.Tracks--
+		return false
+	}
+
+	p.slept.Store(true)
+	return true
 }
@@ -0,0 +1,73 @@
+package player_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	guildplayer "github.com/zanz1n/duvua/internal/player"
+	"github.com/zanz1n/duvua/pkg/pb/player"
+)
+
+func newTestTracks(n int) []*player.Track {
+	tracks := make([]*player.Track, n)
+	for i := range tracks {
+		tracks[i] = &player.Track{
+			Id:   uuid.NewString(),
+			Data: &player.TrackData{Name: "Track"},
+		}
+	}
+	return tracks
+}
+
+func TestSkipTo(t *testing.T) {
+	p := guildplayer.NewGuildPlayer(1)
+	tracks := newTestTracks(4)
+	require.NoError(t, p.AddTracks(tracks))
+	p.Pool()
+
+	evt := make(chan guildplayer.InterruptType, 1)
+	go func() { evt <- p.HandleNextCommand(nil) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	track, err := p.SkipTo(ctx, uuid.MustParse(tracks[2].Id))
+	require.NoError(t, err)
+	assert.Equal(t, tracks[2].Id, track.Id)
+
+	assert.Equal(t, guildplayer.InterruptSkip, <-evt)
+	assert.Equal(t, tracks[2].Id, p.Pool().Id, "Expected the track to be played next")
+}
+
+func TestSkipToAfterCurrentEnded(t *testing.T) {
+	p := guildplayer.NewGuildPlayer(1)
+	tracks := newTestTracks(4)
+	require.NoError(t, p.AddTracks(tracks))
+	p.Pool()
+
+	// The current track ends before the skip is applied, so the queue
+	// already started the selected track
+	evt := make(chan guildplayer.InterruptType, 1)
+	go func() {
+		evt <- p.HandleNextCommand(func() { p.Pool() })
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	track, err := p.SkipTo(ctx, uuid.MustParse(tracks[2].Id))
+	require.NoError(t, err)
+	assert.Equal(t, tracks[2].Id, track.Id)
+
+	assert.Equal(t, guildplayer.InterruptNone, <-evt,
+		"Expected the selected track not to be skipped",
+	)
+
+	current, ok := p.GetCurrent()
+	require.True(t, ok)
+	assert.Equal(t, tracks[2].Id, current.Id)
+}
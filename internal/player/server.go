@@ -31,11 +31,25 @@ func (s *GrpcServer) Add(
 	ctx context.Context,
 	req *player.AddRequest,
 ) (*player.AddResponse, error) {
+	maxSize := int(req.Config.GetMaxQueueSize())
+	if maxSize > 0 && len(req.Data) > maxSize {
+		return nil, errcodes.ErrQueueFull
+	}
+
+	// Checked before creating the player, so it does not join the channel
+	// nor change the settings when nothing can be added
+	if p, ok := s.m.Get(req.GuildId); ok {
+		if err := p.CheckQueueSize(len(req.Data), maxSize); err != nil {
+			return nil, err
+		}
+	}
+
 	p := s.m.GetOrCreate(req.GuildId, req.ChannelId)
+	p.SetConfig(req.Config)
 
 	tracks := make([]*player.Track, len(req.Data))
 	for i, track := range req.Data {
-		track := &player.Track{
+		tracks[i] = &player.Track{
 			Id:        uuid.NewString(),
 			CreatedAt: timestamppb.Now(),
 			UserId:    req.UserId,
@@ -43,10 +57,13 @@ func (s *GrpcServer) Add(
 			State:     nil,
 			Data:      track,
 		}
-		p.AddTrack(track)
+	}
 
-		tracks[i] = track
+	if err := p.AddTracks(tracks); err != nil {
+		return nil, err
+	}
 
+	for _, track := range tracks {
 		slog.Info(
 			"Added track to queue",
 			"guild_id", req.GuildId,
@@ -61,6 +78,51 @@ func (s *GrpcServer) Add(
 	return &player.AddResponse{Tracks: tracks}, nil
 }
 
+// CancelSleep implements player.PlayerServer.
+func (s *GrpcServer) CancelSleep(
+	ctx context.Context,
+	req *player.GuildIdRequest,
+) (*player.ChangedResponse, error) {
+	p, ok := s.m.Get(req.GuildId)
+	if !ok {
+		return nil, errcodes.ErrNoActivePlayer
+	}
+
+	changed := p.CancelSleepTimer()
+
+	return &player.ChangedResponse{Changed: changed}, nil
+}
+
+// Clear implements player.PlayerServer.
+func (s *GrpcServer) Clear(
+	ctx context.Context,
+	req *player.GuildIdRequest,
+) (*player.RemovedResponse, error) {
+	p, ok := s.m.Get(req.GuildId)
+	if !ok {
+		return nil, errcodes.ErrNoActivePlayer
+	}
+
+	removed := p.Clear()
+
+	return &player.RemovedResponse{Removed: int32(removed)}, nil
+}
+
+// Dedupe implements player.PlayerServer.
+func (s *GrpcServer) Dedupe(
+	ctx context.Context,
+	req *player.GuildIdRequest,
+) (*player.RemovedResponse, error) {
+	p, ok := s.m.Get(req.GuildId)
+	if !ok {
+		return nil, errcodes.ErrNoActivePlayer
+	}
+
+	removed := p.Dedupe()
+
+	return &player.RemovedResponse{Removed: int32(removed)}, nil
+}
+
 // EnableLoop implements player.PlayerServer.
 func (s *GrpcServer) EnableLoop(
 	ctx context.Context,
@@ -86,12 +148,30 @@ func (s *GrpcServer) Fetch(
 	ctx context.Context,
 	req *player.FetchRequest,
 ) (*player.FetchResponse, error) {
-	data, err := s.f.Search(req.Query)
+	data, skipped, err := s.f.Search(req.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &player.FetchResponse{Data: data, Skipped: int32(skipped)}, nil
+}
+
+// Search implements player.PlayerServer.
+func (s *GrpcServer) Search(
+	ctx context.Context,
+	req *player.SearchRequest,
+) (*player.SearchResponse, error) {
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = 10
+	}
+
+	results, err := s.f.SearchMany(req.Query, limit)
 	if err != nil {
 		return nil, err
 	}
 
-	return &player.FetchResponse{Data: data}, nil
+	return &player.SearchResponse{Results: results}, nil
 }
 
 // GetAll implements player.PlayerServer.
@@ -112,6 +192,7 @@ func (s *GrpcServer) GetAll(
 		TotalDuration: durationpb.New(d),
 		Playing:       playing,
 		Tracks:        tracks,
+		Sleep:         p.SleepTimer(),
 	}, nil
 }
 
@@ -152,6 +233,25 @@ func (s *GrpcServer) GetCurrent(
 	return &player.TrackResponse{Track: track}, nil
 }
 
+// Move implements player.PlayerServer.
+func (s *GrpcServer) Move(
+	ctx context.Context,
+	req *player.MoveRequest,
+) (*player.TrackResponse, error) {
+	p, ok := s.m.Get(req.GuildId)
+	if !ok {
+		return nil, errcodes.ErrNoActivePlayer
+	}
+
+	id, _ := uuid.Parse(req.Id)
+	track, ok := p.Move(id, int(req.Position))
+	if !ok {
+		return nil, errcodes.ErrTrackNotFoundInQueue
+	}
+
+	return &player.TrackResponse{Track: track}, nil
+}
+
 // Pause implements player.PlayerServer.
 func (s *GrpcServer) Pause(
 	ctx context.Context,
@@ -162,7 +262,10 @@ func (s *GrpcServer) Pause(
 		return nil, errcodes.ErrNoActivePlayer
 	}
 
-	changed := p.Pause()
+	changed, err := p.Pause(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	return &player.ChangedResponse{Changed: changed}, nil
 }
@@ -178,9 +281,9 @@ func (s *GrpcServer) Remove(
 	}
 
 	id, _ := uuid.Parse(req.Id)
-	track, ok := p.RemoveById(id)
-	if !ok {
-		return nil, errcodes.ErrTrackNotFoundInQueue
+	track, err := p.RemoveById(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 
 	return &player.TrackResponse{Track: track}, nil
@@ -196,14 +299,75 @@ func (s *GrpcServer) RemoveByPosition(
 		return nil, errcodes.ErrNoActivePlayer
 	}
 
-	track, ok := p.RemoveByPosition(int(req.Position))
-	if !ok {
-		return nil, errcodes.ErrTrackNotFoundInQueue
+	track, err := p.RemoveByPosition(ctx, int(req.Position))
+	if err != nil {
+		return nil, err
 	}
 
 	return &player.TrackResponse{Track: track}, nil
 }
 
+// RemoveByUser implements player.PlayerServer.
+func (s *GrpcServer) RemoveByUser(
+	ctx context.Context,
+	req *player.RemoveByUserRequest,
+) (*player.RemovedResponse, error) {
+	p, ok := s.m.Get(req.GuildId)
+	if !ok {
+		return nil, errcodes.ErrNoActivePlayer
+	}
+
+	removed := p.RemoveByUser(req.UserId)
+
+	return &player.RemovedResponse{Removed: int32(removed)}, nil
+}
+
+// RemoveRange implements player.PlayerServer.
+func (s *GrpcServer) RemoveRange(
+	ctx context.Context,
+	req *player.RemoveRangeRequest,
+) (*player.RemovedResponse, error) {
+	p, ok := s.m.Get(req.GuildId)
+	if !ok {
+		return nil, errcodes.ErrNoActivePlayer
+	}
+
+	removed, err := p.RemoveRange(int(req.Start), int(req.End))
+	if err != nil {
+		return nil, err
+	}
+
+	return &player.RemovedResponse{Removed: int32(removed)}, nil
+}
+
+// SetSleep implements player.PlayerServer.
+func (s *GrpcServer) SetSleep(
+	ctx context.Context,
+	req *player.SetSleepRequest,
+) (*player.SleepResponse, error) {
+	d := req.Duration.AsDuration()
+	if req.Mode == player.SleepMode_SleepModeTime && 0 >= d {
+		return nil, status.Error(codes.InvalidArgument, "the sleep duration must be positive")
+	}
+
+	p, ok := s.m.Get(req.GuildId)
+	if !ok {
+		return nil, errcodes.ErrNoActivePlayer
+	}
+
+	timer := p.SetSleepTimer(req.Mode, d, int(req.Tracks))
+
+	slog.Info(
+		"Set player sleep timer",
+		"guild_id", req.GuildId,
+		"mode", req.Mode.String(),
+		"duration", d,
+		"tracks", req.Tracks,
+	)
+
+	return &player.SleepResponse{Timer: timer}, nil
+}
+
 // SetVolume implements player.PlayerServer.
 func (s *GrpcServer) SetVolume(
 	ctx context.Context,
@@ -212,6 +376,24 @@ func (s *GrpcServer) SetVolume(
 	return nil, status.Error(codes.Unimplemented, "unimplemented")
 }
 
+// Seek implements player.PlayerServer.
+func (s *GrpcServer) Seek(
+	ctx context.Context,
+	req *player.SeekRequest,
+) (*player.TrackResponse, error) {
+	p, ok := s.m.Get(req.GuildId)
+	if !ok {
+		return nil, errcodes.ErrNoActivePlayer
+	}
+
+	track, err := p.Seek(ctx, req.Position.AsDuration())
+	if err != nil {
+		return nil, err
+	}
+
+	return &player.TrackResponse{Track: track}, nil
+}
+
 // Skip implements player.PlayerServer.
 func (s *GrpcServer) Skip(
 	ctx context.Context,
@@ -222,11 +404,30 @@ func (s *GrpcServer) Skip(
 		return nil, errcodes.ErrNoActivePlayer
 	}
 
-	track := p.Skip()
-	if track == nil {
+	track, err := p.Skip(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &player.TrackResponse{Track: track}, nil
+}
+
+// SkipTo implements player.PlayerServer.
+func (s *GrpcServer) SkipTo(
+	ctx context.Context,
+	req *player.TrackIdRequest,
+) (*player.TrackResponse, error) {
+	p, ok := s.m.Get(req.GuildId)
+	if !ok {
 		return nil, errcodes.ErrNoActivePlayer
 	}
 
+	id, _ := uuid.Parse(req.Id)
+	track, err := p.SkipTo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
 	return &player.TrackResponse{Track: track}, nil
 }
 
@@ -240,7 +441,9 @@ func (s *GrpcServer) Stop(
 		return nil, errcodes.ErrNoActivePlayer
 	}
 
-	p.Stop()
+	if err := p.Stop(ctx); err != nil {
+		return nil, err
+	}
 	return &emptypb.Empty{}, nil
 }
 
@@ -254,7 +457,10 @@ func (s *GrpcServer) Unpause(
 		return nil, errcodes.ErrNoActivePlayer
 	}
 
-	changed := p.Unpause()
+	changed, err := p.Unpause(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	return &player.ChangedResponse{Changed: changed}, nil
 }
@@ -0,0 +1,267 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/zanz1n/duvua/internal/errors"
+	"github.com/zanz1n/duvua/internal/music"
+	"github.com/zanz1n/duvua/pkg/pb/player"
+)
+
+const (
+	defaultQueueLimit = 20
+	maxQueueLimit     = 100
+)
+
+func (s *Server) handleGetQueue(w http.ResponseWriter, r *http.Request, ss *session) error {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = defaultQueueLimit
+	}
+	limit = min(max(limit, 0), maxQueueLimit)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	res, err := s.c.GetAll(ctx, &player.GetAllRequest{
+		GuildId: ss.guildId(),
+		Offset:  int32(max(offset, 0)),
+		Limit:   int32(limit),
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeProto(w, http.StatusOK, res)
+}
+
+type addRequest struct {
+	Query string `json:"query"`
+}
+
+func (s *Server) handleAdd(w http.ResponseWriter, r *http.Request, ss *session) error {
+	var body addRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return errors.New("o corpo da requisição é inválido")
+	}
+
+	body.Query = strings.TrimSpace(body.Query)
+	if body.Query == "" {
+		return errors.New("o campo `query` é necessário")
+	}
+
+	if err := ss.checkPermission(music.MusicActionPlay); err != nil {
+		return err
+	}
+
+	vs, err := s.s.State.VoiceState(ss.token.GuildId, ss.token.UserId)
+	if err != nil {
+		return errors.New("você precisa estar em um canal de voz para adicionar músicas")
+	}
+	if !ss.cfg.VoiceChannelAllowed(vs.ChannelID) {
+		return errors.New("as músicas não podem ser tocadas no seu canal de voz")
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	fetched, err := s.c.Fetch(ctx, &player.FetchRequest{Query: body.Query})
+	if err != nil {
+		return err
+	}
+
+	data, _ := ss.cfg.FilterBlocked(ss.token.UserId, fetched.Data)
+	if len(data) == 0 {
+		return errors.New("as músicas estão bloqueadas no servidor")
+	}
+
+	res, err := s.c.Add(ctx, &player.AddRequest{
+		GuildId:       ss.guildId(),
+		UserId:        cuint64(ss.token.UserId),
+		ChannelId:     cuint64(vs.ChannelID),
+		TextChannelId: cuint64(ss.token.ChannelId),
+		Data:          data,
+		Config:        ss.cfg.PlayerConfig(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeProto(w, http.StatusCreated, res)
+}
+
+func (s *Server) handleRemove(w http.ResponseWriter, r *http.Request, ss *session) error {
+	id, err := trackId(r)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	track, err := s.c.GetById(ctx, &player.TrackIdRequest{
+		GuildId: ss.guildId(),
+		Id:      id,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = ss.checkTrackPermission(music.MusicActionRemove, track.Track); err != nil {
+		return err
+	}
+
+	res, err := s.c.Remove(ctx, &player.TrackIdRequest{
+		GuildId: ss.guildId(),
+		Id:      id,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeProto(w, http.StatusOK, res)
+}
+
+type moveRequest struct {
+	Position int32 `json:"position"`
+}
+
+func (s *Server) handleMove(w http.ResponseWriter, r *http.Request, ss *session) error {
+	id, err := trackId(r)
+	if err != nil {
+		return err
+	}
+
+	var body moveRequest
+	if err = json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return errors.New("o corpo da requisição é inválido")
+	} else if 1 > body.Position {
+		return errors.New("o campo `position` precisa ser maior que 0")
+	}
+
+	// Reordering the queue changes when the tracks of the other members play
+	if err = ss.checkPermission(music.MusicActionRemove); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	res, err := s.c.Move(ctx, &player.MoveRequest{
+		GuildId:  ss.guildId(),
+		Id:       id,
+		Position: body.Position,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeProto(w, http.StatusOK, res)
+}
+
+func (s *Server) handleGetCurrent(w http.ResponseWriter, r *http.Request, ss *session) error {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	res, err := s.c.GetCurrent(ctx, &player.GuildIdRequest{
+		GuildId: ss.guildId(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeProto(w, http.StatusOK, res)
+}
+
+func (s *Server) handleSkip(w http.ResponseWriter, r *http.Request, ss *session) error {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	if ss.cfg.CanDo(ss.member.Roles, ss.admin, music.MusicActionSkip) {
+		res, err := s.c.Skip(ctx, &player.GuildIdRequest{
+			GuildId: ss.guildId(),
+		})
+		if err != nil {
+			return err
+		}
+		return writeProto(w, http.StatusOK, res)
+	}
+
+	current, err := s.c.GetCurrent(ctx, &player.GuildIdRequest{
+		GuildId: ss.guildId(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = ss.checkTrackPermission(music.MusicActionSkip, current.Track); err != nil {
+		return err
+	}
+
+	// Removed by id, so a track that started meanwhile is never skipped
+	res, err := s.c.Remove(ctx, &player.TrackIdRequest{
+		GuildId: ss.guildId(),
+		Id:      current.Track.Id,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeProto(w, http.StatusOK, res)
+}
+
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request, ss *session) error {
+	if err := ss.checkPermission(music.MusicActionPause); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	res, err := s.c.Pause(ctx, &player.GuildIdRequest{
+		GuildId: ss.guildId(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeProto(w, http.StatusOK, res)
+}
+
+func (s *Server) handleUnpause(w http.ResponseWriter, r *http.Request, ss *session) error {
+	if err := ss.checkPermission(music.MusicActionPause); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	res, err := s.c.Unpause(ctx, &player.GuildIdRequest{
+		GuildId: ss.guildId(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeProto(w, http.StatusOK, res)
+}
+
+func trackId(r *http.Request) (string, error) {
+	id := r.PathValue("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return "", errors.New("o id da música é inválido")
+	}
+	return id, nil
+}
+
+func cuint64(s string) uint64 {
+	v, _ := strconv.ParseUint(s, 10, 0)
+	return v
+}
@@ -0,0 +1,286 @@
+package remote
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/gorilla/websocket"
+	"github.com/zanz1n/duvua/internal/errors"
+	"github.com/zanz1n/duvua/internal/music"
+	"github.com/zanz1n/duvua/internal/utils"
+	"github.com/zanz1n/duvua/pkg/pb/player"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var protojsonOptions = protojson.MarshalOptions{
+	UseProtoNames:   true,
+	EmitUnpopulated: true,
+}
+
+// Server exposes the player of the guilds as a JSON REST API and a
+// websocket of live updates, used by the web remote. The requests are
+// authenticated with the tokens issued by the /remote command.
+type Server struct {
+	s  *discordgo.Session
+	r  music.MusicConfigRepository
+	tr music.RemoteTokenRepository
+	c  player.PlayerClient
+
+	upgrader websocket.Upgrader
+	// How often the live updates are pushed to the websockets
+	updateInterval time.Duration
+
+	mu      sync.Mutex
+	clients map[*wsClient]struct{}
+}
+
+func NewServer(
+	s *discordgo.Session,
+	r music.MusicConfigRepository,
+	tr music.RemoteTokenRepository,
+	c player.PlayerClient,
+) *Server {
+	return &Server{
+		s:  s,
+		r:  r,
+		tr: tr,
+		c:  c,
+		upgrader: websocket.Upgrader{
+			// The requests are authenticated by token, not by cookies
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		updateInterval: 2 * time.Second,
+		clients:        map[*wsClient]struct{}{},
+	}
+}
+
+// TokenRepository returns the token repository of the server, which also
+// closes the websockets of the revoked tokens. It must be used by the
+// commands that revoke the tokens.
+func (s *Server) TokenRepository() music.RemoteTokenRepository {
+	return &revokingTokenRepository{RemoteTokenRepository: s.tr, s: s}
+}
+
+type revokingTokenRepository struct {
+	music.RemoteTokenRepository
+	s *Server
+}
+
+// DeleteByUser implements music.RemoteTokenRepository.
+func (r *revokingTokenRepository) DeleteByUser(guildId string, userId string) (int, error) {
+	n, err := r.RemoteTokenRepository.DeleteByUser(guildId, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	r.s.closeClients(guildId, userId)
+	return n, nil
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/queue", s.auth(s.handleGetQueue))
+	mux.HandleFunc("POST /api/queue", s.auth(s.handleAdd))
+	mux.HandleFunc("DELETE /api/queue/{id}", s.auth(s.handleRemove))
+	mux.HandleFunc("POST /api/queue/{id}/move", s.auth(s.handleMove))
+
+	mux.HandleFunc("GET /api/current", s.auth(s.handleGetCurrent))
+	mux.HandleFunc("POST /api/skip", s.auth(s.handleSkip))
+	mux.HandleFunc("POST /api/pause", s.auth(s.handlePause))
+	mux.HandleFunc("POST /api/unpause", s.auth(s.handleUnpause))
+
+	mux.HandleFunc("GET /api/ws", s.auth(s.handleWebSocket))
+
+	return cors(mux)
+}
+
+// session is the authenticated member of a request.
+type session struct {
+	token  *music.RemoteToken
+	cfg    *music.MusicConfig
+	member *discordgo.Member
+	admin  bool
+}
+
+func (ss *session) guildId() uint64 {
+	return cuint64(ss.token.GuildId)
+}
+
+func (ss *session) checkPermission(action music.MusicAction) error {
+	if !ss.cfg.CanDo(ss.member.Roles, ss.admin, action) {
+		return &statusError{
+			status: http.StatusForbidden,
+			err: errors.Newf(
+				"você não tem permissão para %s no servidor",
+				strings.ToLower(action.StringPtBr()),
+			),
+		}
+	}
+	return nil
+}
+
+// checkTrackPermission is like checkPermission, but the members can always
+// skip or remove the tracks they added.
+func (ss *session) checkTrackPermission(action music.MusicAction, track *player.Track) error {
+	if track != nil && track.UserId == cuint64(ss.token.UserId) {
+		return nil
+	}
+	return ss.checkPermission(action)
+}
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request, ss *session) error
+
+// auth authenticates the request with the token of the Authorization header,
+// or of the `token` query parameter since browsers can not set headers on
+// websocket requests.
+func (s *Server) auth(h handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		ss, err := s.authenticate(r)
+		if err == nil {
+			err = h(w, r, ss)
+		}
+
+		if err != nil {
+			writeError(w, err)
+		}
+
+		slog.Debug(
+			"Remote: Handled request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"took", time.Since(start).Round(time.Millisecond),
+			"error", err,
+		)
+	}
+}
+
+func (s *Server) authenticate(r *http.Request) (*session, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		token = r.URL.Query().Get("token")
+	}
+
+	t, err := s.tr.GetByToken(token)
+	if err != nil {
+		if err == music.ErrInvalidRemoteToken {
+			return nil, &statusError{status: http.StatusUnauthorized, err: err}
+		}
+		return nil, err
+	}
+
+	member, admin, err := s.member(t.GuildId, t.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := s.r.GetOrDefault(t.GuildId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session{token: t, cfg: cfg, member: member, admin: admin}, nil
+}
+
+// member fetches the member and whether they are an administrator of the
+// guild, the permissions are computed on each request so the changes of
+// roles apply to the issued tokens.
+func (s *Server) member(guildId, userId string) (*discordgo.Member, bool, error) {
+	guild, err := s.s.State.Guild(guildId)
+	if err != nil {
+		return nil, false, &statusError{
+			status: http.StatusForbidden,
+			err:    errors.New("o bot não está mais no servidor"),
+		}
+	}
+
+	member, err := s.s.State.Member(guildId, userId)
+	if err != nil {
+		if member, err = s.s.GuildMember(guildId, userId); err != nil {
+			return nil, false, &statusError{
+				status: http.StatusForbidden,
+				err:    errors.New("você não está mais no servidor"),
+			}
+		}
+	}
+
+	if guild.OwnerID == userId {
+		return member, true, nil
+	}
+
+	perms := int64(0)
+	for _, role := range guild.Roles {
+		// The @everyone role has the id of the guild
+		if role.ID == guildId || slices.Contains(member.Roles, role.ID) {
+			perms |= role.Permissions
+		}
+	}
+
+	return member, utils.HasPerm(perms, discordgo.PermissionAdministrator), nil
+}
+
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+
+	if se, ok := err.(*statusError); ok {
+		status = se.status
+	} else if e, ok := err.(errors.Expected); ok && e.IsExpected() {
+		status = http.StatusBadRequest
+	} else {
+		slog.Error("Remote: Unexpected error", "error", err)
+		err = errors.New("erro interno")
+	}
+
+	writeJson(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJson(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeProto(w http.ResponseWriter, status int, m proto.Message) error {
+	b, err := protojsonOptions.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(b)
+	return err
+}
+
+// cors allows the web remote to be served from any origin.
+func cors(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,178 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/zanz1n/duvua/internal/errors"
+	"github.com/zanz1n/duvua/pkg/pb/player"
+	"google.golang.org/protobuf/proto"
+)
+
+const wsWriteTimeout = 5 * time.Second
+
+type wsEventType string
+
+const (
+	// The player state, pushed periodically with the progress of the track
+	wsEventUpdate wsEventType = "update"
+	// There is not an active player in the guild
+	wsEventIdle wsEventType = "idle"
+	// The token expired, the connection is closed after it
+	wsEventExpired wsEventType = "expired"
+	// The token was revoked, the connection is closed after it
+	wsEventRevoked wsEventType = "revoked"
+)
+
+var errTokenRevoked = errors.New("token revoked")
+
+// wsClient is a websocket connection, which is closed when the tokens of
+// the member are revoked.
+type wsClient struct {
+	guildId string
+	userId  string
+	cancel  context.CancelCauseFunc
+}
+
+func (s *Server) addClient(c *wsClient) {
+	s.mu.Lock()
+	s.clients[c] = struct{}{}
+	s.mu.Unlock()
+}
+
+func (s *Server) removeClient(c *wsClient) {
+	s.mu.Lock()
+	delete(s.clients, c)
+	s.mu.Unlock()
+}
+
+// closeClients closes the websockets opened with the tokens of the member.
+func (s *Server) closeClients(guildId, userId string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.clients {
+		if c.guildId == guildId && c.userId == userId {
+			c.cancel(errTokenRevoked)
+		}
+	}
+}
+
+type wsEvent struct {
+	Type wsEventType     `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// handleWebSocket pushes the current track, its progress and the size of the
+// queue to the client, until the connection is closed or the token expires.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request, ss *session) error {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader already replied with the error
+		return nil
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	slog.Info(
+		"Remote: Websocket connected",
+		"guild_id", ss.token.GuildId,
+		"user_id", ss.token.UserId,
+	)
+
+	ctx, cancelDeadline := context.WithDeadline(r.Context(), ss.token.ExpiresAt)
+	defer cancelDeadline()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	client := &wsClient{
+		guildId: ss.token.GuildId,
+		userId:  ss.token.UserId,
+		cancel:  cancel,
+	}
+	s.addClient(client)
+	defer s.removeClient(client)
+
+	// The messages of the client are discarded, but they must be read to
+	// handle the close and ping control messages
+	go func() {
+		defer cancel(nil)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(s.updateInterval)
+	defer ticker.Stop()
+
+	for {
+		if err = s.pushUpdate(ctx, conn, ss); err != nil {
+			break
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+		}
+
+		if ctx.Err() != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				err = writeEvent(conn, wsEventExpired, nil)
+			} else if context.Cause(ctx) == errTokenRevoked {
+				err = writeEvent(conn, wsEventRevoked, nil)
+			}
+			break
+		}
+	}
+
+	slog.Info(
+		"Remote: Websocket disconnected",
+		"guild_id", ss.token.GuildId,
+		"user_id", ss.token.UserId,
+		"took", time.Since(start).Round(time.Millisecond),
+		"error", err,
+	)
+	return nil
+}
+
+func (s *Server) pushUpdate(ctx context.Context, conn *websocket.Conn, ss *session) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	// Only the current track and the size of the queue are sent, the tracks
+	// are fetched with the REST api
+	res, err := s.c.GetAll(ctx, &player.GetAllRequest{
+		GuildId: ss.guildId(),
+		Offset:  0,
+		Limit:   0,
+	})
+	if err != nil {
+		if e, ok := err.(errors.Expected); ok && e.IsExpected() {
+			return writeEvent(conn, wsEventIdle, nil)
+		}
+		return err
+	}
+
+	return writeEvent(conn, wsEventUpdate, res)
+}
+
+func writeEvent(conn *websocket.Conn, t wsEventType, m proto.Message) error {
+	event := wsEvent{Type: t}
+	if m != nil {
+		b, err := protojsonOptions.Marshal(m)
+		if err != nil {
+			return err
+		}
+		event.Data = b
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return conn.WriteJSON(event)
+}
@@ -0,0 +1,175 @@
+package ttlcache
+
+import (
+	"container/list"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+type Config struct {
+	// The name of the cache, used in logs
+	Name string
+	// The max number of entries, the least recently used are evicted
+	Size int
+	// How long the loaded values are kept
+	TTL time.Duration
+	// How long the load failures are kept. Failures are not cached if zero.
+	NegativeTTL time.Duration
+	// Reports whether a load failure can be cached, like a search without
+	// results. If nil, all the failures are cached.
+	CacheErr func(err error) bool
+}
+
+type entry[V any] struct {
+	key     string
+	value   V
+	err     error
+	expires time.Time
+}
+
+// Cache is an in-memory LRU cache of loaded values with expiration.
+//
+// Concurrent loads of the same key are de-duplicated, so only one of them
+// calls the load function. The cached values are shared between all the
+// callers and must not be modified.
+type Cache[V any] struct {
+	cfg Config
+
+	entries map[string]*list.Element
+	lru     *list.List
+	mu      sync.Mutex
+
+	sf singleflight.Group
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func New[V any](cfg Config) *Cache[V] {
+	if cfg.Size <= 0 {
+		panic("ttlcache.New() cfg.Size must be greater than zero")
+	}
+
+	return &Cache[V]{
+		cfg:     cfg,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Get returns the cached value of key, calling load if it is not cached or
+// has expired.
+func (c *Cache[V]) Get(key string, load func() (V, error)) (V, error) {
+	if e, ok := c.get(key); ok {
+		hits := c.hits.Add(1)
+		slog.Debug(
+			"Cache: Hit",
+			"cache", c.cfg.Name,
+			"key", key,
+			"negative", e.err != nil,
+			"hits", hits,
+			"misses", c.misses.Load(),
+		)
+		return e.value, e.err
+	}
+
+	start := time.Now()
+	v, err, shared := c.sf.Do(key, func() (any, error) {
+		v, err := load()
+		c.set(key, v, err)
+		return v, err
+	})
+
+	misses := c.misses.Add(1)
+	slog.Debug(
+		"Cache: Miss",
+		"cache", c.cfg.Name,
+		"key", key,
+		"shared", shared,
+		"hits", c.hits.Load(),
+		"misses", misses,
+		"took", time.Since(start).Round(time.Millisecond),
+	)
+
+	return v.(V), err
+}
+
+// Delete removes the cached value of key, so it is loaded again in the next
+// call to Get.
+func (c *Cache[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+// Stats returns the number of cache hits and misses.
+func (c *Cache[V]) Stats() (hits, misses uint64) {
+	return c.hits.Load(), c.misses.Load()
+}
+
+// Len returns the number of cached entries, including the expired ones that
+// were not evicted yet.
+func (c *Cache[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+func (c *Cache[V]) get(key string) (*entry[V], bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := elem.Value.(*entry[V])
+	if time.Now().After(e.expires) {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(elem)
+	return e, true
+}
+
+func (c *Cache[V]) set(key string, v V, err error) {
+	ttl := c.cfg.TTL
+	if err != nil {
+		if c.cfg.NegativeTTL == 0 ||
+			(c.cfg.CacheErr != nil && !c.cfg.CacheErr(err)) {
+			return
+		}
+		ttl = c.cfg.NegativeTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.lru.Remove(elem)
+	}
+
+	c.entries[key] = c.lru.PushFront(&entry[V]{
+		key:     key,
+		value:   v,
+		err:     err,
+		expires: time.Now().Add(ttl),
+	})
+
+	for c.lru.Len() > c.cfg.Size {
+		elem := c.lru.Back()
+		c.lru.Remove(elem)
+		delete(c.entries, elem.Value.(*entry[V]).key)
+	}
+}
@@ -0,0 +1,154 @@
+package ttlcache_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zanz1n/duvua/internal/errors"
+	"github.com/zanz1n/duvua/internal/utils/ttlcache"
+)
+
+func TestCacheExpiration(t *testing.T) {
+	c := ttlcache.New[int](ttlcache.Config{
+		Name: "test",
+		Size: 10,
+		TTL:  50 * time.Millisecond,
+	})
+
+	loads := 0
+	load := func() (int, error) {
+		loads++
+		return loads, nil
+	}
+
+	for range 3 {
+		v, err := c.Get("a", load)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, v)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	v, err := c.Get("a", load)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, v, "Expired entries must be loaded again")
+
+	hits, misses := c.Stats()
+	assert.Equal(t, uint64(2), hits)
+	assert.Equal(t, uint64(2), misses)
+}
+
+func TestCacheNegative(t *testing.T) {
+	notFound := errors.New("not found")
+
+	c := ttlcache.New[int](ttlcache.Config{
+		Name:        "test",
+		Size:        10,
+		TTL:         time.Minute,
+		NegativeTTL: time.Minute,
+		CacheErr: func(err error) bool {
+			return err == notFound
+		},
+	})
+
+	loads := 0
+	for range 2 {
+		_, err := c.Get("a", func() (int, error) {
+			loads++
+			return 0, notFound
+		})
+		assert.Equal(t, notFound, err)
+	}
+	assert.Equal(t, 1, loads, "Cacheable failures must be cached")
+
+	loads = 0
+	for range 2 {
+		_, err := c.Get("b", func() (int, error) {
+			loads++
+			return 0, errors.Unexpected("timeout")
+		})
+		assert.NotNil(t, err)
+	}
+	assert.Equal(t, 2, loads, "Other failures must not be cached")
+}
+
+func TestCacheEviction(t *testing.T) {
+	c := ttlcache.New[string](ttlcache.Config{
+		Name: "test",
+		Size: 2,
+		TTL:  time.Minute,
+	})
+
+	load := func(v string) func() (string, error) {
+		return func() (string, error) { return v, nil }
+	}
+
+	c.Get("a", load("a"))
+	c.Get("b", load("b"))
+	// Marks "a" as recently used
+	c.Get("a", load("a"))
+	c.Get("c", load("c"))
+
+	assert.Equal(t, 2, c.Len())
+
+	v, _ := c.Get("b", load("b2"))
+	assert.Equal(t, "b2", v, "Least recently used entry must be evicted")
+}
+
+func TestCacheSingleflight(t *testing.T) {
+	c := ttlcache.New[int](ttlcache.Config{
+		Name: "test",
+		Size: 10,
+		TTL:  time.Minute,
+	})
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+
+	wg := sync.WaitGroup{}
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.Get("a", func() (int, error) {
+				loads.Add(1)
+				<-release
+				return 42, nil
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, 42, v)
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), loads.Load(), "Concurrent loads must be de-duplicated")
+}
+
+func TestCacheDelete(t *testing.T) {
+	c := ttlcache.New[int](ttlcache.Config{
+		Name: "test",
+		Size: 10,
+		TTL:  time.Minute,
+	})
+
+	loads := 0
+	load := func() (int, error) {
+		loads++
+		return loads, nil
+	}
+
+	c.Get("a", load)
+	c.Delete("a")
+	c.Delete("b")
+
+	v, err := c.Get("a", load)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, v, "Deleted entries must be loaded again")
+	assert.Equal(t, 1, c.Len())
+}
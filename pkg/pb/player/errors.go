@@ -18,6 +18,12 @@ var (
 	errNoActivePlayer       = errors.New("o servidor não tem um player ativo")
 
 	errSpotifyPlaylistsNotSupported = errors.New("playlists e álbuns do spotify não são suportados")
+
+	errTrackNotSeekable = errors.New("não é possível avançar ou voltar em transmissões ao vivo")
+	errSeekOutOfRange   = errors.New("a posição está fora da duração da música")
+
+	errQueueFull    = errors.New("a fila do servidor atingiu o limite de músicas")
+	errTrackBlocked = errors.New("essa música está bloqueada no servidor")
 )
 
 func ConvertError(msg string) error {
@@ -57,6 +63,14 @@ func CodeToErr(code PlayerError) error {
 		return errNoActivePlayer
 	case PlayerError_ErrSpotifyPlaylistsNotSupported:
 		return errSpotifyPlaylistsNotSupported
+	case PlayerError_ErrTrackNotSeekable:
+		return errTrackNotSeekable
+	case PlayerError_ErrSeekOutOfRange:
+		return errSeekOutOfRange
+	case PlayerError_ErrQueueFull:
+		return errQueueFull
+	case PlayerError_ErrTrackBlocked:
+		return errTrackBlocked
 	default:
 		return nil
 	}
@@ -0,0 +1,7 @@
+-- Add down migration script here
+
+DROP TABLE IF EXISTS music_playlist_track;
+
+DROP TRIGGER IF EXISTS update_music_playlist_updated_at ON music_playlist;
+
+DROP TABLE IF EXISTS music_playlist;
@@ -0,0 +1,46 @@
+-- Add up migration script here
+
+CREATE TABLE music_playlist (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamptz(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    name varchar(64) NOT NULL,
+    guild_id bigint,
+    user_id bigint,
+    created_by bigint NOT NULL,
+    CONSTRAINT music_playlist_owner_check
+        CHECK ((guild_id IS NULL) != (user_id IS NULL))
+);
+
+CREATE UNIQUE INDEX music_playlist_guild_name_idx ON music_playlist(guild_id, name)
+    WHERE guild_id IS NOT NULL;
+CREATE UNIQUE INDEX music_playlist_user_name_idx ON music_playlist(user_id, name)
+    WHERE user_id IS NOT NULL;
+
+CREATE TRIGGER update_music_playlist_updated_at
+   BEFORE UPDATE ON music_playlist FOR EACH ROW
+   EXECUTE PROCEDURE update_modified_column();
+
+CREATE TABLE music_playlist_track (
+    id bigserial PRIMARY KEY,
+    playlist_id bigint NOT NULL,
+    position int NOT NULL,
+    name text NOT NULL,
+    url text NOT NULL,
+    play_query text NOT NULL,
+    thumbnail text NOT NULL,
+    -- In milliseconds
+    duration bigint NOT NULL,
+    live boolean NOT NULL DEFAULT FALSE,
+    -- Where the playback of the track starts, in milliseconds
+    start bigint NOT NULL DEFAULT 0,
+    -- The channel or artist that published the track
+    author text NOT NULL DEFAULT ''
+);
+
+ALTER TABLE music_playlist_track ADD CONSTRAINT music_playlist_track_playlist_id_fkey
+    FOREIGN KEY (playlist_id) REFERENCES music_playlist(id)
+    ON DELETE CASCADE ON UPDATE CASCADE;
+
+CREATE INDEX music_playlist_track_position_idx
+    ON music_playlist_track(playlist_id, position);
@@ -0,0 +1,18 @@
+-- Add up migration script here
+
+CREATE TABLE music_favorite (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    user_id bigint NOT NULL,
+    name text NOT NULL,
+    url text NOT NULL,
+    play_query text NOT NULL,
+    thumbnail text NOT NULL,
+    -- In milliseconds
+    duration bigint NOT NULL,
+    live boolean NOT NULL DEFAULT FALSE,
+    -- The channel or artist that published the track
+    author text NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX music_favorite_user_url_idx ON music_favorite(user_id, url);
@@ -0,0 +1,20 @@
+-- Add up migration script here
+
+CREATE TABLE music_play_log (
+    id bigserial PRIMARY KEY,
+    -- When the track started playing
+    created_at timestamptz(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    guild_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    name text NOT NULL,
+    url text NOT NULL,
+    -- In milliseconds
+    duration bigint NOT NULL,
+    -- In milliseconds
+    listened bigint NOT NULL,
+    skipped boolean NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX music_play_log_guild_idx ON music_play_log(guild_id, created_at);
+CREATE INDEX music_play_log_guild_user_idx
+    ON music_play_log(guild_id, user_id, created_at);
@@ -0,0 +1,9 @@
+-- Add down migration script here
+
+ALTER TABLE music_config
+    DROP COLUMN IF EXISTS volume,
+    DROP COLUMN IF EXISTS idle_timeout,
+    DROP COLUMN IF EXISTS pause_timeout,
+    DROP COLUMN IF EXISTS announce_tracks,
+    DROP COLUMN IF EXISTS always_on,
+    DROP COLUMN IF EXISTS max_queue_size;
@@ -0,0 +1,12 @@
+-- Add up migration script here
+
+ALTER TABLE music_config
+    ADD COLUMN volume smallint NOT NULL DEFAULT 100,
+    -- In seconds
+    ADD COLUMN idle_timeout int NOT NULL DEFAULT 10,
+    -- In seconds
+    ADD COLUMN pause_timeout int NOT NULL DEFAULT 300,
+    ADD COLUMN announce_tracks boolean NOT NULL DEFAULT TRUE,
+    ADD COLUMN always_on boolean NOT NULL DEFAULT FALSE,
+    -- Unlimited if zero
+    ADD COLUMN max_queue_size int NOT NULL DEFAULT 0;
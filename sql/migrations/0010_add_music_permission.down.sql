@@ -0,0 +1,12 @@
+-- Add down migration script here
+
+ALTER TABLE music_config ADD COLUMN IF NOT EXISTS dj_role bigint;
+
+UPDATE music_config SET dj_role = (
+    SELECT min(role_id) FROM music_dj_role
+    WHERE music_dj_role.guild_id = music_config.guild_id
+);
+
+DROP TABLE IF EXISTS music_permission;
+DROP TABLE IF EXISTS music_dj_role;
+DROP TYPE IF EXISTS musicaction;
@@ -0,0 +1,25 @@
+-- Add up migration script here
+
+CREATE TYPE musicaction AS ENUM (
+    'play', 'skip', 'stop', 'pause', 'loop', 'volume', 'remove'
+);
+
+CREATE TABLE music_dj_role (
+    guild_id bigint NOT NULL REFERENCES music_config(guild_id) ON DELETE CASCADE,
+    role_id bigint NOT NULL,
+    PRIMARY KEY (guild_id, role_id)
+);
+
+-- Overrides the play_mode (play action) or the control_mode (other actions)
+-- of the music_config
+CREATE TABLE music_permission (
+    guild_id bigint NOT NULL REFERENCES music_config(guild_id) ON DELETE CASCADE,
+    action musicaction NOT NULL,
+    mode musicpermission NOT NULL,
+    PRIMARY KEY (guild_id, action)
+);
+
+INSERT INTO music_dj_role (guild_id, role_id)
+    SELECT guild_id, dj_role FROM music_config WHERE dj_role IS NOT NULL;
+
+ALTER TABLE music_config DROP COLUMN dj_role;
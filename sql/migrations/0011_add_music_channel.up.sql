@@ -0,0 +1,10 @@
+-- Add up migration script here
+
+-- The text and voice channels where the musics can be used, all the channels
+-- are allowed if the guild has none
+CREATE TABLE music_channel (
+    guild_id bigint NOT NULL REFERENCES music_config(guild_id) ON DELETE CASCADE,
+    channel_id bigint NOT NULL,
+    voice boolean NOT NULL,
+    PRIMARY KEY (guild_id, channel_id)
+);
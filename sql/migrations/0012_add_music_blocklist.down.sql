@@ -0,0 +1,4 @@
+-- Add down migration script here
+
+DROP TABLE IF EXISTS music_blocklist;
+DROP TYPE IF EXISTS musicblockkind;
@@ -0,0 +1,15 @@
+-- Add up migration script here
+
+CREATE TYPE musicblockkind AS ENUM ('Url', 'Channel', 'Keyword');
+
+CREATE TABLE music_blocklist (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    guild_id bigint NOT NULL REFERENCES music_config(guild_id) ON DELETE CASCADE,
+    created_by bigint NOT NULL,
+    kind musicblockkind NOT NULL,
+    pattern varchar(128) NOT NULL
+);
+
+CREATE UNIQUE INDEX music_blocklist_guild_pattern_idx
+    ON music_blocklist(guild_id, kind, lower(pattern));
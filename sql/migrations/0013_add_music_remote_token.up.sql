@@ -0,0 +1,16 @@
+-- Add up migration script here
+
+-- The access tokens of the web remote, only the sha256 of the tokens is
+-- stored
+CREATE TABLE music_remote_token (
+    token_hash bytea PRIMARY KEY,
+    created_at timestamptz(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at timestamptz(3) NOT NULL,
+    guild_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    -- The text channel where the tracks added by the remote are announced
+    channel_id bigint NOT NULL
+);
+
+CREATE INDEX music_remote_token_guild_user_idx
+    ON music_remote_token(guild_id, user_id);